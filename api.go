@@ -0,0 +1,139 @@
+package tiktok
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"golang.org/x/oauth2"
+)
+
+// APIError describes an error returned by the TikTok v2 API.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	LogID      string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s [%s]", e.Message, e.Code)
+}
+
+type apiErrorBody struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	LogID   string `json:"log_id"`
+}
+
+type apiResponse struct {
+	Data  interface{}  `json:"data"`
+	Error apiErrorBody `json:"error"`
+}
+
+// doAPIRequest sends a JSON encoded payload to a TikTok v2 endpoint using the access token of the
+// provided oauth2 token and decodes the response data into out.
+func doAPIRequest(ctx context.Context, method, endpoint string, token *oauth2.Token, payload, out interface{}) error {
+	req, err := newAPIRequest(ctx, method, endpoint, token, payload)
+	if err != nil {
+		return err
+	}
+
+	call := &Call{Operation: endpointOperation(endpoint), Request: req}
+
+	return invoke(ctx, call, func(call *Call) error {
+		response, err := sendRequest(ctx, httpClient, call.Request, token.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		defer response.Body.Close()
+
+		call.Response = response
+		if err = decodeAPIResponse(response, out); err != nil {
+			return err
+		}
+
+		call.Result = out
+
+		return nil
+	})
+}
+
+// newAPIRequest returns a request sending a JSON encoded payload to a TikTok v2 endpoint using the
+// access token of the provided oauth2 token.
+func newAPIRequest(ctx context.Context, method, endpoint string, token *oauth2.Token, payload interface{}) (*http.Request, error) {
+	if token == nil || token.AccessToken == "" {
+		return nil, fmt.Errorf("access token cannot be empty")
+	}
+
+	var reqBody io.Reader
+	if payload != nil {
+		payloadBytes, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		reqBody = bytes.NewReader(payloadBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
+
+	return req, nil
+}
+
+// decodeAPIResponse decodes the data of a TikTok v2 response into out, or returns the *APIError it
+// carries.
+func decodeAPIResponse(response *http.Response, out interface{}) error {
+	bodyBytes, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+
+	body := apiResponse{Data: out}
+	if err = json.Unmarshal(bodyBytes, &body); err != nil {
+		if response.StatusCode != http.StatusOK {
+			return &APIError{
+				StatusCode: response.StatusCode,
+				Message:    fmt.Sprintf("unexpected status code %d", response.StatusCode),
+			}
+		}
+
+		return err
+	}
+
+	if body.Error.Code != "" && body.Error.Code != "ok" {
+		return &APIError{
+			StatusCode: response.StatusCode,
+			Code:       body.Error.Code,
+			Message:    redactString(body.Error.Message),
+			LogID:      body.Error.LogID,
+		}
+	}
+
+	if response.StatusCode != http.StatusOK {
+		return &APIError{
+			StatusCode: response.StatusCode,
+			Message:    fmt.Sprintf("unexpected status code %d", response.StatusCode),
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package tiktok
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// bandwidthLimiter is a token bucket limiting the number of bytes transferred per second.
+type bandwidthLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBandwidthLimiter(bytesPerSecond int64) *bandwidthLimiter {
+	// Allow bursts of a quarter of a second so reads are not split into tiny waits.
+	burst := float64(bytesPerSecond) / 4
+	if burst < 1024 {
+		burst = 1024
+	}
+
+	return &bandwidthLimiter{
+		rate:   float64(bytesPerSecond),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// maxRead returns the number of bytes a single read should be limited to.
+func (l *bandwidthLimiter) maxRead() int {
+	return int(l.burst)
+}
+
+// wait blocks until n bytes may be transferred or the context is done.
+func (l *bandwidthLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+
+	l.mu.Unlock()
+
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// meteredReader reports every read to onRead and, if a limiter is set, throttles reads to its rate.
+type meteredReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *bandwidthLimiter
+	onRead  func(n int)
+}
+
+func (m *meteredReader) Read(p []byte) (int, error) {
+	if err := m.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	if m.limiter != nil {
+		if max := m.limiter.maxRead(); len(p) > max {
+			p = p[:max]
+		}
+	}
+
+	n, err := m.r.Read(p)
+	if n > 0 {
+		if m.limiter != nil {
+			if waitErr := m.limiter.wait(m.ctx, n); waitErr != nil {
+				return n, waitErr
+			}
+		}
+
+		if m.onRead != nil {
+			m.onRead(n)
+		}
+	}
+
+	return n, err
+}
@@ -0,0 +1,242 @@
+package tiktok
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+const (
+	// MaxCaptionLength is the maximum length of a video caption, in UTF-16 code units.
+	MaxCaptionLength = 2200
+	// MaxPhotoTitleLength is the maximum length of the title of a photo post, in UTF-16 code units.
+	MaxPhotoTitleLength = 90
+	// MaxPhotoDescriptionLength is the maximum length of the description of a photo post, which is
+	// its caption, in UTF-16 code units.
+	MaxPhotoDescriptionLength = 4000
+	// maxUsernameLength is the maximum length of a TikTok username.
+	maxUsernameLength = 24
+)
+
+// CaptionBuilder composes a post caption from text, hashtags and mentions.
+type CaptionBuilder struct {
+	text     []string
+	hashtags []string
+	mentions []string
+	err      error
+}
+
+// NewCaptionBuilder returns a new empty CaptionBuilder.
+func NewCaptionBuilder() *CaptionBuilder {
+	return &CaptionBuilder{}
+}
+
+// Text appends text to the caption. Hashtags and mentions already in the text are kept as is and
+// are not appended again.
+func (b *CaptionBuilder) Text(text string) *CaptionBuilder {
+	if text = strings.TrimSpace(text); text != "" {
+		b.text = append(b.text, text)
+	}
+
+	return b
+}
+
+// Hashtags appends hashtags to the caption, with or without the leading '#'.
+func (b *CaptionBuilder) Hashtags(tags ...string) *CaptionBuilder {
+	for _, tag := range tags {
+		normalized, err := NormalizeHashtag(tag)
+		if err != nil {
+			b.setErr(err)
+			continue
+		}
+
+		b.hashtags = append(b.hashtags, normalized)
+	}
+
+	return b
+}
+
+// Mentions appends mentions of users to the caption, with or without the leading '@'.
+func (b *CaptionBuilder) Mentions(usernames ...string) *CaptionBuilder {
+	for _, username := range usernames {
+		normalized, err := NormalizeMention(username)
+		if err != nil {
+			b.setErr(err)
+			continue
+		}
+
+		b.mentions = append(b.mentions, normalized)
+	}
+
+	return b
+}
+
+// Build returns the caption, or an error if any hashtag or mention is invalid or the caption would
+// be truncated by TikTok.
+func (b *CaptionBuilder) Build() (string, error) {
+	if b.err != nil {
+		return "", fmt.Errorf("tiktok-oauth2: CaptionBuilder: %w", b.err)
+	}
+
+	text := strings.Join(b.text, " ")
+	parts := append([]string(nil), b.text...)
+
+	seen := make(map[string]bool)
+	for _, tag := range ExtractHashtags(text) {
+		seen["#"+strings.ToLower(tag)] = true
+	}
+
+	for _, mention := range ExtractMentions(text) {
+		seen["@"+strings.ToLower(mention)] = true
+	}
+
+	for _, tag := range b.hashtags {
+		parts = appendUnseen(parts, seen, "#"+tag)
+	}
+
+	for _, mention := range b.mentions {
+		parts = appendUnseen(parts, seen, "@"+mention)
+	}
+
+	caption := strings.Join(parts, " ")
+	if err := ValidateCaption(caption); err != nil {
+		return "", fmt.Errorf("tiktok-oauth2: CaptionBuilder: %w", err)
+	}
+
+	return caption, nil
+}
+
+func (b *CaptionBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func appendUnseen(parts []string, seen map[string]bool, token string) []string {
+	key := strings.ToLower(token)
+	if seen[key] {
+		return parts
+	}
+
+	seen[key] = true
+
+	return append(parts, token)
+}
+
+// CaptionLength returns the length of a caption as counted by TikTok, in UTF-16 code units.
+func CaptionLength(caption string) int {
+	return len(utf16.Encode([]rune(caption)))
+}
+
+// ValidateCaption returns an error if the caption exceeds MaxCaptionLength.
+func ValidateCaption(caption string) error {
+	if length := CaptionLength(caption); length > MaxCaptionLength {
+		return fmt.Errorf("caption length %d exceeds %d UTF-16 code units", length, MaxCaptionLength)
+	}
+
+	return nil
+}
+
+// ValidatePhotoPost returns an error if the title of a photo post exceeds MaxPhotoTitleLength or
+// its description exceeds MaxPhotoDescriptionLength.
+func ValidatePhotoPost(title, description string) error {
+	if length := CaptionLength(title); length > MaxPhotoTitleLength {
+		return fmt.Errorf("photo title length %d exceeds %d UTF-16 code units", length, MaxPhotoTitleLength)
+	}
+
+	if length := CaptionLength(description); length > MaxPhotoDescriptionLength {
+		return fmt.Errorf("photo description length %d exceeds %d UTF-16 code units", length, MaxPhotoDescriptionLength)
+	}
+
+	return nil
+}
+
+// NormalizeHashtag strips the leading '#' of a hashtag and checks it only contains letters,
+// digits and underscores.
+func NormalizeHashtag(tag string) (string, error) {
+	tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+	if tag == "" {
+		return "", fmt.Errorf("hashtag cannot be empty")
+	}
+
+	for _, r := range tag {
+		if !isHashtagRune(r) {
+			return "", fmt.Errorf("hashtag %q contains invalid character %q", tag, r)
+		}
+	}
+
+	return tag, nil
+}
+
+// NormalizeMention strips the leading '@' of a username and checks it is a valid TikTok username.
+func NormalizeMention(username string) (string, error) {
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	if username == "" {
+		return "", fmt.Errorf("mention cannot be empty")
+	}
+
+	if len(username) > maxUsernameLength {
+		return "", fmt.Errorf("mention %q exceeds %d characters", username, maxUsernameLength)
+	}
+
+	if strings.HasSuffix(username, ".") {
+		return "", fmt.Errorf("mention %q cannot end with a period", username)
+	}
+
+	for _, r := range username {
+		if !isUsernameRune(r) {
+			return "", fmt.Errorf("mention %q contains invalid character %q", username, r)
+		}
+	}
+
+	return username, nil
+}
+
+// ExtractHashtags returns the hashtags found in a caption, without the leading '#'.
+func ExtractHashtags(caption string) []string {
+	return extractTokens(caption, '#', isHashtagRune)
+}
+
+// ExtractMentions returns the usernames mentioned in a caption, without the leading '@'.
+func ExtractMentions(caption string) []string {
+	mentions := extractTokens(caption, '@', isUsernameRune)
+	for i, mention := range mentions {
+		mentions[i] = strings.TrimRight(mention, ".")
+	}
+
+	return mentions
+}
+
+// extractTokens returns the runs of valid runes following every prefix that starts a word.
+func extractTokens(caption string, prefix rune, valid func(rune) bool) []string {
+	var tokens []string
+
+	runes := []rune(caption)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != prefix || (i > 0 && (valid(runes[i-1]) || runes[i-1] == prefix)) {
+			continue
+		}
+
+		j := i + 1
+		for j < len(runes) && valid(runes[j]) {
+			j++
+		}
+
+		if j > i+1 {
+			tokens = append(tokens, string(runes[i+1:j]))
+		}
+
+		i = j - 1
+	}
+
+	return tokens
+}
+
+func isHashtagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isUsernameRune(r rune) bool {
+	return (r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r))) || r == '_' || r == '.'
+}
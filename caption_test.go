@@ -0,0 +1,123 @@
+package tiktok_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+)
+
+func TestCaptionBuilderSuccess(t *testing.T) {
+	caption, err := tiktok.NewCaptionBuilder().
+		Text("Sunset at the beach #Summer").
+		Hashtags("#travel", "summer", "beach_life").
+		Mentions("@test.user", "other_user").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := "Sunset at the beach #Summer #travel #beach_life @test.user @other_user"
+	if caption != expected {
+		t.Fatalf("expected caption '%s', but got '%s'", expected, caption)
+	}
+}
+
+func TestCaptionBuilderError(t *testing.T) {
+	tests := []struct {
+		name          string
+		builder       *tiktok.CaptionBuilder
+		errorContains string
+	}{
+		{
+			name:          "invalid hashtag",
+			builder:       tiktok.NewCaptionBuilder().Hashtags("not-valid"),
+			errorContains: `hashtag "not-valid" contains invalid character '-'`,
+		},
+		{
+			name:          "mention ending with period",
+			builder:       tiktok.NewCaptionBuilder().Mentions("user."),
+			errorContains: `mention "user." cannot end with a period`,
+		},
+		{
+			name:          "caption too long",
+			builder:       tiktok.NewCaptionBuilder().Text(strings.Repeat("😀", 1101)),
+			errorContains: "caption length 2202 exceeds 2200 UTF-16 code units",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.errorContains) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestCaptionLength(t *testing.T) {
+	tests := []struct {
+		caption  string
+		expected int
+	}{
+		{caption: "hello", expected: 5},
+		{caption: "héllo", expected: 5},
+		{caption: "😀", expected: 2},
+	}
+
+	for _, tt := range tests {
+		if got := tiktok.CaptionLength(tt.caption); got != tt.expected {
+			t.Fatalf("expected length of '%s' to be %d, but got %d", tt.caption, tt.expected, got)
+		}
+	}
+}
+
+func TestValidatePhotoPost(t *testing.T) {
+	tests := []struct {
+		name          string
+		title         string
+		description   string
+		expectedError string
+	}{
+		{name: "at limits", title: strings.Repeat("😀", 45), description: strings.Repeat("a", 4000)},
+		{name: "title too long", title: strings.Repeat("😀", 46), expectedError: "photo title length 92 exceeds 90"},
+		{name: "description too long", description: strings.Repeat("😀", 2001), expectedError: "photo description length 4002 exceeds 4000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tiktok.ValidatePhotoPost(tt.title, tt.description)
+			if tt.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestExtractHashtagsAndMentions(t *testing.T) {
+	caption := "Day #1 at #Café_Paris with @test.user. Email me at me@example.com ##double"
+
+	hashtags := tiktok.ExtractHashtags(caption)
+	if !reflect.DeepEqual(hashtags, []string{"1", "Café_Paris"}) {
+		t.Fatalf("expected hashtags '[1 Café_Paris]', but got %v", hashtags)
+	}
+
+	mentions := tiktok.ExtractMentions(caption)
+	if !reflect.DeepEqual(mentions, []string{"test.user"}) {
+		t.Fatalf("expected mentions '[test.user]', but got %v", mentions)
+	}
+}
@@ -0,0 +1,100 @@
+// Command tiktok-mock serves the fake TikTok server of the tiktoktest package, so that apps not
+// written in Go can run their TikTok flows locally, without reaching TikTok.
+//
+// Usage:
+//
+//	tiktok-mock [-port 8080] [-fixture fixture.json] [-scenario scenario.json]
+//
+// The fixture seeds the server with apps, users and videos, in the format of tiktoktest.Fixture.
+// Without a fixture the server knows a single app, mock-client-key with secret mock-client-secret
+// accepting any redirect url, and a single user, mock-open-id. The authorize endpoint approves
+// every request on behalf of the first user, or of the user of the open_id parameter, and
+// redirects straight back to the app, so flows run headless.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2/tiktoktest"
+)
+
+func main() {
+	port := flag.Int("port", 8080, "port to listen on")
+	fixturePath := flag.String("fixture", "", "JSON fixture of the apps, users and videos to serve")
+	scenarioPath := flag.String("scenario", "", "JSON scenario of the faults to inject")
+	flag.Parse()
+
+	srv, err := newServer(*fixturePath, *scenarioPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	addr := fmt.Sprintf(":%d", *port)
+	log.Printf("tiktok-mock listening on %s", addr)
+
+	log.Fatal(http.ListenAndServe(addr, logRequests(srv)))
+}
+
+// newServer returns the fake server seeded from the fixture and scenario files, if set.
+func newServer(fixturePath, scenarioPath string) (*tiktoktest.Server, error) {
+	srv := tiktoktest.NewHandler()
+	// Nothing reads the requests of a long running mock, so they are not kept.
+	srv.MaxRequests = -1
+
+	fixture := &tiktoktest.Fixture{
+		Clients: []tiktoktest.FixtureClient{{ClientKey: "mock-client-key", ClientSecret: "mock-client-secret"}},
+		Users:   []tiktoktest.FixtureUser{{User: tiktoktest.User{OpenID: "mock-open-id", DisplayName: "Mock User"}}},
+	}
+
+	if fixturePath != "" {
+		var err error
+		if fixture, err = tiktoktest.LoadFixtureFile(fixturePath); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := srv.LoadFixture(fixture); err != nil {
+		return nil, err
+	}
+
+	if scenarioPath != "" {
+		scenario, err := tiktoktest.LoadScenarioFile(scenarioPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if err = srv.LoadScenario(scenario); err != nil {
+			return nil, err
+		}
+	}
+
+	return srv, nil
+}
+
+// statusRecorder records the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests logs the method, path, status and duration of every request.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rec, r)
+
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, rec.status, time.Since(start))
+	})
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"golang.org/x/oauth2"
+)
+
+// routingTransport sends every request to the target server.
+type routingTransport struct {
+	target *url.URL
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *routingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	routed := req.Clone(req.Context())
+	routed.URL.Scheme, routed.URL.Host, routed.Host = t.target.Scheme, t.target.Host, ""
+
+	return http.DefaultTransport.RoundTrip(routed)
+}
+
+func TestNewServerDefaultFixture(t *testing.T) {
+	srv, err := newServer("", "")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ts := httptest.NewServer(logRequests(srv))
+	t.Cleanup(ts.Close)
+
+	target, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := &http.Client{
+		Transport: &routingTransport{target: target},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+
+	cfg, err := tiktok.NewConfig("mock-client-key", "mock-client-secret", "https://example.com/callback", "user.info.basic")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := client.Get(cfg.AuthCodeURL("test-state"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	response.Body.Close()
+
+	redirect, err := response.Location()
+	if err != nil {
+		t.Fatalf("expected redirect, but got status code %d", response.StatusCode)
+	}
+
+	token, err := tiktok.ConfigExchange(ctx, cfg, redirect.Query().Get("code"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	user, err := tiktok.RetrieveUserInfo(ctx, token)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if user.OpenID != "mock-open-id" || user.DisplayName != "Mock User" {
+		t.Fatalf("expected user 'mock-open-id' named 'Mock User', but got %+v", user)
+	}
+
+	clientToken, err := tiktok.ClientCredentialsToken(ctx, "mock-client-key", "mock-client-secret")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Client access tokens have no user to post as.
+	_, err = tiktok.QueryCreatorInfo(ctx, clientToken)
+
+	var apiErr *tiktok.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected api error, but got '%v'", err)
+	}
+}
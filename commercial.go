@@ -0,0 +1,345 @@
+package tiktok
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// AdFields are the ad fields requested from the Commercial Content API.
+var AdFields = []string{
+	"ad.id", "ad.first_shown_date", "ad.last_shown_date", "ad.status", "ad.status_statement", "ad.videos",
+	"ad.image_urls", "ad.reach", "advertiser.business_id", "advertiser.business_name", "advertiser.paid_for_by",
+}
+
+// AdvertiserFields are the advertiser fields requested from the Commercial Content API.
+var AdvertiserFields = []string{"business_id", "business_name", "country_code"}
+
+// adReachSizes are the unique users seen buckets accepted by the Commercial Content API, in order.
+var adReachSizes = []string{"0", "1K", "10K", "100K", "1M", "10M"}
+
+// Ad is an ad of the TikTok ad library.
+type Ad struct {
+	ID              int64     `json:"id"`
+	FirstShownDate  string    `json:"first_shown_date"`
+	LastShownDate   string    `json:"last_shown_date"`
+	Status          string    `json:"status"`
+	StatusStatement string    `json:"status_statement"`
+	Videos          []AdVideo `json:"videos"`
+	ImageURLs       []string  `json:"image_urls"`
+	Reach           AdReach   `json:"reach"`
+}
+
+// AdVideo is a video of an ad.
+type AdVideo struct {
+	URL           string `json:"url"`
+	CoverImageURL string `json:"cover_image_url"`
+}
+
+// AdReach describes the audience an ad reached.
+type AdReach struct {
+	UniqueUsersSeen string `json:"unique_users_seen"`
+}
+
+// Advertiser is an advertiser of the TikTok ad library.
+type Advertiser struct {
+	BusinessID   int64  `json:"business_id"`
+	BusinessName string `json:"business_name"`
+	PaidForBy    string `json:"paid_for_by"`
+	CountryCode  string `json:"country_code"`
+}
+
+// AdResult is an ad along with its advertiser.
+type AdResult struct {
+	Ad         Ad         `json:"ad"`
+	Advertiser Advertiser `json:"advertiser"`
+}
+
+// AdQuery is a search of the TikTok ad library.
+type AdQuery struct {
+	request adQueryRequest
+}
+
+// AdQueryBuilder builds an AdQuery.
+type AdQueryBuilder struct {
+	filters    adFilters
+	searchTerm string
+	err        error
+}
+
+// NewAdQuery returns a new empty AdQueryBuilder.
+func NewAdQuery() *AdQueryBuilder {
+	return &AdQueryBuilder{}
+}
+
+// SearchTerm matches ads containing the term.
+func (b *AdQueryBuilder) SearchTerm(term string) *AdQueryBuilder {
+	b.searchTerm = term
+	return b
+}
+
+// Advertisers matches ads of any of the advertisers.
+func (b *AdQueryBuilder) Advertisers(businessIDs ...int64) *AdQueryBuilder {
+	b.filters.AdvertiserBusinessIDs = append(b.filters.AdvertiserBusinessIDs, businessIDs...)
+	return b
+}
+
+// PublishedBetween matches ads published between the dates, both inclusive.
+func (b *AdQueryBuilder) PublishedBetween(start, end time.Time) *AdQueryBuilder {
+	start, end = truncateDate(start), truncateDate(end)
+	if end.Before(start) {
+		b.setErr(fmt.Errorf("end date cannot be before start date"))
+		return b
+	}
+
+	b.filters.AdPublishedDateRange = &valueRange{Min: start.Format(researchDateLayout), Max: end.Format(researchDateLayout)}
+
+	return b
+}
+
+// Country matches ads shown in the country, an ISO 3166-1 alpha-2 code, or "ALL".
+func (b *AdQueryBuilder) Country(code string) *AdQueryBuilder {
+	if code != "ALL" && (len(code) != 2 || code[0] < 'A' || code[0] > 'Z' || code[1] < 'A' || code[1] > 'Z') {
+		b.setErr(fmt.Errorf("country code %q is not an uppercase ISO 3166-1 alpha-2 code", code))
+		return b
+	}
+
+	b.filters.CountryCode = code
+
+	return b
+}
+
+// ReachBetween matches ads seen by a number of unique users between the sizes, one of "0", "1K",
+// "10K", "100K", "1M" and "10M".
+func (b *AdQueryBuilder) ReachBetween(min, max string) *AdQueryBuilder {
+	minIndex, maxIndex := indexOfString(adReachSizes, min), indexOfString(adReachSizes, max)
+	if minIndex < 0 || maxIndex < 0 {
+		b.setErr(fmt.Errorf("reach sizes must be one of %v", adReachSizes))
+		return b
+	}
+
+	if maxIndex < minIndex {
+		b.setErr(fmt.Errorf("reach max %s cannot be below min %s", max, min))
+		return b
+	}
+
+	b.filters.UniqueUsersSeenSizeRange = &valueRange{Min: min, Max: max}
+
+	return b
+}
+
+// Build validates the filters and returns the query. TikTok requires a publication date range
+// and a country.
+func (b *AdQueryBuilder) Build() (*AdQuery, error) {
+	if b.err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: AdQueryBuilder: %w", b.err)
+	}
+
+	if b.filters.AdPublishedDateRange == nil {
+		return nil, fmt.Errorf("tiktok-oauth2: AdQueryBuilder: publication date range cannot be empty")
+	}
+
+	if b.filters.CountryCode == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: AdQueryBuilder: country cannot be empty")
+	}
+
+	return &AdQuery{request: adQueryRequest{Filters: b.filters, SearchTerm: b.searchTerm}}, nil
+}
+
+func (b *AdQueryBuilder) setErr(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+// AdPage is a page of ad library search results. When HasMore is set, the next page is requested
+// with the SearchID of this page.
+type AdPage struct {
+	Ads      []AdResult
+	HasMore  bool
+	SearchID string
+}
+
+// QueryAds returns a page of the ads matching the query, using a client access token. MaxCount
+// defaults to 10 and may be up to 50.
+func QueryAds(ctx context.Context, token *oauth2.Token, query *AdQuery, maxCount int, searchID string) (*AdPage, error) {
+	page, err := queryAds(ctx, token, query, maxCount, searchID)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryAds: %w", err)
+	}
+
+	return page, nil
+}
+
+func queryAds(ctx context.Context, token *oauth2.Token, query *AdQuery, maxCount int, searchID string) (*AdPage, error) {
+	if query == nil {
+		return nil, fmt.Errorf("query cannot be nil")
+	}
+
+	if maxCount < 0 || maxCount > 50 {
+		return nil, fmt.Errorf("max count must be between 0 (default) and 50")
+	}
+
+	req := query.request
+	req.MaxCount, req.SearchID = maxCount, searchID
+
+	var data adQueryData
+	if err := doAPIRequest(ctx, http.MethodPost, withFields(endpointAdQuery, AdFields), token, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &AdPage{Ads: data.Ads, HasMore: data.HasMore, SearchID: data.SearchID}, nil
+}
+
+// AdIterator iterates over all the ads matching an ad library query.
+type AdIterator struct {
+	// OnPage, if set, is called with every page fetched. Returning an error stops the iteration
+	// and the error is reported by Err.
+	OnPage func(*AdPage) error
+
+	*researchPager
+	ads []AdResult
+}
+
+// NewAdIterator returns a new AdIterator over the ads matching the query.
+func NewAdIterator(ctx context.Context, token *oauth2.Token, query *AdQuery, maxCount int) *AdIterator {
+	it := &AdIterator{}
+
+	it.researchPager = &researchPager{
+		name:           "AdIterator",
+		ctx:            ctx,
+		searchIDCursor: true,
+		fetch: func(ctx context.Context, _ int64, searchID string) (researchPage, error) {
+			page, err := queryAds(ctx, token, query, maxCount, searchID)
+			if err != nil {
+				return researchPage{}, err
+			}
+
+			if it.OnPage != nil {
+				if err = it.OnPage(page); err != nil {
+					return researchPage{}, err
+				}
+			}
+
+			it.ads = page.Ads
+
+			return researchPage{count: len(page.Ads), hasMore: page.HasMore, searchID: page.SearchID}, nil
+		},
+	}
+
+	return it
+}
+
+// Ad returns the current ad.
+func (it *AdIterator) Ad() AdResult {
+	return it.ads[it.index]
+}
+
+// QueryAdDetails returns the details of an ad of the ad library.
+func QueryAdDetails(ctx context.Context, token *oauth2.Token, adID int64) (*AdResult, error) {
+	if adID == 0 {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryAdDetails: ad id cannot be empty")
+	}
+
+	var data AdResult
+	if err := doAPIRequest(ctx, http.MethodPost, withFields(endpointAdDetail, AdFields), token, adDetailRequest{AdID: adID}, &data); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryAdDetails: %w", err)
+	}
+
+	return &data, nil
+}
+
+// AdvertiserPage is a page of advertiser search results. When HasMore is set, the next page is
+// requested with the SearchID of this page.
+type AdvertiserPage struct {
+	Advertisers []Advertiser
+	HasMore     bool
+	SearchID    string
+}
+
+// QueryAdvertisers returns a page of the advertisers matching the search term. MaxCount defaults
+// to 10 and may be up to 50.
+func QueryAdvertisers(ctx context.Context, token *oauth2.Token, searchTerm string, maxCount int, searchID string) (*AdvertiserPage, error) {
+	page, err := queryAdvertisers(ctx, token, searchTerm, maxCount, searchID)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryAdvertisers: %w", err)
+	}
+
+	return page, nil
+}
+
+func queryAdvertisers(ctx context.Context, token *oauth2.Token, searchTerm string, maxCount int, searchID string) (*AdvertiserPage, error) {
+	if searchTerm == "" {
+		return nil, fmt.Errorf("search term cannot be empty")
+	}
+
+	if maxCount < 0 || maxCount > 50 {
+		return nil, fmt.Errorf("max count must be between 0 (default) and 50")
+	}
+
+	req := advertiserQueryRequest{SearchTerm: searchTerm, MaxCount: maxCount, SearchID: searchID}
+
+	var data advertiserQueryData
+	if err := doAPIRequest(ctx, http.MethodPost, withFields(endpointAdvertiserQuery, AdvertiserFields), token, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &AdvertiserPage{Advertisers: data.Advertisers, HasMore: data.HasMore, SearchID: data.SearchID}, nil
+}
+
+// AdvertiserIterator iterates over all the advertisers matching a search term.
+type AdvertiserIterator struct {
+	// OnPage, if set, is called with every page fetched. Returning an error stops the iteration
+	// and the error is reported by Err.
+	OnPage func(*AdvertiserPage) error
+
+	*researchPager
+	advertisers []Advertiser
+}
+
+// NewAdvertiserIterator returns a new AdvertiserIterator over the advertisers matching the term.
+func NewAdvertiserIterator(ctx context.Context, token *oauth2.Token, searchTerm string, maxCount int) *AdvertiserIterator {
+	it := &AdvertiserIterator{}
+
+	it.researchPager = &researchPager{
+		name:           "AdvertiserIterator",
+		ctx:            ctx,
+		searchIDCursor: true,
+		fetch: func(ctx context.Context, _ int64, searchID string) (researchPage, error) {
+			page, err := queryAdvertisers(ctx, token, searchTerm, maxCount, searchID)
+			if err != nil {
+				return researchPage{}, err
+			}
+
+			if it.OnPage != nil {
+				if err = it.OnPage(page); err != nil {
+					return researchPage{}, err
+				}
+			}
+
+			it.advertisers = page.Advertisers
+
+			return researchPage{count: len(page.Advertisers), hasMore: page.HasMore, searchID: page.SearchID}, nil
+		},
+	}
+
+	return it
+}
+
+// Advertiser returns the current advertiser.
+func (it *AdvertiserIterator) Advertiser() Advertiser {
+	return it.advertisers[it.index]
+}
+
+func indexOfString(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+
+	return -1
+}
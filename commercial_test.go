@@ -0,0 +1,310 @@
+package tiktok_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+const (
+	endpointAdQuery         = "https://open.tiktokapis.com/v2/research/adlib/ad/query/"
+	endpointAdDetail        = "https://open.tiktokapis.com/v2/research/adlib/ad/detail/"
+	endpointAdvertiserQuery = "https://open.tiktokapis.com/v2/research/adlib/advertiser/query/"
+)
+
+// registerTestSearchIDPages serves two pages of two items each under the given list key, paging
+// with a new search id per page.
+func registerTestSearchIDPages(t *testing.T, endpoint, listKey, item string) *[]string {
+	t.Helper()
+
+	var received []string
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpoint,
+		func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				SearchID string `json:"search_id"`
+			}
+
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+
+			received = append(received, body.SearchID)
+
+			first, searchID, hasMore := 1, "page-2", true
+			if body.SearchID == "page-2" {
+				first, searchID, hasMore = 3, "", false
+			}
+
+			response := fmt.Sprintf(
+				`{"data":{"%s":[%s,%s],"search_id":"%s","has_more":%t},"error":{"code":"ok"}}`,
+				listKey, fmt.Sprintf(item, first), fmt.Sprintf(item, first+1), searchID, hasMore,
+			)
+
+			return httpmock.NewStringResponse(http.StatusOK, response), nil
+		},
+	)
+
+	return &received
+}
+
+func testAdQuery(t *testing.T) *tiktok.AdQuery {
+	t.Helper()
+
+	query, err := tiktok.NewAdQuery().
+		SearchTerm("coffee").
+		PublishedBetween(testDate(t, "2023-01-01"), testDate(t, "2023-01-31")).
+		Country("FR").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return query
+}
+
+func TestAdQueryBuilderInvalidArguments(t *testing.T) {
+	start, end := testDate(t, "2023-01-01"), testDate(t, "2023-01-31")
+
+	tests := []struct {
+		name          string
+		builder       *tiktok.AdQueryBuilder
+		expectedError string
+	}{
+		{
+			name:          "Missing date range",
+			builder:       tiktok.NewAdQuery().Country("FR"),
+			expectedError: "publication date range cannot be empty",
+		},
+		{
+			name:          "Missing country",
+			builder:       tiktok.NewAdQuery().PublishedBetween(start, end),
+			expectedError: "country cannot be empty",
+		},
+		{
+			name:          "Reversed date range",
+			builder:       tiktok.NewAdQuery().PublishedBetween(end, start).Country("FR"),
+			expectedError: "end date cannot be before start date",
+		},
+		{
+			name:          "Invalid country",
+			builder:       tiktok.NewAdQuery().PublishedBetween(start, end).Country("fr"),
+			expectedError: `country code "fr" is not an uppercase ISO 3166-1 alpha-2 code`,
+		},
+		{
+			name:          "Unknown reach size",
+			builder:       tiktok.NewAdQuery().PublishedBetween(start, end).Country("FR").ReachBetween("0", "5K"),
+			expectedError: "reach sizes must be one of",
+		},
+		{
+			name:          "Reversed reach range",
+			builder:       tiktok.NewAdQuery().PublishedBetween(start, end).Country("FR").ReachBetween("1M", "1K"),
+			expectedError: "reach max 1K cannot be below min 1M",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestQueryAdsSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	var body string
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointAdQuery,
+		func(req *http.Request) (*http.Response, error) {
+			var raw json.RawMessage
+			if err := json.NewDecoder(req.Body).Decode(&raw); err != nil {
+				return nil, err
+			}
+
+			body = string(raw)
+
+			response := `{"data":{"ads":[{"ad":{"id":1,"status":"active","videos":[{"url":"test-url"}]},"advertiser":{"business_id":9,"business_name":"test-business"}}],"search_id":"test-search-id","has_more":true},"error":{"code":"ok"}}`
+
+			return httpmock.NewStringResponse(http.StatusOK, response), nil
+		},
+	)
+
+	query, err := tiktok.NewAdQuery().
+		Advertisers(9).
+		PublishedBetween(testDate(t, "2023-01-01"), testDate(t, "2023-01-31")).
+		Country("ALL").
+		ReachBetween("1K", "10M").
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	page, err := tiktok.QueryAds(context.Background(), testNewOauthToken(t), query, 10, "")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expectedBody := `{"filters":{"advertiser_business_ids":[9],"ad_published_date_range":{"min":"20230101","max":"20230131"},"country_code":"ALL","unique_users_seen_size_range":{"min":"1K","max":"10M"}},"max_count":10}`
+	if body != expectedBody {
+		t.Fatalf("expected request body '%s', but got '%s'", expectedBody, body)
+	}
+
+	if len(page.Ads) != 1 || page.Ads[0].Ad.ID != 1 || page.Ads[0].Advertiser.BusinessName != "test-business" || !page.HasMore || page.SearchID != "test-search-id" {
+		t.Fatalf("unexpected page %+v", page)
+	}
+}
+
+func TestQueryAdsInvalidArguments(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         *tiktok.AdQuery
+		maxCount      int
+		expectedError string
+	}{
+		{
+			name:          "Nil query",
+			expectedError: "query cannot be nil",
+		},
+		{
+			name:          "Max count too large",
+			query:         testAdQuery(t),
+			maxCount:      51,
+			expectedError: "max count must be between 0 (default) and 50",
+		},
+		{
+			name:          "Negative max count",
+			query:         testAdQuery(t),
+			maxCount:      -1,
+			expectedError: "max count must be between 0 (default) and 50",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tiktok.QueryAds(context.Background(), testNewOauthToken(t), tt.query, tt.maxCount, "")
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestAdIteratorSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	received := registerTestSearchIDPages(t, endpointAdQuery, "ads", `{"ad":{"id":%d}}`)
+
+	it := tiktok.NewAdIterator(context.Background(), testNewOauthToken(t), testAdQuery(t), 2)
+
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.Ad().Ad.ID)
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fmt.Sprint(ids) != "[1 2 3 4]" {
+		t.Fatalf("expected ad ids '[1 2 3 4]', but got %v", ids)
+	}
+
+	if fmt.Sprintf("%q", *received) != `["" "page-2"]` {
+		t.Fatalf("expected search ids '[\"\" \"page-2\"]', but got %q", *received)
+	}
+}
+
+func TestAdIteratorUnchangedSearchID(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointAdQuery,
+		httpmock.NewStringResponder(http.StatusOK, `{"data":{"ads":[{"ad":{"id":1}}],"search_id":"","has_more":true},"error":{"code":"ok"}}`),
+	)
+
+	it := tiktok.NewAdIterator(context.Background(), testNewOauthToken(t), testAdQuery(t), 1)
+	for it.Next() {
+	}
+
+	expectedError := `search id did not change from ""`
+	if err := it.Err(); err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("expected error to contain '%s', but got '%v'", expectedError, err)
+	}
+}
+
+func TestQueryAdDetailsSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointAdDetail,
+		httpmock.NewStringResponder(http.StatusOK, `{"data":{"ad":{"id":1,"reach":{"unique_users_seen":"10K"}},"advertiser":{"business_id":9}},"error":{"code":"ok"}}`),
+	)
+
+	details, err := tiktok.QueryAdDetails(context.Background(), testNewOauthToken(t), 1)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if details.Ad.Reach.UniqueUsersSeen != "10K" || details.Advertiser.BusinessID != 9 {
+		t.Fatalf("unexpected ad details %+v", details)
+	}
+}
+
+func TestAdvertiserIteratorSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	registerTestSearchIDPages(t, endpointAdvertiserQuery, "advertisers", `{"business_id":%d}`)
+
+	it := tiktok.NewAdvertiserIterator(context.Background(), testNewOauthToken(t), "coffee", 2)
+
+	pages := 0
+	it.OnPage = func(*tiktok.AdvertiserPage) error {
+		pages++
+		return nil
+	}
+
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.Advertiser().BusinessID)
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fmt.Sprint(ids) != "[1 2 3 4]" || pages != 2 {
+		t.Fatalf("expected advertiser ids '[1 2 3 4]' in 2 pages, but got %v in %d", ids, pages)
+	}
+}
+
+func TestQueryAdvertisersInvalidArguments(t *testing.T) {
+	_, err := tiktok.QueryAdvertisers(context.Background(), testNewOauthToken(t), "", 10, "")
+
+	expectedError := "search term cannot be empty"
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("expected error to contain '%s', but got '%v'", expectedError, err)
+	}
+}
@@ -0,0 +1,494 @@
+package tiktok
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DataFormat is the format of the files of a user data archive.
+type DataFormat string
+
+// Formats of a user data archive.
+const (
+	DataFormatJSON DataFormat = "json"
+	DataFormatText DataFormat = "text"
+)
+
+// DataCategory is a category of user data that can be requested through the Data Portability API.
+type DataCategory string
+
+// Categories of user data.
+const (
+	DataCategoryAll            DataCategory = "all_data"
+	DataCategoryActivity       DataCategory = "activity"
+	DataCategoryDirectMessages DataCategory = "direct_messages"
+	DataCategoryProfile        DataCategory = "profile"
+	DataCategoryVideo          DataCategory = "video"
+	DataCategoryAdsAndData     DataCategory = "ads_and_data"
+	DataCategoryAppSettings    DataCategory = "app_settings"
+	DataCategoryComment        DataCategory = "comment"
+	DataCategoryLive           DataCategory = "tiktok_live"
+	DataCategoryShop           DataCategory = "tiktok_shop"
+)
+
+// DataRequestStatus is the state of a user data archive request.
+type DataRequestStatus string
+
+// Data request states as reported by TikTok. TikTok reports an archive ready to be downloaded as
+// "downloading".
+const (
+	DataRequestStatusPending   DataRequestStatus = "pending"
+	DataRequestStatusReady     DataRequestStatus = "downloading"
+	DataRequestStatusExpired   DataRequestStatus = "expired"
+	DataRequestStatusCancelled DataRequestStatus = "cancelled"
+)
+
+// Done reports whether the status is final, i.e. the archive is ready or will never be.
+func (s DataRequestStatus) Done() bool {
+	return s == DataRequestStatusReady || s == DataRequestStatusExpired || s == DataRequestStatusCancelled
+}
+
+// DataRequestInfo holds the current state of a user data archive request.
+type DataRequestInfo struct {
+	RequestID   int64
+	Status      DataRequestStatus
+	ApplyTime   time.Time
+	CollectTime time.Time
+	Format      DataFormat
+	Categories  []DataCategory
+}
+
+// DataRequestError is returned when a user data archive request expires or is cancelled before
+// it is downloaded.
+type DataRequestError struct {
+	RequestID int64
+	Status    DataRequestStatus
+}
+
+// Error implements the error interface.
+func (e *DataRequestError) Error() string {
+	return fmt.Sprintf("data request %d is %s", e.RequestID, e.Status)
+}
+
+// DataWaitOptions configures the polling behaviour of WaitForUserData.
+type DataWaitOptions struct {
+	// InitialInterval is the delay before the second status check. Defaults to 1 minute.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between two status checks. Defaults to 15 minutes.
+	MaxInterval time.Duration
+	// Multiplier is applied to the delay after every status check. Defaults to 2.
+	Multiplier float64
+	// Timeout is the maximum total time to wait. Zero means wait until the context is done.
+	Timeout time.Duration
+	// OnProgress, if set, is called with every status fetched.
+	OnProgress func(*DataRequestInfo)
+}
+
+// DownloadOptions configures DownloadUserData.
+type DownloadOptions struct {
+	// MaxRetries is the number of times an interrupted download is resumed before giving up.
+	// Defaults to 3.
+	MaxRetries int
+	// RetryInterval is the time to wait before the first retry, doubled after every retry up to a
+	// minute. Defaults to a second.
+	RetryInterval time.Duration
+	// OnProgress, if set, is called as the archive is written with the bytes written so far and the
+	// total size of the archive, or -1 when TikTok does not report it.
+	OnProgress func(written, total int64)
+}
+
+// ArchiveFile describes a file of a downloaded user data archive.
+type ArchiveFile struct {
+	Name     string
+	Size     int64
+	Modified time.Time
+}
+
+// RequestUserData submits a request for an archive of the selected categories of data of the user
+// of the token, as obtained by ConfigExchange with the portability scopes, and returns the request
+// id. Categories default to DataCategoryAll.
+func RequestUserData(ctx context.Context, token *oauth2.Token, format DataFormat, categories ...DataCategory) (int64, error) {
+	if format != DataFormatJSON && format != DataFormatText {
+		return 0, fmt.Errorf("tiktok-oauth2: RequestUserData: unsupported data format %q", format)
+	}
+
+	if len(categories) == 0 {
+		categories = []DataCategory{DataCategoryAll}
+	}
+
+	req := dataAddRequest{DataFormat: format, CategorySelectionList: categories}
+
+	var data dataAddData
+	if err := doAPIRequest(ctx, http.MethodPost, withFields(endpointDataAdd, []string{"request_id"}), token, req, &data); err != nil {
+		return 0, fmt.Errorf("tiktok-oauth2: RequestUserData: %w", err)
+	}
+
+	return data.RequestID, nil
+}
+
+// FetchUserDataStatus returns the current status of a user data archive request.
+func FetchUserDataStatus(ctx context.Context, token *oauth2.Token, requestID int64) (*DataRequestInfo, error) {
+	info, err := fetchUserDataStatus(ctx, token, requestID)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: FetchUserDataStatus: %w", err)
+	}
+
+	return info, nil
+}
+
+// fetchUserDataStatus returns the current status of a user data archive request, with errors not
+// prefixed, for FetchUserDataStatus and WaitForUserData to prefix with their own names.
+func fetchUserDataStatus(ctx context.Context, token *oauth2.Token, requestID int64) (*DataRequestInfo, error) {
+	if requestID == 0 {
+		return nil, fmt.Errorf("request id cannot be empty")
+	}
+
+	fields := []string{"request_id", "status", "apply_time", "collect_time", "data_format", "category_selection_list"}
+
+	var data dataStatusData
+	if err := doAPIRequest(ctx, http.MethodPost, withFields(endpointDataStatus, fields), token, dataRequestIDRequest{RequestID: requestID}, &data); err != nil {
+		return nil, err
+	}
+
+	info := &DataRequestInfo{
+		RequestID:  requestID,
+		Status:     DataRequestStatus(data.Status),
+		Format:     data.DataFormat,
+		Categories: data.CategorySelectionList,
+	}
+
+	if data.ApplyTime > 0 {
+		info.ApplyTime = time.Unix(data.ApplyTime, 0)
+	}
+
+	if data.CollectTime > 0 {
+		info.CollectTime = time.Unix(data.CollectTime, 0)
+	}
+
+	return info, nil
+}
+
+// CancelUserDataRequest cancels a pending user data archive request.
+func CancelUserDataRequest(ctx context.Context, token *oauth2.Token, requestID int64) error {
+	if requestID == 0 {
+		return fmt.Errorf("tiktok-oauth2: CancelUserDataRequest: request id cannot be empty")
+	}
+
+	if err := doAPIRequest(ctx, http.MethodPost, endpointDataCancel, token, dataRequestIDRequest{RequestID: requestID}, nil); err != nil {
+		return fmt.Errorf("tiktok-oauth2: CancelUserDataRequest: %w", err)
+	}
+
+	return nil
+}
+
+// WaitForUserData polls the status of a user data archive request until the archive is ready to
+// be downloaded. When the request expires or is cancelled the last status is returned along with a
+// *DataRequestError.
+func WaitForUserData(ctx context.Context, token *oauth2.Token, requestID int64, opts *DataWaitOptions) (*DataRequestInfo, error) {
+	if opts == nil {
+		opts = &DataWaitOptions{}
+	}
+
+	backoff := newPollBackoff(opts.InitialInterval, opts.MaxInterval, opts.Multiplier, time.Minute, time.Minute*15)
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	for {
+		info, err := fetchUserDataStatus(ctx, token, requestID)
+		if err != nil {
+			return nil, fmt.Errorf("tiktok-oauth2: WaitForUserData: %w", err)
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(info)
+		}
+
+		switch info.Status {
+		case DataRequestStatusReady:
+			return info, nil
+		case DataRequestStatusExpired, DataRequestStatusCancelled:
+			return info, fmt.Errorf("tiktok-oauth2: WaitForUserData: %w", &DataRequestError{RequestID: requestID, Status: info.Status})
+		}
+
+		if err = backoff.wait(ctx); err != nil {
+			return info, fmt.Errorf("tiktok-oauth2: WaitForUserData: %w", err)
+		}
+	}
+}
+
+// DownloadUserData streams the archive of a ready user data request to the file at path. The
+// archive is written to path with a ".part" suffix first, so an interrupted download, within the
+// call or after a restart, resumes from the bytes already written, and is renamed to path once
+// complete. The id of the request is kept with a ".part.request" suffix, and a partial file of
+// another request is discarded.
+func DownloadUserData(ctx context.Context, token *oauth2.Token, requestID int64, path string, opts *DownloadOptions) error {
+	if requestID == 0 {
+		return fmt.Errorf("tiktok-oauth2: DownloadUserData: request id cannot be empty")
+	}
+
+	if path == "" {
+		return fmt.Errorf("tiktok-oauth2: DownloadUserData: path cannot be empty")
+	}
+
+	if opts == nil {
+		opts = &DownloadOptions{}
+	}
+
+	maxRetries := opts.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 3
+	}
+
+	partPath, requestPath := path+".part", path+".part.request"
+
+	if err := preparePartialFile(partPath, requestPath, requestID); err != nil {
+		return fmt.Errorf("tiktok-oauth2: DownloadUserData: %w", err)
+	}
+
+	backoff := newPollBackoff(opts.RetryInterval, time.Minute, 2, time.Second, time.Minute)
+
+	for attempt := 0; ; attempt++ {
+		err := downloadUserDataPart(ctx, token, requestID, partPath, opts.OnProgress)
+		if err == nil {
+			break
+		}
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) || ctx.Err() != nil || attempt >= maxRetries {
+			return fmt.Errorf("tiktok-oauth2: DownloadUserData: %w", err)
+		}
+
+		if err = backoff.wait(ctx); err != nil {
+			return fmt.Errorf("tiktok-oauth2: DownloadUserData: %w", err)
+		}
+	}
+
+	if err := os.Rename(partPath, path); err != nil {
+		return fmt.Errorf("tiktok-oauth2: DownloadUserData: %w", err)
+	}
+
+	if err := os.Remove(requestPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("tiktok-oauth2: DownloadUserData: %w", err)
+	}
+
+	return nil
+}
+
+// preparePartialFile discards the partial file unless it was written for the request, and records
+// the request it is written for.
+func preparePartialFile(partPath, requestPath string, requestID int64) error {
+	id := strconv.FormatInt(requestID, 10)
+
+	data, err := ioutil.ReadFile(requestPath)
+	if err == nil && string(data) == id {
+		return nil
+	}
+
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err = os.Remove(partPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return writeFileAtomic(requestPath, []byte(id))
+}
+
+// downloadUserDataPart appends the rest of the archive to the partial file at partPath.
+func downloadUserDataPart(ctx context.Context, token *oauth2.Token, requestID int64, partPath string, onProgress func(written, total int64)) error {
+	file, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	offset, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+
+	req, err := newAPIRequest(ctx, http.MethodPost, endpointDataDownload, token, dataRequestIDRequest{RequestID: requestID})
+	if err != nil {
+		return err
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", "bytes="+strconv.FormatInt(offset, 10)+"-")
+	}
+
+	call := &Call{Operation: OperationDataDownload, Request: req}
+
+	return invoke(ctx, call, func(call *Call) error {
+		response, err := sendRequest(ctx, uploadHTTPClient, call.Request, token.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		defer response.Body.Close()
+
+		call.Response = response
+
+		return writeArchive(file, response, offset, onProgress)
+	})
+}
+
+// writeArchive writes the archive of a download response to the partial file, which holds offset
+// bytes already. When the response does not continue the partial file, the file is truncated and
+// an error returned, so the download starts over.
+func writeArchive(file *os.File, response *http.Response, offset int64, onProgress func(written, total int64)) error {
+	var err error
+
+	total := int64(-1)
+
+	switch response.StatusCode {
+	case http.StatusPartialContent:
+		start, size, ok := parseContentRange(response.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return restartArchive(file, fmt.Errorf("unexpected content range %q for offset %d", response.Header.Get("Content-Range"), offset))
+		}
+
+		total = size
+	case http.StatusOK:
+		// The range was ignored, so the archive is written again from the start.
+		if offset > 0 {
+			if offset, err = 0, file.Truncate(0); err != nil {
+				return err
+			}
+
+			if _, err = file.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file holds the whole archive only if it has the size of the archive.
+		_, size, ok := parseContentRange(response.Header.Get("Content-Range"))
+		if ok && size == offset {
+			return nil
+		}
+
+		return restartArchive(file, fmt.Errorf("partial file of %d bytes does not match content range %q", offset, response.Header.Get("Content-Range")))
+	default:
+		return decodeAPIResponse(response, nil)
+	}
+
+	if total < 0 && response.ContentLength >= 0 {
+		total = offset + response.ContentLength
+	}
+
+	written := offset
+	buf := make([]byte, 32*1024)
+
+	for {
+		n, readErr := response.Body.Read(buf)
+		if n > 0 {
+			if _, err = file.Write(buf[:n]); err != nil {
+				return err
+			}
+
+			written += int64(n)
+			if onProgress != nil {
+				onProgress(written, total)
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+
+		if readErr != nil {
+			return readErr
+		}
+	}
+
+	if total >= 0 && written != total {
+		return fmt.Errorf("archive truncated at %d of %d bytes", written, total)
+	}
+
+	return file.Sync()
+}
+
+// restartArchive truncates the partial file and returns the error, so the download is retried
+// from the start.
+func restartArchive(file *os.File, err error) error {
+	if truncErr := file.Truncate(0); truncErr != nil {
+		return truncErr
+	}
+
+	return err
+}
+
+// parseContentRange returns the start and the total size of a "bytes start-end/total" or
+// "bytes */total" Content-Range header. The start is -1 for the latter and the total -1 when
+// unknown.
+func parseContentRange(header string) (start, total int64, ok bool) {
+	if !strings.HasPrefix(header, "bytes ") {
+		return 0, 0, false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(header, "bytes "), "/", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+
+	total = -1
+	if parts[1] != "*" {
+		var err error
+		if total, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	if parts[0] == "*" {
+		return -1, total, true
+	}
+
+	bounds := strings.SplitN(parts[0], "-", 2)
+	if len(bounds) != 2 {
+		return 0, 0, false
+	}
+
+	start, err := strconv.ParseInt(bounds[0], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return start, total, true
+}
+
+// ListArchiveFiles returns the files of a user data archive downloaded by DownloadUserData.
+func ListArchiveFiles(path string) ([]ArchiveFile, error) {
+	reader, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: ListArchiveFiles: %w", err)
+	}
+
+	defer reader.Close()
+
+	files := make([]ArchiveFile, 0, len(reader.File))
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+
+		files = append(files, ArchiveFile{Name: f.Name, Size: int64(f.UncompressedSize64), Modified: f.Modified})
+	}
+
+	return files, nil
+}
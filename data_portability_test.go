@@ -0,0 +1,454 @@
+package tiktok_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+const (
+	endpointDataAdd      = "https://open.tiktokapis.com/v2/user/data/add/"
+	endpointDataStatus   = "https://open.tiktokapis.com/v2/user/data/status/"
+	endpointDataDownload = "https://open.tiktokapis.com/v2/user/data/download/"
+)
+
+// testArchive returns a zip archive holding the provided files.
+func testArchive(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// testPartialResponse returns a partial content response with the archive from the offset.
+func testPartialResponse(archive []byte, offset int) *http.Response {
+	response := httpmock.NewBytesResponse(http.StatusPartialContent, archive[offset:])
+	response.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(archive)-1, len(archive)))
+
+	return response
+}
+
+// failingReader returns an error once it is read.
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
+
+func TestRequestUserDataSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	var body string
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointDataAdd,
+		func(req *http.Request) (*http.Response, error) {
+			bodyBytes, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			body = string(bodyBytes)
+
+			return httpmock.NewStringResponse(http.StatusOK, `{"data":{"request_id":42},"error":{"code":"ok"}}`), nil
+		},
+	)
+
+	requestID, err := tiktok.RequestUserData(context.Background(), testNewOauthToken(t), tiktok.DataFormatJSON, tiktok.DataCategoryProfile, tiktok.DataCategoryVideo)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if requestID != 42 {
+		t.Fatalf("expected request id 42, but got %d", requestID)
+	}
+
+	expectedBody := `{"data_format":"json","category_selection_list":["profile","video"]}`
+	if body != expectedBody {
+		t.Fatalf("expected request body '%s', but got '%s'", expectedBody, body)
+	}
+}
+
+func TestRequestUserDataInvalidFormat(t *testing.T) {
+	_, err := tiktok.RequestUserData(context.Background(), testNewOauthToken(t), "xml")
+
+	expectedError := `unsupported data format "xml"`
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("expected error to contain '%s', but got '%v'", expectedError, err)
+	}
+}
+
+func TestWaitForUserDataSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointDataStatus,
+		httpmock.ResponderFromMultipleResponses([]*http.Response{
+			httpmock.NewStringResponse(http.StatusOK, `{"data":{"request_id":42,"status":"pending","apply_time":1700000000},"error":{"code":"ok"}}`),
+			httpmock.NewStringResponse(http.StatusOK, `{"data":{"request_id":42,"status":"downloading","apply_time":1700000000,"collect_time":1700003600,"data_format":"json","category_selection_list":["all_data"]},"error":{"code":"ok"}}`),
+		}),
+	)
+
+	var statuses []tiktok.DataRequestStatus
+
+	opts := &tiktok.DataWaitOptions{
+		InitialInterval: time.Millisecond,
+		OnProgress: func(info *tiktok.DataRequestInfo) {
+			statuses = append(statuses, info.Status)
+		},
+	}
+
+	info, err := tiktok.WaitForUserData(context.Background(), testNewOauthToken(t), 42, opts)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fmt.Sprint(statuses) != "[pending downloading]" {
+		t.Fatalf("expected statuses '[pending downloading]', but got %v", statuses)
+	}
+
+	if !info.CollectTime.Equal(time.Unix(1700003600, 0)) || info.Format != tiktok.DataFormatJSON || len(info.Categories) != 1 {
+		t.Fatalf("unexpected data request info %+v", info)
+	}
+}
+
+func TestWaitForUserDataExpired(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointDataStatus,
+		httpmock.NewStringResponder(http.StatusOK, `{"data":{"request_id":42,"status":"expired"},"error":{"code":"ok"}}`),
+	)
+
+	info, err := tiktok.WaitForUserData(context.Background(), testNewOauthToken(t), 42, nil)
+
+	var requestErr *tiktok.DataRequestError
+	if !errors.As(err, &requestErr) || requestErr.Status != tiktok.DataRequestStatusExpired {
+		t.Fatalf("expected data request error, but got '%v'", err)
+	}
+
+	if info == nil || !info.Status.Done() {
+		t.Fatalf("expected final status, but got %+v", info)
+	}
+}
+
+func TestDownloadUserDataSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	archive := testArchive(t, map[string]string{"profile/profile.json": `{"username":"test"}`})
+
+	httpmock.RegisterResponder(http.MethodPost, endpointDataDownload, httpmock.NewBytesResponder(http.StatusOK, archive))
+
+	path := filepath.Join(t.TempDir(), "archive.zip")
+
+	var written int64
+
+	opts := &tiktok.DownloadOptions{
+		OnProgress: func(n, _ int64) {
+			written = n
+		},
+	}
+
+	if err := tiktok.DownloadUserData(context.Background(), testNewOauthToken(t), 42, path, opts); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if written != int64(len(archive)) {
+		t.Fatalf("expected %d bytes written, but got %d", len(archive), written)
+	}
+
+	files, err := tiktok.ListArchiveFiles(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(files) != 1 || files[0].Name != "profile/profile.json" || files[0].Size != 19 {
+		t.Fatalf("unexpected archive files %+v", files)
+	}
+}
+
+func TestDownloadUserDataResume(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	archive := testArchive(t, map[string]string{"video/videos.json": `[]`, "comment/comments.json": `[]`})
+	half := len(archive) / 2
+
+	var ranges []string
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointDataDownload,
+		func(req *http.Request) (*http.Response, error) {
+			ranges = append(ranges, req.Header.Get("Range"))
+
+			if len(ranges) == 1 {
+				// The connection drops after the first half of the archive.
+				return &http.Response{
+					StatusCode:    http.StatusOK,
+					ContentLength: int64(len(archive)),
+					Body:          ioutil.NopCloser(io.MultiReader(bytes.NewReader(archive[:half]), failingReader{})),
+				}, nil
+			}
+
+			var offset int
+			if _, err := fmt.Sscanf(req.Header.Get("Range"), "bytes=%d-", &offset); err != nil {
+				return nil, err
+			}
+
+			return testPartialResponse(archive, offset), nil
+		},
+	)
+
+	path := filepath.Join(t.TempDir(), "archive.zip")
+
+	if err := tiktok.DownloadUserData(context.Background(), testNewOauthToken(t), 42, path, &tiktok.DownloadOptions{RetryInterval: time.Millisecond}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expectedRanges := fmt.Sprintf("[ bytes=%d-]", half)
+	if fmt.Sprint(ranges) != expectedRanges {
+		t.Fatalf("expected ranges '%s', but got %v", expectedRanges, ranges)
+	}
+
+	downloaded, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(downloaded, archive) {
+		t.Fatal("expected downloaded archive to match")
+	}
+
+	for _, suffix := range []string{".part", ".part.request"} {
+		if _, err = os.Stat(path + suffix); !os.IsNotExist(err) {
+			t.Fatalf("expected %s file to be removed, but got %v", suffix, err)
+		}
+	}
+}
+
+func TestDownloadUserDataRangeIgnored(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	archive := testArchive(t, map[string]string{"profile/profile.json": `{}`})
+
+	httpmock.RegisterResponder(http.MethodPost, endpointDataDownload, httpmock.NewBytesResponder(http.StatusOK, archive))
+
+	path := testPartialFile(t, 42, []byte("stale"))
+
+	if err := tiktok.DownloadUserData(context.Background(), testNewOauthToken(t), 42, path, nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	downloaded, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(downloaded, archive) {
+		t.Fatal("expected downloaded archive to match")
+	}
+}
+
+// testPartialFile returns the path of an archive with a partial file holding data for the request.
+func testPartialFile(t *testing.T, requestID int64, data []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "archive.zip")
+
+	if err := ioutil.WriteFile(path+".part", data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path+".part.request", []byte(fmt.Sprint(requestID)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestDownloadUserDataOtherRequest(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	archive := testArchive(t, map[string]string{"profile/profile.json": `{}`})
+
+	var ranges []string
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointDataDownload,
+		func(req *http.Request) (*http.Response, error) {
+			ranges = append(ranges, req.Header.Get("Range"))
+			return httpmock.NewBytesResponse(http.StatusOK, archive), nil
+		},
+	)
+
+	// The partial file was written for another request.
+	path := testPartialFile(t, 41, archive[:10])
+
+	if err := tiktok.DownloadUserData(context.Background(), testNewOauthToken(t), 42, path, nil); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fmt.Sprint(ranges) != "[]" {
+		t.Fatalf("expected no range requested, but got %v", ranges)
+	}
+
+	downloaded, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(downloaded, archive) {
+		t.Fatal("expected downloaded archive to match")
+	}
+}
+
+func TestDownloadUserDataRangeMismatch(t *testing.T) {
+	archive := testArchive(t, map[string]string{"profile/profile.json": `{}`})
+
+	tests := []struct {
+		name     string
+		partial  []byte
+		response func(offset int) *http.Response
+	}{
+		{
+			name:    "different start",
+			partial: archive[:10],
+			response: func(offset int) *http.Response {
+				// The server starts the range before the offset.
+				return testPartialResponse(archive, offset/2)
+			},
+		},
+		{
+			name:    "range not satisfiable",
+			partial: append(append([]byte(nil), archive...), "garbage"...),
+			response: func(int) *http.Response {
+				response := httpmock.NewStringResponse(http.StatusRequestedRangeNotSatisfiable, "")
+				response.Header.Set("Content-Range", fmt.Sprintf("bytes */%d", len(archive)))
+
+				return response
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			t.Cleanup(httpmock.Deactivate)
+
+			var ranges []string
+
+			httpmock.RegisterResponder(
+				http.MethodPost,
+				endpointDataDownload,
+				func(req *http.Request) (*http.Response, error) {
+					ranges = append(ranges, req.Header.Get("Range"))
+
+					var offset int
+					if _, err := fmt.Sscanf(req.Header.Get("Range"), "bytes=%d-", &offset); err != nil {
+						return httpmock.NewBytesResponse(http.StatusOK, archive), nil
+					}
+
+					return tt.response(offset), nil
+				},
+			)
+
+			path := testPartialFile(t, 42, tt.partial)
+
+			if err := tiktok.DownloadUserData(context.Background(), testNewOauthToken(t), 42, path, &tiktok.DownloadOptions{RetryInterval: time.Millisecond}); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			// The partial file is discarded and the archive downloaded again from the start.
+			expectedRanges := fmt.Sprintf("[bytes=%d- ]", len(tt.partial))
+			if fmt.Sprint(ranges) != expectedRanges {
+				t.Fatalf("expected ranges '%s', but got %v", expectedRanges, ranges)
+			}
+
+			downloaded, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(downloaded, archive) {
+				t.Fatal("expected downloaded archive to match")
+			}
+		})
+	}
+}
+
+func TestDownloadUserDataAPIError(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(http.MethodPost, endpointDataDownload, httpmock.NewStringResponder(http.StatusBadRequest, responseV2Error))
+
+	err := tiktok.DownloadUserData(context.Background(), testNewOauthToken(t), 42, filepath.Join(t.TempDir(), "archive.zip"), nil)
+
+	var apiErr *tiktok.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected api error, but got '%v'", err)
+	}
+
+	if info := httpmock.GetCallCountInfo(); info["POST "+endpointDataDownload] != 1 {
+		t.Fatalf("expected a single download attempt, but got %v", info)
+	}
+}
+
+func TestWaitForUserDataAPIError(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointDataStatus,
+		httpmock.NewStringResponder(http.StatusUnauthorized, responseV2Error),
+	)
+
+	_, err := tiktok.WaitForUserData(context.Background(), testNewOauthToken(t), 1, nil)
+
+	expected := "tiktok-oauth2: WaitForUserData: The access token is invalid or not found in the request. [access_token_invalid]"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error '%s', but got '%v'", expected, err)
+	}
+}
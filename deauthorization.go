@@ -0,0 +1,150 @@
+package tiktok
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var authorizationRemovedReasonDescriptions = map[AuthorizationRemovedReason]string{
+	AuthorizationRemovedUnknown:        "unknown",
+	AuthorizationRemovedByUser:         "user disconnected the app",
+	AuthorizationRemovedAccountDeleted: "user account was deleted",
+	AuthorizationRemovedAgeChanged:     "user age changed",
+	AuthorizationRemovedAccountBanned:  "user account was banned",
+	AuthorizationRemovedByDeveloper:    "developer revoked the authorization",
+}
+
+// Description returns a human readable description of the reason.
+func (r AuthorizationRemovedReason) Description() string {
+	if description, ok := authorizationRemovedReasonDescriptions[r]; ok {
+		return description
+	}
+
+	return fmt.Sprintf("reason %d", int(r))
+}
+
+// DeauthorizationConfig configures a DeauthorizationSubscriber.
+type DeauthorizationConfig struct {
+	// Tokens is the store the tokens of deauthorized users are deleted from.
+	Tokens TokenStore
+	// UserInfo, if set, is the cache the information of deauthorized users is removed from.
+	UserInfo UserInfoCache
+	// DeleteUserData, if set, is called to delete the data the app holds on a deauthorized user.
+	DeleteUserData func(ctx context.Context, openID string) error
+	// OnAudit, if set, is called with the record of every deauthorization handled, whether the
+	// cleanup succeeded or not.
+	OnAudit func(ctx context.Context, record *DeauthorizationRecord)
+}
+
+// DeauthorizationRecord is the audit record of the cleanup of a deauthorized user.
+type DeauthorizationRecord struct {
+	OpenID      string
+	Reason      AuthorizationRemovedReason
+	EventTime   time.Time
+	ProcessedAt time.Time
+	// TokenDeleted is set when a stored token of the user was deleted.
+	TokenDeleted bool
+	// UserInfoDeleted is set when cached information of the user was removed.
+	UserInfoDeleted bool
+	// UserDataDeleted is set when DeleteUserData succeeded.
+	UserDataDeleted bool
+	// Err is set when any of the cleanup steps failed.
+	Err error
+}
+
+// DeauthorizationSubscriber stops using and deletes the data of users who deauthorize the app. Its
+// HandleEvent method is registered with WebhookHandler.OnAuthorizationRemoved.
+type DeauthorizationSubscriber struct {
+	cfg DeauthorizationConfig
+}
+
+// NewDeauthorizationSubscriber returns a new DeauthorizationSubscriber.
+func NewDeauthorizationSubscriber(cfg DeauthorizationConfig) (*DeauthorizationSubscriber, error) {
+	if cfg.Tokens == nil {
+		return nil, fmt.Errorf("tiktok-oauth2: NewDeauthorizationSubscriber: token store cannot be nil")
+	}
+
+	return &DeauthorizationSubscriber{cfg: cfg}, nil
+}
+
+// HandleEvent deletes the token, cached information and data of the user of the event. Every step
+// is attempted even if a previous one failed; any failure is returned so that TikTok retries the
+// delivery, which is safe as all the steps are idempotent.
+func (s *DeauthorizationSubscriber) HandleEvent(ctx context.Context, event *AuthorizationRemovedEvent) error {
+	record := &DeauthorizationRecord{
+		OpenID:    event.UserOpenID,
+		Reason:    event.Reason,
+		EventTime: event.CreateTime,
+	}
+
+	var failures []string
+
+	if record.OpenID == "" {
+		failures = append(failures, "open id cannot be empty")
+	} else {
+		deleted, err := s.deleteToken(ctx, record.OpenID)
+		if err != nil {
+			failures = append(failures, "delete token: "+err.Error())
+		}
+
+		record.TokenDeleted = deleted
+
+		if s.cfg.UserInfo != nil {
+			deleted, err = s.deleteUserInfo(ctx, record.OpenID)
+			if err != nil {
+				failures = append(failures, "delete user info: "+err.Error())
+			}
+
+			record.UserInfoDeleted = deleted
+		}
+
+		if s.cfg.DeleteUserData != nil {
+			if err = s.cfg.DeleteUserData(ctx, record.OpenID); err != nil {
+				failures = append(failures, "delete user data: "+err.Error())
+			} else {
+				record.UserDataDeleted = true
+			}
+		}
+	}
+
+	record.ProcessedAt = time.Now()
+	if len(failures) > 0 {
+		record.Err = fmt.Errorf("tiktok-oauth2: DeauthorizationSubscriber: %s", strings.Join(failures, "; "))
+	}
+
+	if s.cfg.OnAudit != nil {
+		s.cfg.OnAudit(ctx, record)
+	}
+
+	return record.Err
+}
+
+// deleteToken deletes the token of the user and reports whether there was one.
+func (s *DeauthorizationSubscriber) deleteToken(ctx context.Context, openID string) (bool, error) {
+	token, err := s.cfg.Tokens.Token(ctx, openID)
+	if err != nil {
+		return false, err
+	}
+
+	if err = s.cfg.Tokens.DeleteToken(ctx, openID); err != nil {
+		return false, err
+	}
+
+	return token != nil, nil
+}
+
+// deleteUserInfo removes the cached information of the user and reports whether there was any.
+func (s *DeauthorizationSubscriber) deleteUserInfo(ctx context.Context, openID string) (bool, error) {
+	info, err := s.cfg.UserInfo.UserInfo(ctx, openID)
+	if err != nil {
+		return false, err
+	}
+
+	if err = s.cfg.UserInfo.DeleteUserInfo(ctx, openID); err != nil {
+		return false, err
+	}
+
+	return info != nil, nil
+}
@@ -0,0 +1,111 @@
+package tiktok_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+)
+
+func TestNewDeauthorizationSubscriberInvalidArguments(t *testing.T) {
+	_, err := tiktok.NewDeauthorizationSubscriber(tiktok.DeauthorizationConfig{})
+
+	expectedError := "token store cannot be nil"
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("expected error to contain '%s', but got '%v'", expectedError, err)
+	}
+}
+
+func TestDeauthorizationSubscriberHandleEvent(t *testing.T) {
+	tests := []struct {
+		name             string
+		deleteErr        error
+		expectedCode     int
+		expectedDataGone bool
+	}{
+		{
+			name:             "Success",
+			expectedCode:     http.StatusOK,
+			expectedDataGone: true,
+		},
+		{
+			name:         "Data deletion failure",
+			deleteErr:    errors.New("test-error"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			tokens := tiktok.NewMemoryTokenStore()
+			if err := tokens.SaveToken(ctx, "test-open-id", testNewOauthToken(t)); err != nil {
+				t.Fatal(err)
+			}
+
+			users := tiktok.NewMemoryUserInfoCache()
+			if err := users.SaveUserInfo(ctx, "test-open-id", &tiktok.UserInfo{OpenID: "test-open-id"}); err != nil {
+				t.Fatal(err)
+			}
+
+			var deleted []string
+			var record *tiktok.DeauthorizationRecord
+
+			sub, err := tiktok.NewDeauthorizationSubscriber(tiktok.DeauthorizationConfig{
+				Tokens:   tokens,
+				UserInfo: users,
+				DeleteUserData: func(_ context.Context, openID string) error {
+					deleted = append(deleted, openID)
+					return tt.deleteErr
+				},
+				OnAudit: func(_ context.Context, r *tiktok.DeauthorizationRecord) {
+					record = r
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			h := testNewWebhookHandler(t)
+			h.OnAuthorizationRemoved(sub.HandleEvent)
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, testWebhookRequest(t, testWebhookBody(t, tiktok.EventAuthorizationRemoved, `{"reason":1}`), time.Now()))
+
+			if rec.Code != tt.expectedCode {
+				t.Fatalf("expected status code %d, but got %d", tt.expectedCode, rec.Code)
+			}
+
+			if token, _ := tokens.Token(ctx, "test-open-id"); token != nil {
+				t.Fatal("expected token to be deleted")
+			}
+
+			if info, _ := users.UserInfo(ctx, "test-open-id"); info != nil {
+				t.Fatal("expected user info to be deleted")
+			}
+
+			if len(deleted) != 1 || deleted[0] != "test-open-id" {
+				t.Fatalf("expected user data of 'test-open-id' to be deleted, but got %v", deleted)
+			}
+
+			if record == nil {
+				t.Fatal("expected audit record")
+			}
+
+			if record.OpenID != "test-open-id" || record.Reason != tiktok.AuthorizationRemovedByUser || !record.TokenDeleted || !record.UserInfoDeleted {
+				t.Fatalf("unexpected audit record %+v", record)
+			}
+
+			if record.UserDataDeleted != tt.expectedDataGone || (record.Err != nil) == tt.expectedDataGone {
+				t.Fatalf("unexpected audit record %+v", record)
+			}
+		})
+	}
+}
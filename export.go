@@ -0,0 +1,284 @@
+package tiktok
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// ExportFormat is the file format written by an Exporter.
+type ExportFormat string
+
+// Supported export formats.
+const (
+	FormatCSV   ExportFormat = "csv"
+	FormatTSV   ExportFormat = "tsv"
+	FormatJSONL ExportFormat = "jsonl"
+)
+
+// Exporter streams research results, such as ResearchVideo or ResearchComment values, to a writer
+// one record per line. Columns are the JSON names of the fields; nested objects are flattened into
+// "parent.child" columns and, in CSV and TSV, lists are joined with ListSeparator.
+type Exporter struct {
+	// ListSeparator joins the values of list fields, such as hashtag names, in CSV and TSV.
+	// Defaults to ",".
+	ListSeparator string
+
+	format      ExportFormat
+	columns     []string
+	csv         *csv.Writer
+	jsonl       io.Writer
+	wroteHeader bool
+}
+
+// NewExporter returns a new Exporter writing to w. When no columns are provided, all the fields of
+// the first record written are exported.
+func NewExporter(w io.Writer, format ExportFormat, columns ...string) (*Exporter, error) {
+	if w == nil {
+		return nil, fmt.Errorf("tiktok-oauth2: NewExporter: writer cannot be nil")
+	}
+
+	e := &Exporter{ListSeparator: ",", format: format, columns: columns}
+
+	switch format {
+	case FormatCSV:
+		e.csv = csv.NewWriter(w)
+	case FormatTSV:
+		e.csv = csv.NewWriter(w)
+		e.csv.Comma = '\t'
+	case FormatJSONL:
+		e.jsonl = w
+	default:
+		return nil, fmt.Errorf("tiktok-oauth2: NewExporter: unsupported format %q", format)
+	}
+
+	return e, nil
+}
+
+// Write exports a record.
+func (e *Exporter) Write(record interface{}) error {
+	fields, err := flattenRecord(record)
+	if err != nil {
+		return fmt.Errorf("tiktok-oauth2: Exporter: %w", err)
+	}
+
+	if e.columns == nil {
+		e.columns = recordColumns(record, fields)
+	}
+
+	if e.jsonl != nil {
+		if err = e.writeJSON(fields); err != nil {
+			return fmt.Errorf("tiktok-oauth2: Exporter: %w", err)
+		}
+
+		return nil
+	}
+
+	if !e.wroteHeader {
+		if err = e.csv.Write(e.columns); err != nil {
+			return fmt.Errorf("tiktok-oauth2: Exporter: %w", err)
+		}
+
+		e.wroteHeader = true
+	}
+
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		row[i] = e.formatValue(fields[column])
+	}
+
+	if err = e.csv.Write(row); err != nil {
+		return fmt.Errorf("tiktok-oauth2: Exporter: %w", err)
+	}
+
+	return nil
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (e *Exporter) Flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+
+		if err := e.csv.Error(); err != nil {
+			return fmt.Errorf("tiktok-oauth2: Exporter: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// WriteVideos exports every video of the iterator and returns the number of videos written.
+func (e *Exporter) WriteVideos(it *VideoIterator) (int, error) {
+	count := 0
+	for it.Next() {
+		if err := e.Write(it.Video()); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, it.Err()
+}
+
+// WriteComments exports every comment of the iterator and returns the number of comments written.
+func (e *Exporter) WriteComments(it *CommentIterator) (int, error) {
+	count := 0
+	for it.Next() {
+		if err := e.Write(it.Comment()); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, it.Err()
+}
+
+// WriteUsers exports every user of the iterator and returns the number of users written.
+func (e *Exporter) WriteUsers(it *UserListIterator) (int, error) {
+	count := 0
+	for it.Next() {
+		if err := e.Write(it.User()); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	return count, it.Err()
+}
+
+// writeJSON writes the fields as a JSON object on a line, with the keys in the order of the
+// columns.
+func (e *Exporter) writeJSON(fields map[string]interface{}) error {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	for i, column := range e.columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		if err := writeJSONValue(&buf, column); err != nil {
+			return err
+		}
+
+		buf.WriteByte(':')
+
+		if err := writeJSONValue(&buf, fields[column]); err != nil {
+			return err
+		}
+	}
+
+	buf.WriteString("}\n")
+
+	_, err := e.jsonl.Write(buf.Bytes())
+
+	return err
+}
+
+// writeJSONValue appends the JSON encoding of the value to buf, without escaping HTML characters.
+func writeJSONValue(buf *bytes.Buffer, value interface{}) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(value); err != nil {
+		return err
+	}
+
+	// Drop the newline added by the encoder.
+	buf.Truncate(buf.Len() - 1)
+
+	return nil
+}
+
+func (e *Exporter) formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = e.formatValue(item)
+		}
+
+		return strings.Join(values, e.ListSeparator)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// flattenRecord returns the JSON fields of a record, with nested objects flattened into
+// "parent.child" keys. Numbers are kept as json.Number so large ids are not rounded.
+func flattenRecord(record interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err = decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("record of type %T is not an object", record)
+	}
+
+	fields := make(map[string]interface{})
+	flattenObject("", object, fields)
+
+	return fields, nil
+}
+
+func flattenObject(prefix string, object map[string]interface{}, fields map[string]interface{}) {
+	for key, value := range object {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenObject(prefix+key+".", nested, fields)
+			continue
+		}
+
+		fields[prefix+key] = value
+	}
+}
+
+// recordColumns returns the columns of a record in the order of its struct fields, falling back to
+// sorted names for fields that do not map to a struct field.
+func recordColumns(record interface{}, fields map[string]interface{}) []string {
+	var columns []string
+	seen := make(map[string]bool)
+
+	t := reflect.TypeOf(record)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t != nil && t.Kind() == reflect.Struct {
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if _, ok := fields[name]; ok && !seen[name] {
+				columns = append(columns, name)
+				seen[name] = true
+			}
+		}
+	}
+
+	var rest []string
+	for name := range fields {
+		if !seen[name] {
+			rest = append(rest, name)
+		}
+	}
+
+	sort.Strings(rest)
+
+	return append(columns, rest...)
+}
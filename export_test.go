@@ -0,0 +1,130 @@
+package tiktok_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+)
+
+func testResearchVideos() []tiktok.ResearchVideo {
+	return []tiktok.ResearchVideo{
+		{ID: 7000000000000000001, Username: "test-user-1", HashtagNames: []string{"cat", "cute"}, VideoDescription: "a, quoted \"cat\""},
+		{ID: 7000000000000000002, Username: "test-user-2", EffectIDs: []string{"101"}},
+	}
+}
+
+func TestExporterFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   tiktok.ExportFormat
+		expected string
+	}{
+		{
+			name:   "csv",
+			format: tiktok.FormatCSV,
+			expected: "id,username,hashtag_names,effect_ids,video_description\n" +
+				"7000000000000000001,test-user-1,cat|cute,,\"a, quoted \"\"cat\"\"\"\n" +
+				"7000000000000000002,test-user-2,,101,\n",
+		},
+		{
+			name:   "tsv",
+			format: tiktok.FormatTSV,
+			expected: "id\tusername\thashtag_names\teffect_ids\tvideo_description\n" +
+				"7000000000000000001\ttest-user-1\tcat|cute\t\t\"a, quoted \"\"cat\"\"\"\n" +
+				"7000000000000000002\ttest-user-2\t\t101\t\n",
+		},
+		{
+			name:   "jsonl",
+			format: tiktok.FormatJSONL,
+			expected: `{"id":7000000000000000001,"username":"test-user-1","hashtag_names":["cat","cute"],"effect_ids":null,"video_description":"a, quoted \"cat\""}` + "\n" +
+				`{"id":7000000000000000002,"username":"test-user-2","hashtag_names":null,"effect_ids":["101"],"video_description":""}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			exporter, err := tiktok.NewExporter(&buf, tt.format, "id", "username", "hashtag_names", "effect_ids", "video_description")
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			exporter.ListSeparator = "|"
+
+			for _, video := range testResearchVideos() {
+				if err = exporter.Write(video); err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+			}
+
+			if err = exporter.Flush(); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if buf.String() != tt.expected {
+				t.Fatalf("expected output\n%s\nbut got\n%s", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestExporterDefaultColumns(t *testing.T) {
+	var buf bytes.Buffer
+
+	exporter, err := tiktok.NewExporter(&buf, tiktok.FormatCSV)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err = exporter.Write(tiktok.ResearchUserRef{DisplayName: "Test", Username: "test-user"}); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err = exporter.Flush(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := "display_name,username\nTest,test-user\n"
+	if buf.String() != expected {
+		t.Fatalf("expected output '%s', but got '%s'", expected, buf.String())
+	}
+}
+
+func TestExporterWriteVideos(t *testing.T) {
+	registerTestResearchPages(t, 2)
+
+	var buf bytes.Buffer
+
+	exporter, err := tiktok.NewExporter(&buf, tiktok.FormatCSV, "id")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	count, err := exporter.WriteVideos(tiktok.NewVideoIterator(context.Background(), testNewOauthToken(t), testVideoQueryRequest(t)))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err = exporter.Flush(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if count != 4 || strings.Count(buf.String(), "\n") != 5 {
+		t.Fatalf("expected 4 videos and a header, but got %d videos and output '%s'", count, buf.String())
+	}
+}
+
+func TestNewExporterUnsupportedFormat(t *testing.T) {
+	_, err := tiktok.NewExporter(&bytes.Buffer{}, "xlsx")
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if !strings.Contains(err.Error(), `NewExporter: unsupported format "xlsx"`) {
+		t.Fatalf("expected error to contain 'NewExporter: unsupported format \"xlsx\"', but got '%v'", err)
+	}
+}
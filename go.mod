@@ -3,6 +3,6 @@ module github.com/chanioxaris/tiktok-oauth2
 go 1.16
 
 require (
-	github.com/jarcoal/httpmock v1.0.8 // indirect
+	github.com/jarcoal/httpmock v1.0.8
 	golang.org/x/oauth2 v0.0.0-20210628180205-a41e5a781914
 )
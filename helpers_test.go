@@ -1,19 +1,36 @@
 package tiktok_test
 
 import (
+	"fmt"
+	"net/http"
 	"testing"
 	"time"
 
 	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
 	"golang.org/x/oauth2"
 )
 
 var (
-	responseSuccessToken     = `{"data":{"open_id":"test-open-id","scope":"test-scope-1,test-scope-2","access_token":"test-access-token","expires_in":86400,"refresh_token":"test-refresh-token","refresh_expires_in":31536000}}`
-	responseSuccessRevoke    = `{"data":{"captcha":"","desc_url":"","description":"","error_code":0,"log_id":"test-log-id"},"message":"success"}`
-	responseError            = `{"data":{"captcha":"","desc_url":"","description":"Request error","error_code":1000},"message":""}`
-	responseEmptyAccessToken = `{"data":{"open_id":"test-open-id","scope":"test-scope-1,test-scope-2","expires_in":86400,"refresh_token":"test-refresh-token","refresh_expires_in":31536000}}`
-	responseSuccessUserInfo  = `{"data":{"open_id":"test-open-id","union_id":"test-union-id","avatar":"test-avatar","avatar_larger":"test-avatar-larger","display_name":"test-display-name"}}`
+	responseSuccessToken       = `{"data":{"open_id":"test-open-id","scope":"test-scope-1,test-scope-2","access_token":"test-access-token","expires_in":86400,"refresh_token":"test-refresh-token","refresh_expires_in":31536000}}`
+	responseSuccessRevoke      = `{"data":{"captcha":"","desc_url":"","description":"","error_code":0,"log_id":"test-log-id"},"message":"success"}`
+	responseError              = `{"data":{"captcha":"","desc_url":"","description":"Request error","error_code":1000},"message":""}`
+	responseEmptyAccessToken   = `{"data":{"open_id":"test-open-id","scope":"test-scope-1,test-scope-2","expires_in":86400,"refresh_token":"test-refresh-token","refresh_expires_in":31536000}}`
+	responseSuccessClientToken = `{"access_token":"clt.test-access-token","expires_in":7200,"token_type":"Bearer"}`
+	responseClientTokenError   = `{"error":"invalid_client","error_description":"Client key or secret is incorrect.","log_id":"test-log-id"}`
+	responseSuccessUserInfo    = `{"data":{"open_id":"test-open-id","union_id":"test-union-id","avatar":"test-avatar","avatar_larger":"test-avatar-larger","display_name":"test-display-name"}}`
+
+	responseV2Error              = `{"data":{},"error":{"code":"access_token_invalid","message":"The access token is invalid or not found in the request.","log_id":"test-log-id"}}`
+	responsePublishProcessing    = `{"data":{"status":"PROCESSING_UPLOAD","uploaded_bytes":1024},"error":{"code":"ok","message":"","log_id":"test-log-id"}}`
+	responsePublishComplete      = `{"data":{"status":"PUBLISH_COMPLETE","publicaly_available_post_id":[7300000000000000000]},"error":{"code":"ok","message":"","log_id":"test-log-id"}}`
+	responsePublishFailed        = `{"data":{"status":"FAILED","fail_reason":"duration_check_failed"},"error":{"code":"ok","message":"","log_id":"test-log-id"}}`
+	responseCreatorInfo          = `{"data":{"creator_avatar_url":"test-avatar","creator_username":"test-username","creator_nickname":"test-nickname","privacy_level_options":["PUBLIC_TO_EVERYONE","SELF_ONLY"],"comment_disabled":false,"duet_disabled":true,"stitch_disabled":true,"max_video_post_duration_sec":300},"error":{"code":"ok","message":"","log_id":"test-log-id"}}`
+	responseContentInit          = `{"data":{"publish_id":"test-publish-id"},"error":{"code":"ok","message":"","log_id":"test-log-id"}}`
+	responseResearchVideos       = `{"data":{"videos":[{"id":702874395068494830,"username":"test-user-1","hashtag_names":["cat"],"create_time":1655300000},{"id":702874395068494831,"username":"test-user-2","hashtag_names":["cat","cute"],"create_time":1655400000}],"cursor":2,"has_more":true,"search_id":"test-search-id"},"error":{"code":"ok","message":"","log_id":"test-log-id"}}`
+	responseResearchUser         = `{"data":{"display_name":"test-display-name","follower_count":1500},"error":{"code":"ok","message":"","log_id":"test-log-id"}}`
+	responseResearchUserPrivate  = `{"data":{},"error":{"code":"invalid_params","message":"The user's account is private","log_id":"test-log-id"}}`
+	responseResearchUserNotFound = `{"data":{},"error":{"code":"invalid_params","message":"The user does not exist","log_id":"test-log-id"}}`
+	responseVideoInit            = `{"data":{"publish_id":"test-publish-id","upload_url":"https://open-upload.tiktokapis.com/video/?upload_id=test-upload-id"},"error":{"code":"ok","message":"","log_id":"test-log-id"}}`
 )
 
 var (
@@ -30,6 +47,12 @@ var (
 		"grant_type":    "refresh_token",
 	}
 
+	clientCredentialsParameters = map[string]string{
+		"client_key":    "test-client-id",
+		"client_secret": "test-client-secret",
+		"grant_type":    "client_credentials",
+	}
+
 	revokeParameters = map[string]string{
 		"access_token": "test-access-token",
 		"open_id":      "test-open-id",
@@ -67,3 +90,25 @@ func testNewOauthToken(t *testing.T) *oauth2.Token {
 		Expiry:       time.Now().Add(time.Second * 86400),
 	}
 }
+
+// testFormResponder returns a responder replying with the body to requests carrying the parameters
+// in a form encoded body and nothing in their url.
+func testFormResponder(parameters map[string]string, body string) httpmock.Responder {
+	return func(req *http.Request) (*http.Response, error) {
+		if req.URL.RawQuery != "" {
+			return nil, fmt.Errorf("unexpected query string %q", req.URL.RawQuery)
+		}
+
+		if err := req.ParseForm(); err != nil {
+			return nil, err
+		}
+
+		for key, value := range parameters {
+			if req.PostForm.Get(key) != value {
+				return nil, fmt.Errorf("expected form parameter %s '%s', but got '%s'", key, value, req.PostForm.Get(key))
+			}
+		}
+
+		return httpmock.NewStringResponse(http.StatusOK, body), nil
+	}
+}
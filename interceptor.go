@@ -0,0 +1,135 @@
+package tiktok
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Operation is the logical name of a call made by the package.
+type Operation string
+
+// Operations of the calls made by the package.
+const (
+	OperationExchange              Operation = "exchange"
+	OperationRefresh               Operation = "refresh"
+	OperationClientToken           Operation = "client_token"
+	OperationRevoke                Operation = "revoke"
+	OperationUserInfo              Operation = "user_info"
+	OperationCreatorInfo           Operation = "creator_info"
+	OperationPublishStatus         Operation = "publish_status"
+	OperationVideoInit             Operation = "video_init"
+	OperationInboxVideoInit        Operation = "inbox_video_init"
+	OperationContentInit           Operation = "content_init"
+	OperationUploadChunk           Operation = "upload_chunk"
+	OperationResearchVideos        Operation = "research_videos"
+	OperationResearchUserInfo      Operation = "research_user_info"
+	OperationResearchUserFollowers Operation = "research_user_followers"
+	OperationResearchUserFollowing Operation = "research_user_following"
+	OperationResearchUserLiked     Operation = "research_user_liked_videos"
+	OperationResearchUserPinned    Operation = "research_user_pinned_videos"
+	OperationResearchUserReposted  Operation = "research_user_reposted_videos"
+	OperationResearchComments      Operation = "research_comments"
+	OperationResearchPlaylist      Operation = "research_playlist"
+	OperationAdQuery               Operation = "ad_query"
+	OperationAdDetail              Operation = "ad_detail"
+	OperationAdvertiserQuery       Operation = "advertiser_query"
+	OperationDataRequest           Operation = "data_request"
+	OperationDataStatus            Operation = "data_status"
+	OperationDataCancel            Operation = "data_cancel"
+	OperationDataDownload          Operation = "data_download"
+)
+
+// endpointOperations maps the v2 endpoints to the operations of their calls.
+var endpointOperations = map[string]Operation{
+	endpointCreatorInfo:           OperationCreatorInfo,
+	endpointPublishStatus:         OperationPublishStatus,
+	endpointVideoInit:             OperationVideoInit,
+	endpointInboxVideoInit:        OperationInboxVideoInit,
+	endpointContentInit:           OperationContentInit,
+	endpointResearchVideo:         OperationResearchVideos,
+	endpointResearchUserInfo:      OperationResearchUserInfo,
+	endpointResearchUserFollowers: OperationResearchUserFollowers,
+	endpointResearchUserFollowing: OperationResearchUserFollowing,
+	endpointResearchUserLiked:     OperationResearchUserLiked,
+	endpointResearchUserPinned:    OperationResearchUserPinned,
+	endpointResearchUserReposted:  OperationResearchUserReposted,
+	endpointResearchComments:      OperationResearchComments,
+	endpointResearchPlaylist:      OperationResearchPlaylist,
+	endpointAdQuery:               OperationAdQuery,
+	endpointAdDetail:              OperationAdDetail,
+	endpointAdvertiserQuery:       OperationAdvertiserQuery,
+	endpointDataAdd:               OperationDataRequest,
+	endpointDataStatus:            OperationDataStatus,
+	endpointDataCancel:            OperationDataCancel,
+	endpointDataDownload:          OperationDataDownload,
+}
+
+// Call is a call made by the package, as seen by interceptors.
+type Call struct {
+	Operation Operation
+	// Request is the outgoing request. Interceptors may modify or replace it before invoking the
+	// next handler, e.g. to add headers or route it elsewhere.
+	Request *http.Request
+	// Response is the response received, once the call is sent. Its body is consumed and closed by
+	// the time the handler returns.
+	Response *http.Response
+	// Result is the decoded result of a successful call: the *oauth2.Token of the token operations,
+	// the *UserInfo of OperationUserInfo and the decoded response data of the other operations, or
+	// nil for the revoke, cancel, upload and download operations.
+	Result interface{}
+}
+
+// Handler sends the request of a call and decodes its result.
+type Handler func(call *Call) error
+
+// Interceptor runs around a call. It must invoke next to send the call, and may inspect the
+// result or the error it returns, which has its secrets scrubbed.
+type Interceptor func(call *Call, next Handler) error
+
+type interceptorsKey struct{}
+
+// WithInterceptors returns a copy of the context carrying the interceptors, which run around every
+// call made by the package with the context. The first interceptor is the outermost, and the
+// interceptors are appended to those already carried by the context.
+func WithInterceptors(ctx context.Context, interceptors ...Interceptor) context.Context {
+	current := contextInterceptors(ctx)
+
+	chain := make([]Interceptor, 0, len(current)+len(interceptors))
+	chain = append(chain, current...)
+	chain = append(chain, interceptors...)
+
+	return context.WithValue(ctx, interceptorsKey{}, chain)
+}
+
+func contextInterceptors(ctx context.Context) []Interceptor {
+	interceptors, _ := ctx.Value(interceptorsKey{}).([]Interceptor)
+	return interceptors
+}
+
+// invoke runs the handler of the call through the interceptors carried by the context.
+func invoke(ctx context.Context, call *Call, handler Handler) error {
+	interceptors := contextInterceptors(ctx)
+
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(call *Call) error {
+			return interceptor(call, next)
+		}
+	}
+
+	return handler(call)
+}
+
+// endpointOperation returns the operation of the calls to a v2 endpoint, ignoring its query.
+func endpointOperation(endpoint string) Operation {
+	if i := strings.IndexByte(endpoint, '?'); i >= 0 {
+		endpoint = endpoint[:i]
+	}
+
+	if op, ok := endpointOperations[endpoint]; ok {
+		return op
+	}
+
+	return Operation(endpoint)
+}
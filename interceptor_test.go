@@ -0,0 +1,173 @@
+package tiktok_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestInterceptorsSeeCalls(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	tenantResponder := func(body string) httpmock.Responder {
+		return func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("X-Tenant") != "test-tenant" {
+				return httpmock.NewStringResponse(http.StatusOK, responseError), nil
+			}
+
+			return httpmock.NewStringResponse(http.StatusOK, body), nil
+		}
+	}
+
+	httpmock.RegisterResponder(http.MethodPost, "https://open-api.tiktok.com/oauth/access_token/", tenantResponder(responseSuccessToken))
+	httpmock.RegisterResponder(http.MethodPost, "https://open-api.tiktok.com/oauth/userinfo/", tenantResponder(responseSuccessUserInfo))
+	httpmock.RegisterResponder(http.MethodPost, "https://open.tiktokapis.com/v2/post/publish/creator_info/query/", tenantResponder(responseCreatorInfo))
+
+	var trace []string
+	var results []interface{}
+
+	ctx := tiktok.WithInterceptors(context.Background(), func(call *tiktok.Call, next tiktok.Handler) error {
+		trace = append(trace, "outer:"+string(call.Operation))
+
+		call.Request.Header.Set("X-Tenant", "test-tenant")
+
+		err := next(call)
+		results = append(results, call.Result)
+
+		return err
+	})
+
+	ctx = tiktok.WithInterceptors(ctx, func(call *tiktok.Call, next tiktok.Handler) error {
+		trace = append(trace, "inner:"+string(call.Operation))
+
+		if call.Request.Header.Get("X-Tenant") != "test-tenant" {
+			t.Errorf("expected inner interceptor to see the header of the outer one")
+		}
+
+		return next(call)
+	})
+
+	token, err := tiktok.ConfigExchange(ctx, testNewOauthConfig(t), "test-code")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	info, err := tiktok.RetrieveUserInfo(ctx, token)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err = tiktok.QueryCreatorInfo(ctx, token); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expected := []string{
+		"outer:exchange", "inner:exchange",
+		"outer:user_info", "inner:user_info",
+		"outer:creator_info", "inner:creator_info",
+	}
+	if !reflect.DeepEqual(trace, expected) {
+		t.Fatalf("expected trace %v, but got %v", expected, trace)
+	}
+
+	if results[0] != token {
+		t.Fatalf("expected the exchange result to be the returned token, but got %v", results[0])
+	}
+
+	if results[1] != info {
+		t.Fatalf("expected the user info result to be the returned user info, but got %v", results[1])
+	}
+
+	if results[2] == nil {
+		t.Fatal("expected the creator info result to be set")
+	}
+}
+
+func TestInterceptorsSeeRedactedErrors(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		"https://open-api.tiktok.com/oauth/refresh_token/",
+		httpmock.NewErrorResponder(errors.New("connection reset for refresh_token=test-refresh-token")),
+	)
+
+	var seen error
+
+	ctx := tiktok.WithInterceptors(context.Background(), func(call *tiktok.Call, next tiktok.Handler) error {
+		seen = next(call)
+		return seen
+	})
+
+	_, err := tiktok.RefreshToken(ctx, "test-client-id", "test-refresh-token")
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if seen == nil || strings.Contains(seen.Error(), "test-refresh-token") {
+		t.Fatalf("expected interceptor to see a redacted error, but got '%v'", seen)
+	}
+}
+
+func TestInterceptorsShortCircuit(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.ZeroCallCounters()
+
+	errDenied := errors.New("denied")
+
+	ctx := tiktok.WithInterceptors(context.Background(), func(call *tiktok.Call, next tiktok.Handler) error {
+		if call.Operation == tiktok.OperationRevoke {
+			return errDenied
+		}
+
+		return next(call)
+	})
+
+	token := testNewOauthToken(t).WithExtra(map[string]interface{}{"open_id": "test-open-id"})
+
+	err := tiktok.RevokeAccess(ctx, token)
+	if !errors.Is(err, errDenied) || !strings.Contains(err.Error(), "RevokeAccess: denied") {
+		t.Fatalf("expected error to contain '%s', but got '%v'", "RevokeAccess: denied", err)
+	}
+
+	if calls := httpmock.GetTotalCallCount(); calls != 0 {
+		t.Fatalf("expected no request to be sent, but got %d", calls)
+	}
+}
+
+func TestInterceptorsRouteRequests(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		"https://tenant.example.com/oauth/refresh_token/",
+		testFormResponder(refreshTokenParameters, responseSuccessToken),
+	)
+
+	ctx := tiktok.WithInterceptors(context.Background(), func(call *tiktok.Call, next tiktok.Handler) error {
+		call.Request.URL.Host = "tenant.example.com"
+		call.Request.Host = ""
+
+		return next(call)
+	})
+
+	token, err := tiktok.RefreshToken(ctx, "test-client-id", "test-refresh-token")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if token.AccessToken != "test-access-token" {
+		t.Fatalf("expected access token 'test-access-token', but got %s", token.AccessToken)
+	}
+}
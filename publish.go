@@ -0,0 +1,290 @@
+package tiktok
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// PublishStatus is the lifecycle state of a post initiated through the Content Posting API.
+type PublishStatus string
+
+// Publish lifecycle states as reported by TikTok.
+const (
+	PublishStatusProcessingUpload   PublishStatus = "PROCESSING_UPLOAD"
+	PublishStatusProcessingDownload PublishStatus = "PROCESSING_DOWNLOAD"
+	PublishStatusSendToUserInbox    PublishStatus = "SEND_TO_USER_INBOX"
+	PublishStatusPublishComplete    PublishStatus = "PUBLISH_COMPLETE"
+	PublishStatusFailed             PublishStatus = "FAILED"
+)
+
+// Done reports whether the status is final, i.e. the post will not change state anymore.
+func (s PublishStatus) Done() bool {
+	return s == PublishStatusPublishComplete || s == PublishStatusFailed
+}
+
+// PublishFailReason is the reason code TikTok reports for a failed post.
+type PublishFailReason string
+
+// Known publish fail reasons.
+const (
+	PublishFailReasonFileFormatCheckFailed  PublishFailReason = "file_format_check_failed"
+	PublishFailReasonDurationCheckFailed    PublishFailReason = "duration_check_failed"
+	PublishFailReasonFrameRateCheckFailed   PublishFailReason = "frame_rate_check_failed"
+	PublishFailReasonPictureSizeCheckFailed PublishFailReason = "picture_size_check_failed"
+	PublishFailReasonInternal               PublishFailReason = "internal"
+	PublishFailReasonVideoPullFailed        PublishFailReason = "video_pull_failed"
+	PublishFailReasonPhotoPullFailed        PublishFailReason = "photo_pull_failed"
+	PublishFailReasonPublishCancelled       PublishFailReason = "publish_cancelled"
+	PublishFailReasonAuthRemoved            PublishFailReason = "auth_removed"
+	PublishFailReasonSpamRiskTooManyPosts   PublishFailReason = "spam_risk_too_many_posts"
+	PublishFailReasonSpamRiskUserBanned     PublishFailReason = "spam_risk_user_banned_from_posting"
+	PublishFailReasonSpamRiskText           PublishFailReason = "spam_risk_text"
+	PublishFailReasonSpamRisk               PublishFailReason = "spam_risk"
+)
+
+var publishFailReasonDescriptions = map[PublishFailReason]string{
+	PublishFailReasonFileFormatCheckFailed:  "unsupported media file format",
+	PublishFailReasonDurationCheckFailed:    "video duration is not supported",
+	PublishFailReasonFrameRateCheckFailed:   "video frame rate is not supported",
+	PublishFailReasonPictureSizeCheckFailed: "picture size is not supported",
+	PublishFailReasonInternal:               "internal server error",
+	PublishFailReasonVideoPullFailed:        "failed to download video from the provided url",
+	PublishFailReasonPhotoPullFailed:        "failed to download photo from the provided url",
+	PublishFailReasonPublishCancelled:       "post was cancelled by the user",
+	PublishFailReasonAuthRemoved:            "user revoked the authorization",
+	PublishFailReasonSpamRiskTooManyPosts:   "user reached the daily post limit",
+	PublishFailReasonSpamRiskUserBanned:     "user is banned from posting",
+	PublishFailReasonSpamRiskText:           "post text was flagged as spam",
+	PublishFailReasonSpamRisk:               "post was flagged as spam",
+}
+
+// Description returns a human readable description of the fail reason.
+func (r PublishFailReason) Description() string {
+	if description, ok := publishFailReasonDescriptions[r]; ok {
+		return description
+	}
+
+	return string(r)
+}
+
+// PublishStatusInfo holds the current state of a post.
+type PublishStatusInfo struct {
+	PublishID               string
+	Status                  PublishStatus
+	FailReason              PublishFailReason
+	PubliclyAvailablePostID []int64
+	UploadedBytes           int64
+	DownloadedBytes         int64
+}
+
+// PublishError is returned when a post reaches the FAILED state.
+type PublishError struct {
+	PublishID string
+	Reason    PublishFailReason
+}
+
+// Error implements the error interface.
+func (e *PublishError) Error() string {
+	return fmt.Sprintf("publish %s failed: %s [%s]", e.PublishID, e.Reason.Description(), e.Reason)
+}
+
+// WaitOptions configures the polling behaviour of WaitForPublish.
+type WaitOptions struct {
+	// InitialInterval is the delay before the second status check. Defaults to 2 seconds.
+	InitialInterval time.Duration
+	// MaxInterval caps the delay between two status checks. Defaults to 30 seconds.
+	MaxInterval time.Duration
+	// Multiplier is applied to the delay after every status check. Defaults to 2.
+	Multiplier float64
+	// Timeout is the maximum total time to wait. Zero means wait until the context is done.
+	Timeout time.Duration
+	// OnProgress, if set, is called with every status fetched.
+	OnProgress func(*PublishStatusInfo)
+}
+
+// CreatorInfo holds the posting settings of a creator.
+type CreatorInfo struct {
+	AvatarURL               string
+	Username                string
+	Nickname                string
+	PrivacyLevelOptions     []string
+	CommentDisabled         bool
+	DuetDisabled            bool
+	StitchDisabled          bool
+	MaxVideoPostDurationSec int
+}
+
+// QueryCreatorInfo returns the posting settings of the user of the token. TikTok requires it to be
+// called before every post.
+func QueryCreatorInfo(ctx context.Context, token *oauth2.Token) (*CreatorInfo, error) {
+	var data creatorInfoData
+	if err := doAPIRequest(ctx, http.MethodPost, endpointCreatorInfo, token, struct{}{}, &data); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryCreatorInfo: %w", err)
+	}
+
+	return &CreatorInfo{
+		AvatarURL:               data.CreatorAvatarURL,
+		Username:                data.CreatorUsername,
+		Nickname:                data.CreatorNickname,
+		PrivacyLevelOptions:     data.PrivacyLevelOptions,
+		CommentDisabled:         data.CommentDisabled,
+		DuetDisabled:            data.DuetDisabled,
+		StitchDisabled:          data.StitchDisabled,
+		MaxVideoPostDurationSec: data.MaxVideoPostDurationSec,
+	}, nil
+}
+
+// PostPhotos publishes photos, pulled by TikTok from the provided urls, directly to the profile of
+// the user of the token and returns the publish id of the post.
+func PostPhotos(ctx context.Context, token *oauth2.Token, photoURLs []string, coverIndex int, postInfo *PostInfo) (string, error) {
+	if len(photoURLs) == 0 {
+		return "", fmt.Errorf("tiktok-oauth2: PostPhotos: photo urls cannot be empty")
+	}
+
+	if coverIndex < 0 || coverIndex >= len(photoURLs) {
+		return "", fmt.Errorf("tiktok-oauth2: PostPhotos: cover index out of range")
+	}
+
+	if postInfo == nil {
+		return "", fmt.Errorf("tiktok-oauth2: PostPhotos: post info cannot be nil")
+	}
+
+	if err := ValidatePhotoPost(postInfo.Title, postInfo.Description); err != nil {
+		return "", fmt.Errorf("tiktok-oauth2: PostPhotos: %w", err)
+	}
+
+	req := photoInitRequest{
+		PostInfo: postInfo,
+		SourceInfo: photoSourceInfo{
+			Source:          "PULL_FROM_URL",
+			PhotoCoverIndex: coverIndex,
+			PhotoImages:     photoURLs,
+		},
+		PostMode:  "DIRECT_POST",
+		MediaType: "PHOTO",
+	}
+
+	var data photoInitData
+	if err := doAPIRequest(ctx, http.MethodPost, endpointContentInit, token, req, &data); err != nil {
+		return "", fmt.Errorf("tiktok-oauth2: PostPhotos: %w", err)
+	}
+
+	return data.PublishID, nil
+}
+
+// FetchPublishStatus returns the current status of a post initiated by the user of the token.
+func FetchPublishStatus(ctx context.Context, token *oauth2.Token, publishID string) (*PublishStatusInfo, error) {
+	info, err := fetchPublishStatus(ctx, token, publishID)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: FetchPublishStatus: %w", err)
+	}
+
+	return info, nil
+}
+
+// fetchPublishStatus returns the current status of a post, with errors not prefixed, for
+// FetchPublishStatus and WaitForPublish to prefix with their own names.
+func fetchPublishStatus(ctx context.Context, token *oauth2.Token, publishID string) (*PublishStatusInfo, error) {
+	if publishID == "" {
+		return nil, fmt.Errorf("publish id cannot be empty")
+	}
+
+	var data publishStatusData
+	if err := doAPIRequest(ctx, http.MethodPost, endpointPublishStatus, token, publishStatusRequest{PublishID: publishID}, &data); err != nil {
+		return nil, err
+	}
+
+	return &PublishStatusInfo{
+		PublishID:               publishID,
+		Status:                  PublishStatus(data.Status),
+		FailReason:              PublishFailReason(data.FailReason),
+		PubliclyAvailablePostID: data.PubliclyAvailablePostID,
+		UploadedBytes:           data.UploadedBytes,
+		DownloadedBytes:         data.DownloadedBytes,
+	}, nil
+}
+
+// WaitForPublish polls the status of a post until it is published or failed. When the post fails
+// the last status is returned along with a *PublishError.
+func WaitForPublish(ctx context.Context, token *oauth2.Token, publishID string, opts *WaitOptions) (*PublishStatusInfo, error) {
+	if opts == nil {
+		opts = &WaitOptions{}
+	}
+
+	backoff := newPollBackoff(opts.InitialInterval, opts.MaxInterval, opts.Multiplier, time.Second*2, time.Second*30)
+
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	for {
+		info, err := fetchPublishStatus(ctx, token, publishID)
+		if err != nil {
+			return nil, fmt.Errorf("tiktok-oauth2: WaitForPublish: %w", err)
+		}
+
+		if opts.OnProgress != nil {
+			opts.OnProgress(info)
+		}
+
+		switch info.Status {
+		case PublishStatusPublishComplete:
+			return info, nil
+		case PublishStatusFailed:
+			return info, fmt.Errorf("tiktok-oauth2: WaitForPublish: %w", &PublishError{PublishID: publishID, Reason: info.FailReason})
+		}
+
+		if err = backoff.wait(ctx); err != nil {
+			return info, fmt.Errorf("tiktok-oauth2: WaitForPublish: %w", err)
+		}
+	}
+}
+
+// pollBackoff spaces out the requests of a polling loop with an exponential backoff.
+type pollBackoff struct {
+	interval    time.Duration
+	maxInterval time.Duration
+	multiplier  float64
+}
+
+// newPollBackoff returns a pollBackoff, replacing unset values with the provided defaults and the
+// multiplier with 2.
+func newPollBackoff(interval, maxInterval time.Duration, multiplier float64, defaultInterval, defaultMaxInterval time.Duration) *pollBackoff {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	if maxInterval <= 0 {
+		maxInterval = defaultMaxInterval
+	}
+
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	return &pollBackoff{interval: interval, maxInterval: maxInterval, multiplier: multiplier}
+}
+
+// wait sleeps for the current interval, or until the context is done, and increases the interval.
+func (b *pollBackoff) wait(ctx context.Context) error {
+	timer := time.NewTimer(b.interval)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	b.interval = time.Duration(float64(b.interval) * b.multiplier)
+	if b.interval > b.maxInterval {
+		b.interval = b.maxInterval
+	}
+
+	return nil
+}
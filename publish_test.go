@@ -0,0 +1,246 @@
+package tiktok_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+const (
+	endpointCreatorInfo   = "https://open.tiktokapis.com/v2/post/publish/creator_info/query/"
+	endpointPublishStatus = "https://open.tiktokapis.com/v2/post/publish/status/fetch/"
+)
+
+func TestQueryCreatorInfoSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointCreatorInfo,
+		httpmock.NewStringResponder(http.StatusOK, responseCreatorInfo),
+	)
+
+	info, err := tiktok.QueryCreatorInfo(context.Background(), testNewOauthToken(t))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.Username != "test-username" {
+		t.Fatalf("expected username 'test-username', but got %s", info.Username)
+	}
+
+	if info.MaxVideoPostDurationSec != 300 {
+		t.Fatalf("expected max video post duration '300', but got %d", info.MaxVideoPostDurationSec)
+	}
+
+	if len(info.PrivacyLevelOptions) != 2 {
+		t.Fatalf("expected 2 privacy level options, but got %v", info.PrivacyLevelOptions)
+	}
+}
+
+func TestFetchPublishStatusInvalidArguments(t *testing.T) {
+	_, err := tiktok.FetchPublishStatus(context.Background(), testNewOauthToken(t), "")
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if !strings.Contains(err.Error(), "FetchPublishStatus: publish id cannot be empty") {
+		t.Fatalf("expected error to contain 'FetchPublishStatus: publish id cannot be empty', but got '%v'", err)
+	}
+}
+
+func TestFetchPublishStatusSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointPublishStatus,
+		func(req *http.Request) (*http.Response, error) {
+			if req.Header.Get("Authorization") != "Bearer test-access-token" {
+				return httpmock.NewStringResponse(http.StatusUnauthorized, responseV2Error), nil
+			}
+
+			return httpmock.NewStringResponse(http.StatusOK, responsePublishComplete), nil
+		},
+	)
+
+	info, err := tiktok.FetchPublishStatus(context.Background(), testNewOauthToken(t), "test-publish-id")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.Status != tiktok.PublishStatusPublishComplete {
+		t.Fatalf("expected status '%s', but got %s", tiktok.PublishStatusPublishComplete, info.Status)
+	}
+
+	if len(info.PubliclyAvailablePostID) != 1 || info.PubliclyAvailablePostID[0] != 7300000000000000000 {
+		t.Fatalf("expected post id '7300000000000000000', but got %v", info.PubliclyAvailablePostID)
+	}
+}
+
+func TestFetchPublishStatusError(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointPublishStatus,
+		httpmock.NewStringResponder(http.StatusUnauthorized, responseV2Error),
+	)
+
+	_, err := tiktok.FetchPublishStatus(context.Background(), testNewOauthToken(t), "test-publish-id")
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	var apiErr *tiktok.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected error of type *tiktok.APIError, but got %T", err)
+	}
+
+	if apiErr.Code != "access_token_invalid" {
+		t.Fatalf("expected error code 'access_token_invalid', but got %s", apiErr.Code)
+	}
+}
+
+func TestWaitForPublishSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointPublishStatus,
+		httpmock.ResponderFromMultipleResponses([]*http.Response{
+			httpmock.NewStringResponse(http.StatusOK, responsePublishProcessing),
+			httpmock.NewStringResponse(http.StatusOK, responsePublishProcessing),
+			httpmock.NewStringResponse(http.StatusOK, responsePublishComplete),
+		}),
+	)
+
+	var progress []tiktok.PublishStatus
+	opts := &tiktok.WaitOptions{
+		InitialInterval: time.Millisecond,
+		OnProgress: func(info *tiktok.PublishStatusInfo) {
+			progress = append(progress, info.Status)
+		},
+	}
+
+	info, err := tiktok.WaitForPublish(context.Background(), testNewOauthToken(t), "test-publish-id", opts)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.Status != tiktok.PublishStatusPublishComplete {
+		t.Fatalf("expected status '%s', but got %s", tiktok.PublishStatusPublishComplete, info.Status)
+	}
+
+	if len(progress) != 3 {
+		t.Fatalf("expected 3 progress calls, but got %d", len(progress))
+	}
+}
+
+func TestWaitForPublishFailed(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointPublishStatus,
+		httpmock.NewStringResponder(http.StatusOK, responsePublishFailed),
+	)
+
+	_, err := tiktok.WaitForPublish(context.Background(), testNewOauthToken(t), "test-publish-id", nil)
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	var publishErr *tiktok.PublishError
+	if !errors.As(err, &publishErr) {
+		t.Fatalf("expected error of type *tiktok.PublishError, but got %T", err)
+	}
+
+	if publishErr.Reason != tiktok.PublishFailReasonDurationCheckFailed {
+		t.Fatalf("expected fail reason '%s', but got %s", tiktok.PublishFailReasonDurationCheckFailed, publishErr.Reason)
+	}
+}
+
+func TestWaitForPublishTimeout(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointPublishStatus,
+		httpmock.NewStringResponder(http.StatusOK, responsePublishProcessing),
+	)
+
+	opts := &tiktok.WaitOptions{InitialInterval: time.Millisecond, Timeout: time.Millisecond * 20}
+
+	_, err := tiktok.WaitForPublish(context.Background(), testNewOauthToken(t), "test-publish-id", opts)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error '%v', but got '%v'", context.DeadlineExceeded, err)
+	}
+}
+
+func TestWaitForPublishAPIError(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointPublishStatus,
+		httpmock.NewStringResponder(http.StatusUnauthorized, responseV2Error),
+	)
+
+	_, err := tiktok.WaitForPublish(context.Background(), testNewOauthToken(t), "test-publish-id", nil)
+
+	expected := "tiktok-oauth2: WaitForPublish: The access token is invalid or not found in the request. [access_token_invalid]"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error '%s', but got '%v'", expected, err)
+	}
+}
+
+func TestPostPhotosCaptionTooLong(t *testing.T) {
+	tests := []struct {
+		name          string
+		postInfo      *tiktok.PostInfo
+		expectedError string
+	}{
+		{
+			name:          "title",
+			postInfo:      &tiktok.PostInfo{Title: strings.Repeat("a", 91), PrivacyLevel: "SELF_ONLY"},
+			expectedError: "PostPhotos: photo title length 91 exceeds 90",
+		},
+		{
+			name:          "description",
+			postInfo:      &tiktok.PostInfo{Title: strings.Repeat("a", 90), Description: strings.Repeat("a", 4001), PrivacyLevel: "SELF_ONLY"},
+			expectedError: "PostPhotos: photo description length 4001 exceeds 4000",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			t.Cleanup(httpmock.Deactivate)
+
+			httpmock.ZeroCallCounters()
+
+			_, err := tiktok.PostPhotos(context.Background(), testNewOauthToken(t), []string{"https://example.com/1.jpg"}, 0, tt.postInfo)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.expectedError, err)
+			}
+
+			if calls := httpmock.GetTotalCallCount(); calls != 0 {
+				t.Fatalf("expected no request to be sent, but got %d", calls)
+			}
+		})
+	}
+}
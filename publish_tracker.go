@@ -0,0 +1,264 @@
+package tiktok
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DefaultPublishWebhookTimeout is the time a PublishTracker waits for a webhook by default before
+// polling the status of a post.
+const DefaultPublishWebhookTimeout = time.Minute * 2
+
+// PublishTrackerConfig configures a PublishTracker.
+type PublishTrackerConfig struct {
+	// WebhookTimeout is the time to wait for a webhook resolving a post before polling its status.
+	// Defaults to DefaultPublishWebhookTimeout.
+	WebhookTimeout time.Duration
+	// Wait configures the status polling. Its OnProgress is also called with the statuses received
+	// through webhooks.
+	Wait *WaitOptions
+}
+
+// PublishTracker resolves pending posts from the post.publish webhook events, falling back to
+// polling the status of a post when no webhook arrives in time. Its HandleEvent method is
+// registered with WebhookHandler.OnPostPublish. The posts are tracked in the background until they
+// are resolved or the tracker is closed.
+type PublishTracker struct {
+	cfg PublishTrackerConfig
+
+	// ctx is cancelled by Close, stopping the tracking of the pending posts.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending map[string]*PendingPublish
+	// early holds the final events received for posts not tracked yet, as a webhook may arrive
+	// before Track is called with the publish id returned by the API.
+	early map[string]*publishResult
+}
+
+// PendingPublish is a post tracked by a PublishTracker until it is published or failed.
+type PendingPublish struct {
+	PublishID string
+
+	once   sync.Once
+	done   chan struct{}
+	result publishResult
+}
+
+type publishResult struct {
+	info *PublishStatusInfo
+	err  error
+	at   time.Time
+}
+
+// NewPublishTracker returns a new PublishTracker. Close it to stop tracking the pending posts.
+func NewPublishTracker(cfg PublishTrackerConfig) *PublishTracker {
+	if cfg.WebhookTimeout <= 0 {
+		cfg.WebhookTimeout = DefaultPublishWebhookTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &PublishTracker{
+		cfg:     cfg,
+		ctx:     ctx,
+		cancel:  cancel,
+		pending: make(map[string]*PendingPublish),
+		early:   make(map[string]*publishResult),
+	}
+}
+
+// Track registers a post initiated by the user of the token. The post is resolved by the first
+// post.publish webhook reporting it published or failed, or by polling its status with the token
+// once the webhook timeout elapsed. Tracking a post already tracked returns the same
+// PendingPublish.
+//
+// The post is tracked until it is resolved or the tracker is closed, even if the context is done
+// first, so Track may be called with a request scoped context. The status is polled with the
+// values of the context, such as its HTTP client and interceptors.
+func (t *PublishTracker) Track(ctx context.Context, token *oauth2.Token, publishID string) (*PendingPublish, error) {
+	if publishID == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: Track: publish id cannot be empty")
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.ctx.Err() != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: Track: tracker is closed")
+	}
+
+	if p, ok := t.pending[publishID]; ok {
+		return p, nil
+	}
+
+	p := &PendingPublish{PublishID: publishID, done: make(chan struct{})}
+
+	if early, ok := t.early[publishID]; ok {
+		delete(t.early, publishID)
+		p.resolve(early.info, early.err)
+
+		return p, nil
+	}
+
+	t.pending[publishID] = p
+
+	go t.watch(trackContext{Context: t.ctx, values: ctx}, token, p)
+
+	return p, nil
+}
+
+// Close stops tracking the pending posts, which are resolved with a context.Canceled error, and
+// rejects the posts tracked afterwards.
+func (t *PublishTracker) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.cancel()
+
+	return nil
+}
+
+// trackContext is cancelled with the tracker, but carries the values of the context passed to
+// Track.
+type trackContext struct {
+	context.Context
+	values context.Context
+}
+
+// Value implements context.Context.
+func (c trackContext) Value(key interface{}) interface{} {
+	return c.values.Value(key)
+}
+
+// HandleEvent resolves the tracked post of a post.publish event. Events of posts not tracked by
+// this tracker are kept for the webhook timeout, in case the post is tracked shortly after.
+func (t *PublishTracker) HandleEvent(_ context.Context, event *PostPublishEvent) error {
+	info := &PublishStatusInfo{PublishID: event.PublishID}
+
+	var err error
+
+	switch event.Type {
+	case EventPostPublishComplete, EventPostPublishPubliclyAvailable:
+		info.Status = PublishStatusPublishComplete
+		if postID, parseErr := strconv.ParseInt(event.PostID, 10, 64); parseErr == nil {
+			info.PubliclyAvailablePostID = []int64{postID}
+		}
+	case EventPostPublishFailed:
+		info.Status = PublishStatusFailed
+		info.FailReason = event.Reason
+		err = fmt.Errorf("tiktok-oauth2: PublishTracker: %w", &PublishError{PublishID: event.PublishID, Reason: event.Reason})
+	case EventPostPublishInboxDelivered:
+		info.Status = PublishStatusSendToUserInbox
+	default:
+		return nil
+	}
+
+	if event.PublishID == "" {
+		return nil
+	}
+
+	if t.cfg.Wait != nil && t.cfg.Wait.OnProgress != nil {
+		t.cfg.Wait.OnProgress(info)
+	}
+
+	if !info.Status.Done() {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for publishID, early := range t.early {
+		if now.Sub(early.at) > t.cfg.WebhookTimeout {
+			delete(t.early, publishID)
+		}
+	}
+
+	p, ok := t.pending[event.PublishID]
+	if !ok {
+		if _, resolved := t.early[event.PublishID]; !resolved {
+			t.early[event.PublishID] = &publishResult{info: info, err: err, at: now}
+		}
+
+		return nil
+	}
+
+	delete(t.pending, event.PublishID)
+	p.resolve(info, err)
+
+	return nil
+}
+
+// watch polls the status of the post once the webhook timeout elapsed, until it is resolved.
+func (t *PublishTracker) watch(ctx context.Context, token *oauth2.Token, p *PendingPublish) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	timer := time.NewTimer(t.cfg.WebhookTimeout)
+	select {
+	case <-p.done:
+		timer.Stop()
+		return
+	case <-ctx.Done():
+		timer.Stop()
+		t.resolve(p, nil, fmt.Errorf("tiktok-oauth2: PublishTracker: %w", ctx.Err()))
+		return
+	case <-timer.C:
+	}
+
+	// A webhook arriving while polling resolves the post and stops the polling.
+	go func() {
+		select {
+		case <-p.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	info, err := WaitForPublish(ctx, token, p.PublishID, t.cfg.Wait)
+	t.resolve(p, info, err)
+}
+
+// resolve resolves a tracked post, unless it was resolved already, and stops tracking it.
+func (t *PublishTracker) resolve(p *PendingPublish, info *PublishStatusInfo, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.pending[p.PublishID] == p {
+		delete(t.pending, p.PublishID)
+	}
+
+	p.resolve(info, err)
+}
+
+func (p *PendingPublish) resolve(info *PublishStatusInfo, err error) {
+	p.once.Do(func() {
+		p.result = publishResult{info: info, err: err, at: time.Now()}
+		close(p.done)
+	})
+}
+
+// Done returns a channel closed once the post is resolved.
+func (p *PendingPublish) Done() <-chan struct{} {
+	return p.done
+}
+
+// Wait waits until the post is resolved and returns its final status. When the post fails the
+// status is returned along with a *PublishError. Wait returns early with the context error if the
+// context is done first; the post stays tracked.
+func (p *PendingPublish) Wait(ctx context.Context) (*PublishStatusInfo, error) {
+	select {
+	case <-p.done:
+		return p.result.info, p.result.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("tiktok-oauth2: PendingPublish: %w", ctx.Err())
+	}
+}
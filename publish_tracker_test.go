@@ -0,0 +1,230 @@
+package tiktok_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+// testDeliverPublishEvent delivers a post.publish webhook event to a handler resolving the posts of
+// the tracker.
+func testDeliverPublishEvent(t *testing.T, tracker *tiktok.PublishTracker, event tiktok.WebhookEventType, content string) {
+	t.Helper()
+
+	h := testNewWebhookHandler(t)
+	h.OnPostPublish(tracker.HandleEvent)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, testWebhookRequest(t, testWebhookBody(t, event, content), time.Now()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, but got %d", http.StatusOK, rec.Code)
+	}
+}
+
+func TestPublishTrackerWebhook(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+	httpmock.ZeroCallCounters()
+
+	var statuses []tiktok.PublishStatus
+
+	tracker := tiktok.NewPublishTracker(tiktok.PublishTrackerConfig{
+		WebhookTimeout: time.Hour,
+		Wait: &tiktok.WaitOptions{
+			OnProgress: func(info *tiktok.PublishStatusInfo) {
+				statuses = append(statuses, info.Status)
+			},
+		},
+	})
+
+	pending, err := tracker.Track(context.Background(), testNewOauthToken(t), "test-publish-id")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	testDeliverPublishEvent(t, tracker, tiktok.EventPostPublishInboxDelivered, `{"publish_id":"test-publish-id"}`)
+	testDeliverPublishEvent(t, tracker, tiktok.EventPostPublishComplete, `{"publish_id":"other-publish-id"}`)
+
+	select {
+	case <-pending.Done():
+		t.Fatal("expected post to be pending")
+	default:
+	}
+
+	testDeliverPublishEvent(t, tracker, tiktok.EventPostPublishComplete, `{"publish_id":"test-publish-id"}`)
+
+	info, err := pending.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.Status != tiktok.PublishStatusPublishComplete {
+		t.Fatalf("expected status '%s', but got '%s'", tiktok.PublishStatusPublishComplete, info.Status)
+	}
+
+	expectedStatuses := "SEND_TO_USER_INBOX,PUBLISH_COMPLETE,PUBLISH_COMPLETE"
+	if got := joinPublishStatuses(statuses); got != expectedStatuses {
+		t.Fatalf("expected statuses '%s', but got '%s'", expectedStatuses, got)
+	}
+
+	if calls := httpmock.GetCallCountInfo()["POST "+endpointPublishStatus]; calls != 0 {
+		t.Fatalf("expected no status polling, but got %d calls", calls)
+	}
+}
+
+func TestPublishTrackerWebhookFailed(t *testing.T) {
+	tracker := tiktok.NewPublishTracker(tiktok.PublishTrackerConfig{WebhookTimeout: time.Hour})
+
+	pending, err := tracker.Track(context.Background(), testNewOauthToken(t), "test-publish-id")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	testDeliverPublishEvent(t, tracker, tiktok.EventPostPublishFailed, `{"publish_id":"test-publish-id","reason":"spam_risk"}`)
+
+	info, err := pending.Wait(context.Background())
+
+	var publishErr *tiktok.PublishError
+	if !errors.As(err, &publishErr) || publishErr.Reason != tiktok.PublishFailReasonSpamRisk {
+		t.Fatalf("expected publish error, but got '%v'", err)
+	}
+
+	if info == nil || info.Status != tiktok.PublishStatusFailed {
+		t.Fatalf("expected failed status, but got %+v", info)
+	}
+}
+
+func TestPublishTrackerWebhookBeforeTrack(t *testing.T) {
+	tracker := tiktok.NewPublishTracker(tiktok.PublishTrackerConfig{WebhookTimeout: time.Hour})
+
+	testDeliverPublishEvent(t, tracker, tiktok.EventPostPublishPubliclyAvailable, `{"publish_id":"test-publish-id","post_id":"7300000000000000000"}`)
+
+	pending, err := tracker.Track(context.Background(), testNewOauthToken(t), "test-publish-id")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	info, err := pending.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(info.PubliclyAvailablePostID) != 1 || info.PubliclyAvailablePostID[0] != 7300000000000000000 {
+		t.Fatalf("expected post id 7300000000000000000, but got %v", info.PubliclyAvailablePostID)
+	}
+}
+
+func TestPublishTrackerPollingFallback(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointPublishStatus,
+		httpmock.ResponderFromMultipleResponses([]*http.Response{
+			httpmock.NewStringResponse(http.StatusOK, responsePublishProcessing),
+			httpmock.NewStringResponse(http.StatusOK, responsePublishComplete),
+		}),
+	)
+
+	tracker := tiktok.NewPublishTracker(tiktok.PublishTrackerConfig{
+		WebhookTimeout: time.Millisecond,
+		Wait:           &tiktok.WaitOptions{InitialInterval: time.Millisecond},
+	})
+
+	pending, err := tracker.Track(context.Background(), testNewOauthToken(t), "test-publish-id")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	info, err := pending.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.Status != tiktok.PublishStatusPublishComplete {
+		t.Fatalf("expected status '%s', but got '%s'", tiktok.PublishStatusPublishComplete, info.Status)
+	}
+}
+
+func TestPublishTrackerRequestContextCancelled(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(http.MethodPost, endpointPublishStatus, httpmock.NewStringResponder(http.StatusOK, responsePublishComplete))
+
+	tracker := tiktok.NewPublishTracker(tiktok.PublishTrackerConfig{
+		WebhookTimeout: time.Millisecond,
+		Wait:           &tiktok.WaitOptions{InitialInterval: time.Millisecond},
+	})
+	t.Cleanup(func() { tracker.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	pending, err := tracker.Track(ctx, testNewOauthToken(t), "test-publish-id")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// The request that tracked the post is over, the post is still polled.
+	cancel()
+
+	info, err := pending.Wait(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.Status != tiktok.PublishStatusPublishComplete {
+		t.Fatalf("expected status '%s', but got '%s'", tiktok.PublishStatusPublishComplete, info.Status)
+	}
+}
+
+func TestPublishTrackerClose(t *testing.T) {
+	tracker := tiktok.NewPublishTracker(tiktok.PublishTrackerConfig{WebhookTimeout: time.Hour})
+
+	pending, err := tracker.Track(context.Background(), testNewOauthToken(t), "test-publish-id")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err = tracker.Close(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err = pending.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected error '%v', but got '%v'", context.Canceled, err)
+	}
+
+	_, err = tracker.Track(context.Background(), testNewOauthToken(t), "test-publish-id-2")
+
+	expectedError := "tiktok-oauth2: Track: tracker is closed"
+	if err == nil || err.Error() != expectedError {
+		t.Fatalf("expected error '%s', but got '%v'", expectedError, err)
+	}
+}
+
+func TestPublishTrackerInvalidArguments(t *testing.T) {
+	_, err := tiktok.NewPublishTracker(tiktok.PublishTrackerConfig{}).Track(context.Background(), testNewOauthToken(t), "")
+
+	expectedError := "publish id cannot be empty"
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("expected error to contain '%s', but got '%v'", expectedError, err)
+	}
+}
+
+func joinPublishStatuses(statuses []tiktok.PublishStatus) string {
+	values := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		values = append(values, string(status))
+	}
+
+	return strings.Join(values, ",")
+}
@@ -0,0 +1,380 @@
+package tiktok
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// JobStatus is the state of a PublishJob.
+type JobStatus string
+
+// Publish job states. A job is claimed by moving it to running, leased to the claiming queue,
+// before any request is sent to TikTok. A job whose lease expired, e.g. because the process crashed
+// while publishing it, is claimed again and resumes from its publish id or upload checkpoint.
+const (
+	JobStatusPending   JobStatus = "pending"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+)
+
+// PhotoSource holds the photos of a photo post.
+type PhotoSource struct {
+	URLs       []string `json:"urls"`
+	CoverIndex int      `json:"cover_index"`
+}
+
+// PublishJob is a post scheduled through a Queue. Exactly one of VideoPath and Photos must be set.
+type PublishJob struct {
+	// ID is the idempotency key of the job. Enqueuing a job with an existing id is rejected.
+	ID        string       `json:"id"`
+	OpenID    string       `json:"open_id"`
+	PublishAt time.Time    `json:"publish_at"`
+	VideoPath string       `json:"video_path,omitempty"`
+	Photos    *PhotoSource `json:"photos,omitempty"`
+	PostInfo  PostInfo     `json:"post_info"`
+
+	Status JobStatus `json:"status"`
+	// LeaseUntil is the time until which a running job is claimed by a queue.
+	LeaseUntil time.Time `json:"lease_until"`
+	PublishID  string    `json:"publish_id,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// QueueConfig configures a Queue.
+type QueueConfig struct {
+	// Jobs persists the scheduled jobs.
+	Jobs JobStore
+	// Tokens provides the tokens of the creators and stores refreshed ones.
+	Tokens TokenStore
+	// ClientID is used to refresh expired tokens.
+	ClientID string
+	// PollInterval is the delay between checks for due jobs. Defaults to 30 seconds.
+	PollInterval time.Duration
+	// LeaseDuration is the time a claimed job stays running without its lease being renewed, after
+	// which it is claimed again. Leases are renewed while the job is published. Defaults to five
+	// minutes.
+	LeaseDuration time.Duration
+	// Upload configures video uploads. Its PostInfo is ignored in favour of the job's.
+	Upload *UploadOptions
+	// Wait configures the publish status polling.
+	Wait *WaitOptions
+	// Tracker, if set, resolves the posts from webhooks instead, falling back to its own polling.
+	Tracker *PublishTracker
+	// OnJobDone, if set, is called with every job that succeeded or failed.
+	OnJobDone func(*PublishJob)
+}
+
+// Queue schedules posts and publishes them when due, as TikTok has no scheduled posting.
+type Queue struct {
+	cfg QueueConfig
+}
+
+// NewQueue returns a new publishing Queue.
+func NewQueue(cfg QueueConfig) (*Queue, error) {
+	if cfg.Jobs == nil {
+		return nil, fmt.Errorf("tiktok-oauth2: NewQueue: job store cannot be nil")
+	}
+
+	if cfg.Tokens == nil {
+		return nil, fmt.Errorf("tiktok-oauth2: NewQueue: token store cannot be nil")
+	}
+
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: NewQueue: client id cannot be empty")
+	}
+
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second * 30
+	}
+
+	if cfg.LeaseDuration <= 0 {
+		cfg.LeaseDuration = time.Minute * 5
+	}
+
+	return &Queue{cfg: cfg}, nil
+}
+
+// Enqueue schedules a job. It returns an error wrapping ErrJobExists if a job with the same id
+// was already enqueued.
+func (q *Queue) Enqueue(ctx context.Context, job PublishJob) (*PublishJob, error) {
+	if job.ID == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: Enqueue: job id cannot be empty")
+	}
+
+	if job.OpenID == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: Enqueue: open id cannot be empty")
+	}
+
+	if (job.VideoPath == "") == (job.Photos == nil) {
+		return nil, fmt.Errorf("tiktok-oauth2: Enqueue: exactly one of video path and photos must be set")
+	}
+
+	now := time.Now()
+	job.Status = JobStatusPending
+	job.LeaseUntil = time.Time{}
+	job.PublishID = ""
+	job.Error = ""
+	job.CreatedAt = now
+	job.UpdatedAt = now
+
+	if err := q.cfg.Jobs.Create(ctx, &job); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: Enqueue: %w", err)
+	}
+
+	return &job, nil
+}
+
+// Run processes due jobs until the context is done.
+func (q *Queue) Run(ctx context.Context) error {
+	ticker := time.NewTicker(q.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := q.RunDue(ctx); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue processes every job due now and returns the number of jobs processed. Failed jobs are
+// recorded in the job store and do not stop the processing; only job store errors are returned. A
+// job interrupted by the context being done is left to be claimed again.
+func (q *Queue) RunDue(ctx context.Context) (int, error) {
+	processed := 0
+
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		job, err := q.cfg.Jobs.Claim(ctx, now, now.Add(q.cfg.LeaseDuration))
+		if err != nil {
+			return processed, fmt.Errorf("tiktok-oauth2: RunDue: %w", err)
+		}
+
+		if job == nil {
+			break
+		}
+
+		stopLease := q.renewLease(ctx, job.ID)
+		publishID, err := q.publish(ctx, job)
+		stopLease()
+
+		interrupted := err != nil && ctx.Err() != nil
+
+		job.PublishID = publishID
+		job.LeaseUntil = time.Time{}
+		job.UpdatedAt = time.Now()
+
+		switch {
+		case interrupted:
+			job.Status = JobStatusPending
+		case err != nil:
+			job.Status = JobStatusFailed
+			job.Error = err.Error()
+		default:
+			job.Status = JobStatusSucceeded
+		}
+
+		// The outcome is stored even if the context is done, so that it is not lost with the lease.
+		if err = q.cfg.Jobs.Update(detachedContext{ctx}, job); err != nil {
+			return processed, fmt.Errorf("tiktok-oauth2: RunDue: %w", err)
+		}
+
+		if interrupted {
+			break
+		}
+
+		if q.cfg.OnJobDone != nil {
+			q.cfg.OnJobDone(job)
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
+
+// renewLease extends the lease of the claimed job periodically until the returned function is
+// called. A failed renewal is retried on the next tick; if the lease expires meanwhile, the job
+// may be claimed again and resumes from its publish id or upload checkpoint.
+func (q *Queue) renewLease(ctx context.Context, id string) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(q.cfg.LeaseDuration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				_ = q.cfg.Jobs.Renew(ctx, id, now.Add(q.cfg.LeaseDuration))
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// publish posts the content of the job, unless a previous run of the job already did, and waits
+// for TikTok to process it. The returned publish id is set as soon as the post is initiated, even
+// if it fails later.
+func (q *Queue) publish(ctx context.Context, job *PublishJob) (string, error) {
+	token, err := q.token(ctx, job.OpenID)
+	if err != nil {
+		return job.PublishID, err
+	}
+
+	publishID := job.PublishID
+	if publishID == "" {
+		if publishID, err = q.post(ctx, token, job); err != nil {
+			return "", err
+		}
+
+		// Record the publish id, so that a run interrupted from now on does not post again.
+		job.PublishID = publishID
+		if err = q.cfg.Jobs.Update(ctx, job); err != nil {
+			return publishID, err
+		}
+	}
+
+	if q.cfg.Tracker == nil {
+		if _, err = WaitForPublish(ctx, token, publishID, q.cfg.Wait); err != nil {
+			return publishID, err
+		}
+
+		return publishID, nil
+	}
+
+	pending, err := q.cfg.Tracker.Track(ctx, token, publishID)
+	if err != nil {
+		return publishID, err
+	}
+
+	if _, err = pending.Wait(ctx); err != nil {
+		return publishID, err
+	}
+
+	return publishID, nil
+}
+
+// post initiates the post of the content of the job and returns its publish id.
+func (q *Queue) post(ctx context.Context, token *oauth2.Token, job *PublishJob) (string, error) {
+	creatorInfo, err := QueryCreatorInfo(ctx, token)
+	if err != nil {
+		return "", err
+	}
+
+	if !containsString(creatorInfo.PrivacyLevelOptions, job.PostInfo.PrivacyLevel) {
+		return "", fmt.Errorf("privacy level %q is not available to the creator", job.PostInfo.PrivacyLevel)
+	}
+
+	postInfo := job.PostInfo
+
+	if job.Photos != nil {
+		return PostPhotos(ctx, token, job.Photos.URLs, job.Photos.CoverIndex, &postInfo)
+	}
+
+	return q.uploadVideo(ctx, token, job, creatorInfo, &postInfo)
+}
+
+func (q *Queue) uploadVideo(ctx context.Context, token *oauth2.Token, job *PublishJob, creatorInfo *CreatorInfo, postInfo *PostInfo) (string, error) {
+	file, err := os.Open(job.VideoPath)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	info, err := InspectVideo(file, stat.Size())
+	if err != nil {
+		return "", err
+	}
+
+	if err = info.Validate(DefaultVideoLimits().WithCreatorInfo(creatorInfo)); err != nil {
+		return "", err
+	}
+
+	opts := UploadOptions{}
+	if q.cfg.Upload != nil {
+		opts = *q.cfg.Upload
+	}
+	opts.PostInfo = postInfo
+
+	if opts.Checkpoint != nil {
+		opts.CheckpointKey = job.ID
+	}
+
+	return UploadVideo(ctx, token, file, stat.Size(), &opts)
+}
+
+// token returns a valid token of the creator, refreshing and storing it if it has expired.
+func (q *Queue) token(ctx context.Context, openID string) (*oauth2.Token, error) {
+	token, err := q.cfg.Tokens.Token(ctx, openID)
+	if err != nil {
+		return nil, err
+	}
+
+	if token == nil {
+		return nil, fmt.Errorf("no token found for open id %s", openID)
+	}
+
+	if token.Valid() {
+		return token, nil
+	}
+
+	token, err = RefreshToken(ctx, q.cfg.ClientID, token.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = q.cfg.Tokens.SaveToken(ctx, openID, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// detachedContext keeps the values of its context but is never done, to store the outcome of a job
+// after the context it ran with is done.
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
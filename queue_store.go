@@ -0,0 +1,191 @@
+package tiktok
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrJobExists is returned when a job is enqueued with the id of an existing job.
+var ErrJobExists = errors.New("job already exists")
+
+// JobStore persists the jobs of a publishing Queue.
+type JobStore interface {
+	// Create stores a new job, returning ErrJobExists if a job with the same id exists.
+	Create(ctx context.Context, job *PublishJob) error
+	// Claim atomically moves the earliest job due at now, that is pending or running with a lease
+	// expired at now, to the running state leased until leaseUntil and returns it, or returns nil
+	// if no job is due.
+	Claim(ctx context.Context, now, leaseUntil time.Time) (*PublishJob, error)
+	// Renew extends the lease of a running job until leaseUntil.
+	Renew(ctx context.Context, id string, leaseUntil time.Time) error
+	// Update replaces a stored job.
+	Update(ctx context.Context, job *PublishJob) error
+	// Get returns the job with the given id, or nil if there is none.
+	Get(ctx context.Context, id string) (*PublishJob, error)
+}
+
+// FileJobStore is a JobStore keeping all jobs in a single JSON file.
+type FileJobStore struct {
+	mu   sync.Mutex
+	path string
+	jobs map[string]*PublishJob
+}
+
+// NewFileJobStore returns a new FileJobStore backed by the file at path, loading any jobs it holds.
+func NewFileJobStore(path string) (*FileJobStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: NewFileJobStore: path cannot be empty")
+	}
+
+	store := &FileJobStore{path: path, jobs: make(map[string]*PublishJob)}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("tiktok-oauth2: NewFileJobStore: %w", err)
+	}
+
+	if len(data) > 0 {
+		if err = json.Unmarshal(data, &store.jobs); err != nil {
+			return nil, fmt.Errorf("tiktok-oauth2: NewFileJobStore: %w", err)
+		}
+	}
+
+	return store, nil
+}
+
+// Create implements JobStore.
+func (s *FileJobStore) Create(_ context.Context, job *PublishJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[job.ID]; ok {
+		return ErrJobExists
+	}
+
+	s.jobs[job.ID] = copyJob(job)
+
+	if err := s.flush(); err != nil {
+		delete(s.jobs, job.ID)
+		return err
+	}
+
+	return nil
+}
+
+// Claim implements JobStore.
+func (s *FileJobStore) Claim(_ context.Context, now, leaseUntil time.Time) (*PublishJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	due := make([]*PublishJob, 0)
+	for _, job := range s.jobs {
+		claimable := job.Status == JobStatusPending ||
+			(job.Status == JobStatusRunning && !job.LeaseUntil.After(now))
+
+		if claimable && !job.PublishAt.After(now) {
+			due = append(due, job)
+		}
+	}
+
+	if len(due) == 0 {
+		return nil, nil
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].PublishAt.Before(due[j].PublishAt)
+	})
+
+	job := due[0]
+	previous := *job
+
+	job.Status = JobStatusRunning
+	job.LeaseUntil = leaseUntil
+	job.UpdatedAt = now
+
+	if err := s.flush(); err != nil {
+		*job = previous
+		return nil, err
+	}
+
+	return copyJob(job), nil
+}
+
+// Update implements JobStore.
+func (s *FileJobStore) Update(_ context.Context, job *PublishJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.jobs[job.ID]; !ok {
+		return fmt.Errorf("tiktok-oauth2: FileJobStore: job %s not found", job.ID)
+	}
+
+	s.jobs[job.ID] = copyJob(job)
+
+	return s.flush()
+}
+
+// Renew implements JobStore.
+func (s *FileJobStore) Renew(_ context.Context, id string, leaseUntil time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok || job.Status != JobStatusRunning {
+		return fmt.Errorf("tiktok-oauth2: FileJobStore: running job %s not found", id)
+	}
+
+	previous := job.LeaseUntil
+	job.LeaseUntil = leaseUntil
+
+	if err := s.flush(); err != nil {
+		job.LeaseUntil = previous
+		return err
+	}
+
+	return nil
+}
+
+// Get implements JobStore.
+func (s *FileJobStore) Get(_ context.Context, id string) (*PublishJob, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, nil
+	}
+
+	return copyJob(job), nil
+}
+
+// flush atomically replaces the store file with the current jobs.
+func (s *FileJobStore) flush() error {
+	data, err := json.Marshal(s.jobs)
+	if err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileJobStore: %w", err)
+	}
+
+	if err = writeFileAtomic(s.path, data); err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileJobStore: %w", err)
+	}
+
+	return nil
+}
+
+func copyJob(job *PublishJob) *PublishJob {
+	c := *job
+	if job.Photos != nil {
+		photos := *job.Photos
+		photos.URLs = append([]string(nil), job.Photos.URLs...)
+		c.Photos = &photos
+	}
+
+	return &c
+}
@@ -0,0 +1,400 @@
+package tiktok_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+const endpointContentInit = "https://open.tiktokapis.com/v2/post/publish/content/init/"
+
+func newTestQueue(t *testing.T, tokens tiktok.TokenStore) (*tiktok.Queue, *tiktok.FileJobStore) {
+	t.Helper()
+
+	store, err := tiktok.NewFileJobStore(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	queue, err := tiktok.NewQueue(tiktok.QueueConfig{
+		Jobs:     store,
+		Tokens:   tokens,
+		ClientID: "test-client-id",
+		Wait:     &tiktok.WaitOptions{InitialInterval: time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return queue, store
+}
+
+func newTestTokenStore(t *testing.T, expired bool) *tiktok.MemoryTokenStore {
+	t.Helper()
+
+	token := testNewOauthToken(t)
+	if expired {
+		token.Expiry = time.Now().Add(-time.Hour)
+	}
+
+	tokens := tiktok.NewMemoryTokenStore()
+	if err := tokens.SaveToken(context.Background(), "test-open-id", token); err != nil {
+		t.Fatal(err)
+	}
+
+	return tokens
+}
+
+func registerTestPublishResponders(t *testing.T) {
+	t.Helper()
+
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(http.MethodPost, endpointCreatorInfo, httpmock.NewStringResponder(http.StatusOK, responseCreatorInfo))
+	httpmock.RegisterResponder(http.MethodPost, endpointContentInit, httpmock.NewStringResponder(http.StatusOK, responseContentInit))
+	httpmock.RegisterResponder(http.MethodPost, endpointVideoInit, httpmock.NewStringResponder(http.StatusOK, responseVideoInit))
+	httpmock.RegisterResponder(http.MethodPut, testUploadURL, httpmock.NewStringResponder(http.StatusCreated, ""))
+	httpmock.RegisterResponder(http.MethodPost, endpointPublishStatus, httpmock.NewStringResponder(http.StatusOK, responsePublishComplete))
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		"https://open-api.tiktok.com/oauth/refresh_token/",
+		testFormResponder(refreshTokenParameters, responseSuccessToken),
+	)
+}
+
+func testPhotoJob(id string, publishAt time.Time) tiktok.PublishJob {
+	return tiktok.PublishJob{
+		ID:        id,
+		OpenID:    "test-open-id",
+		PublishAt: publishAt,
+		Photos:    &tiktok.PhotoSource{URLs: []string{"https://example.com/photo.jpg"}},
+		PostInfo:  tiktok.PostInfo{Title: "test-title", PrivacyLevel: "SELF_ONLY"},
+	}
+}
+
+func TestQueueEnqueueInvalidArguments(t *testing.T) {
+	queue, _ := newTestQueue(t, tiktok.NewMemoryTokenStore())
+
+	tests := []struct {
+		name          string
+		job           tiktok.PublishJob
+		errorContains string
+	}{
+		{
+			name:          "empty id",
+			job:           tiktok.PublishJob{OpenID: "test-open-id", VideoPath: "video.mp4"},
+			errorContains: "Enqueue: job id cannot be empty",
+		},
+		{
+			name:          "empty open id",
+			job:           tiktok.PublishJob{ID: "test-job", VideoPath: "video.mp4"},
+			errorContains: "Enqueue: open id cannot be empty",
+		},
+		{
+			name:          "no content",
+			job:           tiktok.PublishJob{ID: "test-job", OpenID: "test-open-id"},
+			errorContains: "Enqueue: exactly one of video path and photos must be set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := queue.Enqueue(context.Background(), tt.job)
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.errorContains) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestQueueEnqueueIdempotent(t *testing.T) {
+	queue, _ := newTestQueue(t, tiktok.NewMemoryTokenStore())
+
+	if _, err := queue.Enqueue(context.Background(), testPhotoJob("test-job", time.Now())); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	_, err := queue.Enqueue(context.Background(), testPhotoJob("test-job", time.Now()))
+	if !errors.Is(err, tiktok.ErrJobExists) {
+		t.Fatalf("expected error '%v', but got '%v'", tiktok.ErrJobExists, err)
+	}
+}
+
+func TestQueueRunDuePhotoJob(t *testing.T) {
+	registerTestPublishResponders(t)
+
+	tokens := newTestTokenStore(t, true)
+	queue, store := newTestQueue(t, tokens)
+
+	if _, err := queue.Enqueue(context.Background(), testPhotoJob("test-job-due", time.Now().Add(-time.Minute))); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := queue.Enqueue(context.Background(), testPhotoJob("test-job-later", time.Now().Add(time.Hour))); err != nil {
+		t.Fatal(err)
+	}
+
+	processed, err := queue.RunDue(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if processed != 1 {
+		t.Fatalf("expected 1 processed job, but got %d", processed)
+	}
+
+	job, err := store.Get(context.Background(), "test-job-due")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Status != tiktok.JobStatusSucceeded || job.PublishID != "test-publish-id" {
+		t.Fatalf("expected job to succeed with publish id 'test-publish-id', but got %s %s %s", job.Status, job.PublishID, job.Error)
+	}
+
+	token, err := tokens.Token(context.Background(), "test-open-id")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !token.Valid() {
+		t.Fatal("expected refreshed token to be stored")
+	}
+
+	later, err := store.Get(context.Background(), "test-job-later")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if later.Status != tiktok.JobStatusPending {
+		t.Fatalf("expected job not due to be pending, but got %s", later.Status)
+	}
+}
+
+func TestQueueRunDueVideoJob(t *testing.T) {
+	registerTestPublishResponders(t)
+
+	videoPath := filepath.Join(t.TempDir(), "video.mp4")
+	if err := ioutil.WriteFile(videoPath, testMP4(1080, 1920, "avc1", false), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	queue, store := newTestQueue(t, newTestTokenStore(t, false))
+
+	job := tiktok.PublishJob{
+		ID:        "test-job",
+		OpenID:    "test-open-id",
+		PublishAt: time.Now(),
+		VideoPath: videoPath,
+		PostInfo:  tiktok.PostInfo{PrivacyLevel: "PUBLIC_TO_EVERYONE"},
+	}
+
+	if _, err := queue.Enqueue(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := queue.RunDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	got, err := store.Get(context.Background(), "test-job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status != tiktok.JobStatusSucceeded {
+		t.Fatalf("expected job to succeed, but got %s: %s", got.Status, got.Error)
+	}
+}
+
+func TestQueueRunDueUnavailablePrivacyLevel(t *testing.T) {
+	registerTestPublishResponders(t)
+
+	queue, store := newTestQueue(t, newTestTokenStore(t, false))
+
+	job := testPhotoJob("test-job", time.Now())
+	job.PostInfo.PrivacyLevel = "FOLLOWER_OF_CREATOR"
+
+	if _, err := queue.Enqueue(context.Background(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := queue.RunDue(context.Background()); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	got, err := store.Get(context.Background(), "test-job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Status != tiktok.JobStatusFailed {
+		t.Fatalf("expected job to fail, but got %s", got.Status)
+	}
+
+	if !strings.Contains(got.Error, `privacy level "FOLLOWER_OF_CREATOR" is not available`) {
+		t.Fatalf("expected error to mention the privacy level, but got '%s'", got.Error)
+	}
+
+	if calls := httpmock.GetCallCountInfo()["POST "+endpointContentInit]; calls != 0 {
+		t.Fatalf("expected no post to be initiated, but got %d calls", calls)
+	}
+}
+
+func TestFileJobStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+
+	store, err := tiktok.NewFileJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := testPhotoJob("test-job", time.Now())
+	job.Status = tiktok.JobStatusPending
+	if err = store.Create(context.Background(), &job); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	now := time.Now()
+
+	claimed, err := store.Claim(context.Background(), now, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if claimed == nil || claimed.Status != tiktok.JobStatusRunning {
+		t.Fatalf("expected claimed job to be running, but got %+v", claimed)
+	}
+
+	reopened, err := tiktok.NewFileJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := reopened.Get(context.Background(), "test-job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got == nil || got.Status != tiktok.JobStatusRunning {
+		t.Fatalf("expected persisted job to be running, but got %+v", got)
+	}
+
+	if again, _ := reopened.Claim(context.Background(), now, now.Add(time.Minute)); again != nil {
+		t.Fatalf("expected leased job not to be claimed again, but got %+v", again)
+	}
+
+	if err = reopened.Renew(context.Background(), "test-job", now.Add(time.Minute*2)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	later := now.Add(time.Minute + time.Second)
+	if again, _ := reopened.Claim(context.Background(), later, later.Add(time.Minute)); again != nil {
+		t.Fatalf("expected renewed job not to be claimed again, but got %+v", again)
+	}
+
+	// The queue that claimed the job crashed and stopped renewing its lease.
+	expired := now.Add(time.Minute * 3)
+
+	again, err := reopened.Claim(context.Background(), expired, expired.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if again == nil || again.ID != "test-job" || !again.LeaseUntil.Equal(expired.Add(time.Minute)) {
+		t.Fatalf("expected job with expired lease to be claimed again, but got %+v", again)
+	}
+}
+
+// cancelCheckingJobStore fails updates made with a done context.
+type cancelCheckingJobStore struct {
+	*tiktok.FileJobStore
+}
+
+func (s cancelCheckingJobStore) Update(ctx context.Context, job *tiktok.PublishJob) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return s.FileJobStore.Update(ctx, job)
+}
+
+func TestQueueRunDueInterrupted(t *testing.T) {
+	registerTestPublishResponders(t)
+
+	store, err := tiktok.NewFileJobStore(filepath.Join(t.TempDir(), "jobs.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := 0
+
+	queue, err := tiktok.NewQueue(tiktok.QueueConfig{
+		Jobs:      cancelCheckingJobStore{store},
+		Tokens:    newTestTokenStore(t, false),
+		ClientID:  "test-client-id",
+		Wait:      &tiktok.WaitOptions{InitialInterval: time.Millisecond},
+		OnJobDone: func(*tiktok.PublishJob) { done++ },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = queue.Enqueue(context.Background(), testPhotoJob("test-job", time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The queue is stopped while it waits for TikTok to process the post.
+	httpmock.RegisterResponder(http.MethodPost, endpointPublishStatus, func(*http.Request) (*http.Response, error) {
+		cancel()
+		return httpmock.NewStringResponse(http.StatusOK, responsePublishProcessing), nil
+	})
+
+	processed, err := queue.RunDue(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	job, err := store.Get(context.Background(), "test-job")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if processed != 0 || done != 0 || job.Status != tiktok.JobStatusPending || job.PublishID != "test-publish-id" {
+		t.Fatalf("expected interrupted job to be pending with its publish id, but got %d processed, %+v", processed, job)
+	}
+
+	httpmock.RegisterResponder(http.MethodPost, endpointPublishStatus, httpmock.NewStringResponder(http.StatusOK, responsePublishComplete))
+
+	if processed, err = queue.RunDue(context.Background()); err != nil || processed != 1 {
+		t.Fatalf("expected 1 processed job, but got %d, %v", processed, err)
+	}
+
+	if job, err = store.Get(context.Background(), "test-job"); err != nil {
+		t.Fatal(err)
+	}
+
+	if job.Status != tiktok.JobStatusSucceeded {
+		t.Fatalf("expected resumed job to succeed, but got %s %s", job.Status, job.Error)
+	}
+
+	if calls := httpmock.GetCallCountInfo()["POST "+endpointContentInit]; calls != 1 {
+		t.Fatalf("expected the post to be initiated once, but got %d calls", calls)
+	}
+}
@@ -0,0 +1,70 @@
+package tiktok
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// secretPattern matches the values of the credential parameters and bearer tokens, which must never
+// appear in the errors returned by the package. The code parameter is only matched in queries and
+// forms, as other codes, such as status codes, are no secrets.
+var secretPattern = regexp.MustCompile(`(?i)((?:^|[?&])code=|\b(?:client_secret|access_token|refresh_token)=|Bearer\s+)[^&\s"']+`)
+
+// redactedError is an error whose message has its secrets scrubbed. It unwraps to the original
+// error, so errors.Is and errors.As keep working.
+type redactedError struct {
+	err     error
+	message string
+}
+
+// Error implements the error interface.
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+// Unwrap returns the original error.
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
+
+// redactError returns the error with the secrets, and the values of credential parameters and
+// bearer tokens, scrubbed from its message. Errors with nothing to scrub are returned unchanged.
+func redactError(err error, secrets ...string) error {
+	if err == nil {
+		return nil
+	}
+
+	message := redactString(err.Error(), secrets...)
+	if message == err.Error() {
+		return err
+	}
+
+	return &redactedError{err: err, message: message}
+}
+
+// redactString returns s with the secrets, and the values of credential parameters and bearer
+// tokens, replaced.
+func redactString(s string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+
+	return secretPattern.ReplaceAllString(s, "${1}"+redacted)
+}
+
+// sendRequest sends the request with the client of the context, or the fallback client, and
+// scrubs the secrets from the error, as transports may include parts of the request in it.
+func sendRequest(ctx context.Context, fallback *http.Client, req *http.Request, secrets ...string) (*http.Response, error) {
+	response, err := contextClient(ctx, fallback).Do(req)
+	if err != nil {
+		return nil, redactError(err, secrets...)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,177 @@
+package tiktok_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+var (
+	testSecrets = []string{"test-client-secret", "test-access-token", "test-refresh-token", "test-code"}
+
+	// errTestTransport is returned by the leaky responders below.
+	errTestTransport = errors.New("transport failure")
+)
+
+// testLeakyResponder returns a responder failing with an error echoing the whole request, as some
+// transports and proxies do.
+func testLeakyResponder(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		body, _ = ioutil.ReadAll(req.Body)
+	}
+
+	return nil, fmt.Errorf("%s %s authorization=%q body=%q: %w", req.Method, req.URL, req.Header.Get("Authorization"), body, errTestTransport)
+}
+
+// testEchoResponder returns a responder replying with an error, in the format of the endpoint,
+// describing the request credentials.
+func testEchoResponder(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("invalid request %s with %s", req.Form.Encode(), req.Header.Get("Authorization"))
+
+	var body string
+	switch {
+	case req.URL.Path == "/v2/oauth/token/":
+		body = fmt.Sprintf(`{"error":"invalid_client","error_description":%q,"log_id":"test-log-id"}`, description)
+	case strings.HasPrefix(req.URL.Path, "/v2/"):
+		body = fmt.Sprintf(`{"data":{},"error":{"code":"invalid_params","message":%q,"log_id":"test-log-id"}}`, description)
+	default:
+		body = fmt.Sprintf(`{"data":{"description":%q,"error_code":10002},"message":"error"}`, description)
+	}
+
+	return httpmock.NewStringResponse(http.StatusOK, body), nil
+}
+
+// testCredentialCalls returns the calls sending the test secrets, by name.
+func testCredentialCalls(t *testing.T) map[string]func(ctx context.Context) error {
+	t.Helper()
+
+	return map[string]func(ctx context.Context) error{
+		"ConfigExchange": func(ctx context.Context) error {
+			_, err := tiktok.ConfigExchange(ctx, testNewOauthConfig(t), "test-code")
+			return err
+		},
+		"RefreshToken": func(ctx context.Context) error {
+			_, err := tiktok.RefreshToken(ctx, "test-client-id", "test-refresh-token")
+			return err
+		},
+		"ClientCredentialsToken": func(ctx context.Context) error {
+			_, err := tiktok.ClientCredentialsToken(ctx, "test-client-id", "test-client-secret")
+			return err
+		},
+		"RevokeAccess": func(ctx context.Context) error {
+			return tiktok.RevokeAccess(ctx, testNewOauthToken(t).WithExtra(map[string]interface{}{"open_id": "test-open-id"}))
+		},
+		"RetrieveUserInfo": func(ctx context.Context) error {
+			_, err := tiktok.RetrieveUserInfo(ctx, testNewOauthToken(t).WithExtra(map[string]interface{}{"open_id": "test-open-id"}))
+			return err
+		},
+		"QueryCreatorInfo": func(ctx context.Context) error {
+			_, err := tiktok.QueryCreatorInfo(ctx, testNewOauthToken(t))
+			return err
+		},
+	}
+}
+
+func TestErrorsDoNotLeakSecrets(t *testing.T) {
+	calls := testCredentialCalls(t)
+
+	responders := map[string]httpmock.Responder{
+		"transport error": testLeakyResponder,
+		"error response":  testEchoResponder,
+	}
+
+	for name, call := range calls {
+		for kind, responder := range responders {
+			t.Run(name+" "+kind, func(t *testing.T) {
+				httpmock.Activate()
+				t.Cleanup(httpmock.DeactivateAndReset)
+
+				// Responders registered by other tests would answer before the no responder.
+				httpmock.Reset()
+				httpmock.RegisterNoResponder(responder)
+
+				err := call(context.Background())
+				if err == nil {
+					t.Fatal("expected error but got nil")
+				}
+
+				if kind == "error response" && !strings.Contains(err.Error(), "invalid request") {
+					t.Fatalf("expected error to contain '%s', but got '%v'", "invalid request", err)
+				}
+
+				for _, secret := range testSecrets {
+					if strings.Contains(err.Error(), secret) {
+						t.Fatalf("expected error not to contain '%s', but got '%v'", secret, err)
+					}
+				}
+
+				if kind == "transport error" && !errors.Is(err, errTestTransport) {
+					t.Fatalf("expected error to wrap '%v', but got '%v'", errTestTransport, err)
+				}
+			})
+		}
+	}
+}
+
+func TestErrorsKeepStatusCodes(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.DeactivateAndReset)
+
+	httpmock.Reset()
+	httpmock.RegisterNoResponder(httpmock.NewErrorResponder(errors.New("upstream status code=500")))
+
+	_, err := tiktok.ConfigExchange(context.Background(), testNewOauthConfig(t), "test-code")
+	if err == nil || !strings.Contains(err.Error(), "upstream status code=500") {
+		t.Fatalf("expected error to contain '%s', but got '%v'", "upstream status code=500", err)
+	}
+
+	if strings.Contains(err.Error(), "test-code") {
+		t.Fatalf("expected error not to contain '%s', but got '%v'", "test-code", err)
+	}
+}
+
+func TestRequestURLsDoNotLeakSecrets(t *testing.T) {
+	for name, call := range testCredentialCalls(t) {
+		t.Run(name, func(t *testing.T) {
+			httpmock.Activate()
+			t.Cleanup(httpmock.DeactivateAndReset)
+
+			httpmock.Reset()
+			httpmock.RegisterNoResponder(testEchoResponder)
+
+			var urls []string
+
+			// Interceptors see the outgoing requests, as do proxies and tracing.
+			ctx := tiktok.WithInterceptors(context.Background(), func(call *tiktok.Call, next tiktok.Handler) error {
+				urls = append(urls, call.Request.URL.String())
+				return next(call)
+			})
+
+			_ = call(ctx)
+
+			if len(urls) == 0 {
+				t.Fatal("expected a request to be sent")
+			}
+
+			for _, u := range urls {
+				for _, secret := range testSecrets {
+					if strings.Contains(u, secret) {
+						t.Fatalf("expected request url not to contain '%s', but got '%s'", secret, u)
+					}
+				}
+			}
+		})
+	}
+}
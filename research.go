@@ -0,0 +1,316 @@
+package tiktok
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// researchDateLayout is the date format used by the Research API.
+	researchDateLayout = "20060102"
+	// MaxResearchDateRange is the longest date range a single Research API query may cover.
+	MaxResearchDateRange = 30 * 24 * time.Hour
+	// MaxResearchPageSize is the maximum number of results of a single Research API request.
+	MaxResearchPageSize = 100
+)
+
+// ResearchField is a field a Research API video query condition applies to.
+type ResearchField string
+
+// Fields supported in Research API video query conditions.
+const (
+	FieldCreateDate  ResearchField = "create_date"
+	FieldUsername    ResearchField = "username"
+	FieldRegionCode  ResearchField = "region_code"
+	FieldVideoID     ResearchField = "video_id"
+	FieldHashtagName ResearchField = "hashtag_name"
+	FieldKeyword     ResearchField = "keyword"
+	FieldMusicID     ResearchField = "music_id"
+	FieldEffectID    ResearchField = "effect_id"
+	FieldVideoLength ResearchField = "video_length"
+)
+
+// ResearchOperation is the comparison of a Research API video query condition.
+type ResearchOperation string
+
+// Operations supported in Research API video query conditions.
+const (
+	OperationEQ  ResearchOperation = "EQ"
+	OperationIN  ResearchOperation = "IN"
+	OperationGT  ResearchOperation = "GT"
+	OperationGTE ResearchOperation = "GTE"
+	OperationLT  ResearchOperation = "LT"
+	OperationLTE ResearchOperation = "LTE"
+)
+
+var researchFieldOperations = map[ResearchField][]ResearchOperation{
+	FieldCreateDate:  {OperationEQ, OperationIN, OperationGT, OperationGTE, OperationLT, OperationLTE},
+	FieldUsername:    {OperationEQ, OperationIN},
+	FieldRegionCode:  {OperationEQ, OperationIN},
+	FieldVideoID:     {OperationEQ, OperationIN},
+	FieldHashtagName: {OperationEQ, OperationIN},
+	FieldKeyword:     {OperationEQ, OperationIN},
+	FieldMusicID:     {OperationEQ, OperationIN},
+	FieldEffectID:    {OperationEQ, OperationIN},
+	FieldVideoLength: {OperationEQ, OperationIN},
+}
+
+var researchVideoLengths = []string{"SHORT", "MID", "LONG", "EXTRA_LONG"}
+
+// ResearchVideoFields are the video fields requested when none are configured.
+var ResearchVideoFields = []string{
+	"id", "video_description", "create_time", "region_code", "share_count", "view_count", "like_count",
+	"comment_count", "music_id", "hashtag_names", "username", "effect_ids", "playlist_id", "voice_to_text",
+}
+
+// Condition is a single condition of a Research API video query.
+type Condition struct {
+	Operation   ResearchOperation `json:"operation"`
+	FieldName   ResearchField     `json:"field_name"`
+	FieldValues []string          `json:"field_values"`
+}
+
+// Eq returns a condition matching a field equal to the value.
+func Eq(field ResearchField, value string) Condition {
+	return Condition{Operation: OperationEQ, FieldName: field, FieldValues: []string{value}}
+}
+
+// In returns a condition matching a field equal to any of the values.
+func In(field ResearchField, values ...string) Condition {
+	return Condition{Operation: OperationIN, FieldName: field, FieldValues: values}
+}
+
+// Gt returns a condition matching a field greater than the value.
+func Gt(field ResearchField, value string) Condition {
+	return Condition{Operation: OperationGT, FieldName: field, FieldValues: []string{value}}
+}
+
+// Gte returns a condition matching a field greater than or equal to the value.
+func Gte(field ResearchField, value string) Condition {
+	return Condition{Operation: OperationGTE, FieldName: field, FieldValues: []string{value}}
+}
+
+// Lt returns a condition matching a field less than the value.
+func Lt(field ResearchField, value string) Condition {
+	return Condition{Operation: OperationLT, FieldName: field, FieldValues: []string{value}}
+}
+
+// Lte returns a condition matching a field less than or equal to the value.
+func Lte(field ResearchField, value string) Condition {
+	return Condition{Operation: OperationLTE, FieldName: field, FieldValues: []string{value}}
+}
+
+// validate checks the operation is supported by the field and the values are well formed.
+func (c Condition) validate() error {
+	operations, ok := researchFieldOperations[c.FieldName]
+	if !ok {
+		return fmt.Errorf("unsupported field %q", c.FieldName)
+	}
+
+	supported := false
+	for _, operation := range operations {
+		supported = supported || operation == c.Operation
+	}
+
+	if !supported {
+		return fmt.Errorf("operation %q is not supported by field %q", c.Operation, c.FieldName)
+	}
+
+	if len(c.FieldValues) == 0 {
+		return fmt.Errorf("field %q has no values", c.FieldName)
+	}
+
+	if c.Operation != OperationIN && len(c.FieldValues) != 1 {
+		return fmt.Errorf("operation %q of field %q expects a single value", c.Operation, c.FieldName)
+	}
+
+	for _, value := range c.FieldValues {
+		switch c.FieldName {
+		case FieldCreateDate:
+			if _, err := time.Parse(researchDateLayout, value); err != nil || len(value) != len(researchDateLayout) {
+				return fmt.Errorf("value %q of field %q is not a YYYYMMDD date", value, c.FieldName)
+			}
+		case FieldVideoLength:
+			if !containsString(researchVideoLengths, value) {
+				return fmt.Errorf("value %q of field %q must be one of %s", value, c.FieldName, strings.Join(researchVideoLengths, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+// VideoQuery is the boolean query of a Research API video search.
+type VideoQuery struct {
+	And []Condition `json:"and,omitempty"`
+	Or  []Condition `json:"or,omitempty"`
+	Not []Condition `json:"not,omitempty"`
+}
+
+// VideoQueryBuilder builds a VideoQuery.
+type VideoQueryBuilder struct {
+	query VideoQuery
+}
+
+// NewVideoQuery returns a new empty VideoQueryBuilder.
+func NewVideoQuery() *VideoQueryBuilder {
+	return &VideoQueryBuilder{}
+}
+
+// And adds conditions that must all match.
+func (b *VideoQueryBuilder) And(conditions ...Condition) *VideoQueryBuilder {
+	b.query.And = append(b.query.And, conditions...)
+	return b
+}
+
+// Or adds conditions of which at least one must match.
+func (b *VideoQueryBuilder) Or(conditions ...Condition) *VideoQueryBuilder {
+	b.query.Or = append(b.query.Or, conditions...)
+	return b
+}
+
+// Not adds conditions that must not match.
+func (b *VideoQueryBuilder) Not(conditions ...Condition) *VideoQueryBuilder {
+	b.query.Not = append(b.query.Not, conditions...)
+	return b
+}
+
+// Build validates the conditions and returns the query.
+func (b *VideoQueryBuilder) Build() (*VideoQuery, error) {
+	query := b.query
+
+	if len(query.And)+len(query.Or)+len(query.Not) == 0 {
+		return nil, fmt.Errorf("tiktok-oauth2: VideoQueryBuilder: query has no conditions")
+	}
+
+	for _, conditions := range [][]Condition{query.And, query.Or, query.Not} {
+		for _, condition := range conditions {
+			if err := condition.validate(); err != nil {
+				return nil, fmt.Errorf("tiktok-oauth2: VideoQueryBuilder: %w", err)
+			}
+		}
+	}
+
+	return &query, nil
+}
+
+// VideoQueryRequest is a Research API video search.
+type VideoQueryRequest struct {
+	Query *VideoQuery
+	// StartDate and EndDate bound the creation date of the videos, inclusive. They may be at most
+	// MaxResearchDateRange apart.
+	StartDate time.Time
+	EndDate   time.Time
+	// MaxCount is the number of videos per page, up to MaxResearchPageSize. Defaults to 20.
+	MaxCount int
+	// Cursor and SearchID continue a previous search; see VideoQueryPage.
+	Cursor   int64
+	SearchID string
+	IsRandom bool
+	// Fields are the video fields to return. Defaults to ResearchVideoFields.
+	Fields []string
+}
+
+// ResearchVideo is a video returned by the Research API.
+type ResearchVideo struct {
+	ID               int64    `json:"id"`
+	VideoDescription string   `json:"video_description"`
+	CreateTime       int64    `json:"create_time"`
+	RegionCode       string   `json:"region_code"`
+	ShareCount       int64    `json:"share_count"`
+	ViewCount        int64    `json:"view_count"`
+	LikeCount        int64    `json:"like_count"`
+	CommentCount     int64    `json:"comment_count"`
+	MusicID          int64    `json:"music_id"`
+	HashtagNames     []string `json:"hashtag_names"`
+	Username         string   `json:"username"`
+	EffectIDs        []string `json:"effect_ids"`
+	PlaylistID       int64    `json:"playlist_id"`
+	VoiceToText      string   `json:"voice_to_text"`
+	IsStemVerified   bool     `json:"is_stem_verified"`
+	FavouritesCount  int64    `json:"favourites_count"`
+	VideoDuration    int64    `json:"video_duration"`
+}
+
+// CreatedAt returns the creation time of the video.
+func (v ResearchVideo) CreatedAt() time.Time {
+	return time.Unix(v.CreateTime, 0)
+}
+
+// VideoQueryPage is a page of Research API videos. When HasMore is set, the next
+// page is requested with the same query and dates, the Cursor and the SearchID of this page.
+type VideoQueryPage struct {
+	Videos   []ResearchVideo
+	Cursor   int64
+	HasMore  bool
+	SearchID string
+}
+
+// QueryResearchVideos returns a page of the videos matching a Research API query, using a client
+// access token from ClientCredentialsToken.
+func QueryResearchVideos(ctx context.Context, token *oauth2.Token, req *VideoQueryRequest) (*VideoQueryPage, error) {
+	if req == nil || req.Query == nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchVideos: query cannot be nil")
+	}
+
+	if req.StartDate.IsZero() || req.EndDate.IsZero() {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchVideos: start and end date cannot be empty")
+	}
+
+	startDate, endDate := truncateDate(req.StartDate), truncateDate(req.EndDate)
+	if endDate.Before(startDate) {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchVideos: end date cannot be before start date")
+	}
+
+	if endDate.Sub(startDate) > MaxResearchDateRange {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchVideos: date range cannot exceed 30 days")
+	}
+
+	if req.MaxCount < 0 || req.MaxCount > MaxResearchPageSize {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchVideos: max count must be between 0 (default) and %d", MaxResearchPageSize)
+	}
+
+	fields := req.Fields
+	if len(fields) == 0 {
+		fields = ResearchVideoFields
+	}
+
+	body := researchVideoRequest{
+		Query:     req.Query,
+		StartDate: startDate.Format(researchDateLayout),
+		EndDate:   endDate.Format(researchDateLayout),
+		MaxCount:  req.MaxCount,
+		Cursor:    req.Cursor,
+		SearchID:  req.SearchID,
+		IsRandom:  req.IsRandom,
+	}
+
+	var data researchVideoData
+	if err := doAPIRequest(ctx, http.MethodPost, withFields(endpointResearchVideo, fields), token, body, &data); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchVideos: %w", err)
+	}
+
+	return &VideoQueryPage{
+		Videos:   data.Videos,
+		Cursor:   data.Cursor,
+		HasMore:  data.HasMore,
+		SearchID: data.SearchID,
+	}, nil
+}
+
+// withFields returns the endpoint with the fields query parameter the Research API requires.
+func withFields(endpoint string, fields []string) string {
+	return endpoint + "?" + url.Values{"fields": {strings.Join(fields, ",")}}.Encode()
+}
+
+// truncateDate returns the date of t at midnight UTC, as the Research API dates are in UTC.
+func truncateDate(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,278 @@
+package tiktok
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// ResearchUserVideoFields are the video fields requested for the liked, pinned and reposted videos
+// of a user.
+var ResearchUserVideoFields = []string{
+	"id", "create_time", "username", "region_code", "video_description", "music_id", "like_count",
+	"comment_count", "share_count", "view_count", "hashtag_names", "is_stem_verified", "favourites_count",
+	"video_duration",
+}
+
+// ResearchCommentFields are the comment fields requested from the Research API.
+var ResearchCommentFields = []string{
+	"id", "video_id", "text", "like_count", "reply_count", "parent_comment_id", "create_time",
+}
+
+// ResearchComment is a comment of a video returned by the Research API.
+type ResearchComment struct {
+	ID              int64  `json:"id"`
+	VideoID         int64  `json:"video_id"`
+	Text            string `json:"text"`
+	LikeCount       int64  `json:"like_count"`
+	ReplyCount      int64  `json:"reply_count"`
+	ParentCommentID int64  `json:"parent_comment_id"`
+	CreateTime      int64  `json:"create_time"`
+}
+
+// CreatedAt returns the creation time of the comment.
+func (c ResearchComment) CreatedAt() time.Time {
+	return time.Unix(c.CreateTime, 0)
+}
+
+// CommentPage is a page of the comments of a video.
+type CommentPage struct {
+	Comments []ResearchComment
+	Cursor   int64
+	HasMore  bool
+}
+
+// ResearchPlaylist is a page of the videos of a playlist returned by the Research API.
+type ResearchPlaylist struct {
+	ID         int64
+	Name       string
+	VideoIDs   []int64
+	TotalItems int64
+	Cursor     int64
+	HasMore    bool
+}
+
+// QueryResearchLikedVideos returns a page of the videos a user liked. MaxCount defaults to 20 and
+// may be up to MaxResearchPageSize.
+func QueryResearchLikedVideos(ctx context.Context, token *oauth2.Token, username string, maxCount int, cursor int64) (*VideoQueryPage, error) {
+	page, err := queryUserVideos(ctx, token, endpointResearchUserLiked, username, maxCount, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchLikedVideos: %w", err)
+	}
+
+	return page, nil
+}
+
+// QueryResearchRepostedVideos returns a page of the videos a user reposted. MaxCount defaults to 20
+// and may be up to MaxResearchPageSize.
+func QueryResearchRepostedVideos(ctx context.Context, token *oauth2.Token, username string, maxCount int, cursor int64) (*VideoQueryPage, error) {
+	page, err := queryUserVideos(ctx, token, endpointResearchUserReposted, username, maxCount, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchRepostedVideos: %w", err)
+	}
+
+	return page, nil
+}
+
+// QueryResearchPinnedVideos returns the videos a user pinned to their profile.
+func QueryResearchPinnedVideos(ctx context.Context, token *oauth2.Token, username string) ([]ResearchVideo, error) {
+	page, err := queryUserVideos(ctx, token, endpointResearchUserPinned, username, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchPinnedVideos: %w", err)
+	}
+
+	return page.Videos, nil
+}
+
+func queryUserVideos(ctx context.Context, token *oauth2.Token, endpoint, username string, maxCount int, cursor int64) (*VideoQueryPage, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+
+	if maxCount < 0 || maxCount > MaxResearchPageSize {
+		return nil, fmt.Errorf("max count must be between 0 (default) and %d", MaxResearchPageSize)
+	}
+
+	req := researchUserRequest{Username: username, MaxCount: maxCount, Cursor: cursor}
+
+	var data researchUserVideosData
+	if err := doAPIRequest(ctx, http.MethodPost, withFields(endpoint, ResearchUserVideoFields), token, req, &data); err != nil {
+		return nil, accountError(username, err)
+	}
+
+	var videos []ResearchVideo
+	switch endpoint {
+	case endpointResearchUserLiked:
+		videos = data.UserLikedVideos
+	case endpointResearchUserReposted:
+		videos = data.UserRepostedVideos
+	case endpointResearchUserPinned:
+		videos = data.PinnedVideosList
+	}
+
+	return &VideoQueryPage{Videos: videos, Cursor: data.Cursor, HasMore: data.HasMore}, nil
+}
+
+// NewLikedVideoIterator returns a new VideoIterator over the videos a user liked.
+func NewLikedVideoIterator(ctx context.Context, token *oauth2.Token, username string, maxCount int) *VideoIterator {
+	return newVideoIterator(ctx, func(ctx context.Context, cursor int64, _ string) (*VideoQueryPage, error) {
+		return queryUserVideos(ctx, token, endpointResearchUserLiked, username, maxCount, cursor)
+	})
+}
+
+// NewRepostedVideoIterator returns a new VideoIterator over the videos a user reposted.
+func NewRepostedVideoIterator(ctx context.Context, token *oauth2.Token, username string, maxCount int) *VideoIterator {
+	return newVideoIterator(ctx, func(ctx context.Context, cursor int64, _ string) (*VideoQueryPage, error) {
+		return queryUserVideos(ctx, token, endpointResearchUserReposted, username, maxCount, cursor)
+	})
+}
+
+// QueryResearchComments returns a page of the comments of a video. MaxCount defaults to 10 and may
+// be up to MaxResearchPageSize.
+func QueryResearchComments(ctx context.Context, token *oauth2.Token, videoID int64, maxCount int, cursor int64) (*CommentPage, error) {
+	page, err := queryComments(ctx, token, videoID, maxCount, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchComments: %w", err)
+	}
+
+	return page, nil
+}
+
+func queryComments(ctx context.Context, token *oauth2.Token, videoID int64, maxCount int, cursor int64) (*CommentPage, error) {
+	if videoID == 0 {
+		return nil, fmt.Errorf("video id cannot be empty")
+	}
+
+	if maxCount < 0 || maxCount > MaxResearchPageSize {
+		return nil, fmt.Errorf("max count must be between 0 (default) and %d", MaxResearchPageSize)
+	}
+
+	req := researchCommentRequest{VideoID: videoID, MaxCount: maxCount, Cursor: cursor}
+
+	var data researchCommentData
+	if err := doAPIRequest(ctx, http.MethodPost, withFields(endpointResearchComments, ResearchCommentFields), token, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &CommentPage{Comments: data.Comments, Cursor: data.Cursor, HasMore: data.HasMore}, nil
+}
+
+// CommentIterator iterates over all the comments of a video.
+type CommentIterator struct {
+	// OnPage, if set, is called with every page fetched. Returning an error stops the iteration
+	// and the error is reported by Err.
+	OnPage func(*CommentPage) error
+
+	*researchPager
+	comments []ResearchComment
+}
+
+// NewCommentIterator returns a new CommentIterator over the comments of a video.
+func NewCommentIterator(ctx context.Context, token *oauth2.Token, videoID int64, maxCount int) *CommentIterator {
+	it := &CommentIterator{}
+
+	it.researchPager = &researchPager{
+		name: "CommentIterator",
+		ctx:  ctx,
+		fetch: func(ctx context.Context, cursor int64, _ string) (researchPage, error) {
+			page, err := queryComments(ctx, token, videoID, maxCount, cursor)
+			if err != nil {
+				return researchPage{}, err
+			}
+
+			if it.OnPage != nil {
+				if err = it.OnPage(page); err != nil {
+					return researchPage{}, err
+				}
+			}
+
+			it.comments = page.Comments
+
+			return researchPage{count: len(page.Comments), cursor: page.Cursor, hasMore: page.HasMore}, nil
+		},
+	}
+
+	return it
+}
+
+// Comment returns the current comment.
+func (it *CommentIterator) Comment() ResearchComment {
+	return it.comments[it.index]
+}
+
+// QueryResearchPlaylist returns a page of the videos of a playlist, starting at the cursor of the
+// previous page.
+func QueryResearchPlaylist(ctx context.Context, token *oauth2.Token, playlistID, cursor int64) (*ResearchPlaylist, error) {
+	playlist, err := queryPlaylist(ctx, token, playlistID, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchPlaylist: %w", err)
+	}
+
+	return playlist, nil
+}
+
+func queryPlaylist(ctx context.Context, token *oauth2.Token, playlistID, cursor int64) (*ResearchPlaylist, error) {
+	if playlistID == 0 {
+		return nil, fmt.Errorf("playlist id cannot be empty")
+	}
+
+	var data researchPlaylistData
+	if err := doAPIRequest(ctx, http.MethodPost, endpointResearchPlaylist, token, researchPlaylistRequest{PlaylistID: playlistID, Cursor: cursor}, &data); err != nil {
+		return nil, err
+	}
+
+	return &ResearchPlaylist{
+		ID:         data.PlaylistID,
+		Name:       data.PlaylistName,
+		VideoIDs:   data.PlaylistVideoIDs,
+		TotalItems: data.PlaylistItemTotal,
+		Cursor:     data.Cursor,
+		HasMore:    data.HasMore,
+	}, nil
+}
+
+// PlaylistIterator iterates over the ids of all the videos of a playlist.
+type PlaylistIterator struct {
+	// OnPage, if set, is called with every page fetched. Returning an error stops the iteration
+	// and the error is reported by Err.
+	OnPage func(*ResearchPlaylist) error
+
+	*researchPager
+	videoIDs []int64
+}
+
+// NewPlaylistIterator returns a new PlaylistIterator over the videos of a playlist.
+func NewPlaylistIterator(ctx context.Context, token *oauth2.Token, playlistID int64) *PlaylistIterator {
+	it := &PlaylistIterator{}
+
+	it.researchPager = &researchPager{
+		name: "PlaylistIterator",
+		ctx:  ctx,
+		fetch: func(ctx context.Context, cursor int64, _ string) (researchPage, error) {
+			page, err := queryPlaylist(ctx, token, playlistID, cursor)
+			if err != nil {
+				return researchPage{}, err
+			}
+
+			if it.OnPage != nil {
+				if err = it.OnPage(page); err != nil {
+					return researchPage{}, err
+				}
+			}
+
+			it.videoIDs = page.VideoIDs
+
+			return researchPage{count: len(page.VideoIDs), cursor: page.Cursor, hasMore: page.HasMore}, nil
+		},
+	}
+
+	return it
+}
+
+// VideoID returns the id of the current video.
+func (it *PlaylistIterator) VideoID() int64 {
+	return it.videoIDs[it.index]
+}
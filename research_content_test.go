@@ -0,0 +1,239 @@
+package tiktok_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+const (
+	endpointResearchUserLiked    = "https://open.tiktokapis.com/v2/research/user/liked_videos/"
+	endpointResearchUserPinned   = "https://open.tiktokapis.com/v2/research/user/pinned_videos/"
+	endpointResearchUserReposted = "https://open.tiktokapis.com/v2/research/user/reposted_videos/"
+	endpointResearchComments     = "https://open.tiktokapis.com/v2/research/video/comment/list/"
+	endpointResearchPlaylist     = "https://open.tiktokapis.com/v2/research/playlist/info/"
+)
+
+// registerTestListPages serves two pages of two items each under the given list key.
+func registerTestListPages(t *testing.T, endpoint, listKey string) {
+	t.Helper()
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpoint,
+		func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				Cursor int64 `json:"cursor"`
+			}
+
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+
+			response := fmt.Sprintf(
+				`{"data":{"%s":[{"id":%d},{"id":%d}],"cursor":%d,"has_more":%t},"error":{"code":"ok"}}`,
+				listKey, body.Cursor+1, body.Cursor+2, body.Cursor+2, body.Cursor == 0,
+			)
+
+			return httpmock.NewStringResponse(http.StatusOK, response), nil
+		},
+	)
+}
+
+func TestUserVideoIteratorSuccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		listKey  string
+		iterator func(ctx context.Context, t *testing.T) *tiktok.VideoIterator
+	}{
+		{
+			name:     "liked videos",
+			endpoint: endpointResearchUserLiked,
+			listKey:  "user_liked_videos",
+			iterator: func(ctx context.Context, t *testing.T) *tiktok.VideoIterator {
+				return tiktok.NewLikedVideoIterator(ctx, testNewOauthToken(t), "test-user", 2)
+			},
+		},
+		{
+			name:     "reposted videos",
+			endpoint: endpointResearchUserReposted,
+			listKey:  "user_reposted_videos",
+			iterator: func(ctx context.Context, t *testing.T) *tiktok.VideoIterator {
+				return tiktok.NewRepostedVideoIterator(ctx, testNewOauthToken(t), "test-user", 2)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			t.Cleanup(httpmock.Deactivate)
+
+			registerTestListPages(t, tt.endpoint, tt.listKey)
+
+			it := tt.iterator(context.Background(), t)
+
+			var ids []int64
+			for it.Next() {
+				ids = append(ids, it.Video().ID)
+			}
+
+			if err := it.Err(); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if fmt.Sprint(ids) != "[1 2 3 4]" {
+				t.Fatalf("expected video ids '[1 2 3 4]', but got %v", ids)
+			}
+		})
+	}
+}
+
+func TestQueryResearchLikedVideosPrivateAccount(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(http.MethodPost, endpointResearchUserLiked, httpmock.NewStringResponder(http.StatusForbidden, responseResearchUserPrivate))
+
+	_, err := tiktok.QueryResearchLikedVideos(context.Background(), testNewOauthToken(t), "test-user", 10, 0)
+	if !errors.Is(err, tiktok.ErrAccountPrivate) {
+		t.Fatalf("expected error '%v', but got '%v'", tiktok.ErrAccountPrivate, err)
+	}
+}
+
+func TestQueryResearchPinnedVideosSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointResearchUserPinned,
+		httpmock.NewStringResponder(http.StatusOK, `{"data":{"pinned_videos_list":[{"id":1},{"id":2}]},"error":{"code":"ok"}}`),
+	)
+
+	videos, err := tiktok.QueryResearchPinnedVideos(context.Background(), testNewOauthToken(t), "test-user")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(videos) != 2 {
+		t.Fatalf("expected 2 pinned videos, but got %d", len(videos))
+	}
+}
+
+func TestCommentIteratorSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	registerTestListPages(t, endpointResearchComments, "comments")
+
+	it := tiktok.NewCommentIterator(context.Background(), testNewOauthToken(t), 7000, 2)
+
+	pages := 0
+	it.OnPage = func(*tiktok.CommentPage) error {
+		pages++
+		return nil
+	}
+
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.Comment().ID)
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fmt.Sprint(ids) != "[1 2 3 4]" || pages != 2 {
+		t.Fatalf("expected comment ids '[1 2 3 4]' in 2 pages, but got %v in %d", ids, pages)
+	}
+}
+
+func TestQueryResearchPlaylistSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointResearchPlaylist,
+		httpmock.NewStringResponder(http.StatusOK, `{"data":{"playlist_id":42,"playlist_name":"test-playlist","playlist_video_ids":[1,2,3],"playlist_item_total":3},"error":{"code":"ok"}}`),
+	)
+
+	playlist, err := tiktok.QueryResearchPlaylist(context.Background(), testNewOauthToken(t), 42, 0)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if playlist.Name != "test-playlist" || len(playlist.VideoIDs) != 3 || playlist.TotalItems != 3 {
+		t.Fatalf("expected playlist 'test-playlist' with 3 videos, but got %+v", playlist)
+	}
+}
+
+func TestPlaylistIteratorSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	var cursors []int64
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointResearchPlaylist,
+		func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				PlaylistID int64 `json:"playlist_id"`
+				Cursor     int64 `json:"cursor"`
+			}
+
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+
+			cursors = append(cursors, body.Cursor)
+
+			response := fmt.Sprintf(
+				`{"data":{"playlist_id":%d,"playlist_video_ids":[%d,%d],"playlist_item_total":4,"cursor":%d,"has_more":%t},"error":{"code":"ok"}}`,
+				body.PlaylistID, body.Cursor+1, body.Cursor+2, body.Cursor+2, body.Cursor == 0,
+			)
+
+			return httpmock.NewStringResponse(http.StatusOK, response), nil
+		},
+	)
+
+	it := tiktok.NewPlaylistIterator(context.Background(), testNewOauthToken(t), 42)
+
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.VideoID())
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fmt.Sprint(ids) != "[1 2 3 4]" {
+		t.Fatalf("expected video ids '[1 2 3 4]', but got %v", ids)
+	}
+
+	if fmt.Sprint(cursors) != "[0 2]" {
+		t.Fatalf("expected cursors '[0 2]', but got %v", cursors)
+	}
+}
+
+func TestPlaylistIteratorEmptyID(t *testing.T) {
+	it := tiktok.NewPlaylistIterator(context.Background(), testNewOauthToken(t), 0)
+	if it.Next() {
+		t.Fatal("expected no videos")
+	}
+
+	expectedError := "tiktok-oauth2: PlaylistIterator: playlist id cannot be empty"
+	if err := it.Err(); err == nil || err.Error() != expectedError {
+		t.Fatalf("expected error '%s', but got '%v'", expectedError, err)
+	}
+}
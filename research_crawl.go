@@ -0,0 +1,351 @@
+package tiktok
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DefaultDailyQuota is the number of Research API requests an app may send per day by default.
+const DefaultDailyQuota = 1000
+
+// CrawlCheckpoint is the persisted progress of a research crawl.
+type CrawlCheckpoint struct {
+	// WindowStart is the start date of the window being crawled.
+	WindowStart time.Time `json:"window_start"`
+	// Cursor and SearchID continue the search of the window.
+	Cursor   int64  `json:"cursor"`
+	SearchID string `json:"search_id"`
+	// Count is the number of videos passed to the callback so far.
+	Count int64 `json:"count"`
+	// QuotaDay is the UTC date, formatted as YYYYMMDD, QuotaUsed applies to.
+	QuotaDay  string `json:"quota_day"`
+	QuotaUsed int    `json:"quota_used"`
+	Done      bool   `json:"done"`
+}
+
+// CrawlCheckpointStore persists the checkpoints of research crawls.
+type CrawlCheckpointStore interface {
+	// Load returns the checkpoint stored under key, or nil if there is none.
+	Load(ctx context.Context, key string) (*CrawlCheckpoint, error)
+	// Save stores the checkpoint under key, replacing any previous checkpoint.
+	Save(ctx context.Context, key string, checkpoint *CrawlCheckpoint) error
+}
+
+// FileCrawlCheckpointStore is a CrawlCheckpointStore keeping every checkpoint in a JSON file inside
+// a directory.
+type FileCrawlCheckpointStore struct {
+	dir string
+}
+
+// NewFileCrawlCheckpointStore returns a new FileCrawlCheckpointStore writing to dir, creating it if
+// needed.
+func NewFileCrawlCheckpointStore(dir string) (*FileCrawlCheckpointStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: NewFileCrawlCheckpointStore: directory cannot be empty")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: NewFileCrawlCheckpointStore: %w", err)
+	}
+
+	return &FileCrawlCheckpointStore{dir: dir}, nil
+}
+
+// Load implements CrawlCheckpointStore.
+func (s *FileCrawlCheckpointStore) Load(_ context.Context, key string) (*CrawlCheckpoint, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: FileCrawlCheckpointStore: %w", err)
+	}
+
+	var checkpoint CrawlCheckpoint
+	if err = json.Unmarshal(data, &checkpoint); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: FileCrawlCheckpointStore: %w", err)
+	}
+
+	return &checkpoint, nil
+}
+
+// Save implements CrawlCheckpointStore.
+func (s *FileCrawlCheckpointStore) Save(_ context.Context, key string, checkpoint *CrawlCheckpoint) error {
+	data, err := json.Marshal(checkpoint)
+	if err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileCrawlCheckpointStore: %w", err)
+	}
+
+	if err = writeFileAtomic(s.path(key), data); err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileCrawlCheckpointStore: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileCrawlCheckpointStore) path(key string) string {
+	return filepath.Join(s.dir, unsafeFileNameChars.ReplaceAllString(key, "_")+".json")
+}
+
+// CrawlConfig configures Crawl.
+type CrawlConfig struct {
+	// Key identifies the crawl in the checkpoint store.
+	Key string
+	// Store persists the progress of the crawl.
+	Store CrawlCheckpointStore
+	// TokenSource provides client access tokens, see NewClientCredentialsTokenSource.
+	TokenSource oauth2.TokenSource
+	// Request is the query to crawl. Its date range may be of any length.
+	Request *VideoQueryRequest
+	// DailyQuota is the number of requests the crawl may send per UTC day. Defaults to
+	// DefaultDailyQuota.
+	DailyQuota int
+	// OnPause, if set, is called when the quota is exhausted, with the time the crawl resumes at.
+	OnPause func(until time.Time)
+}
+
+// Crawl runs a long research crawl, passing every video to fn. The progress is saved after every
+// page so a crawl stopped by an error or a crash resumes from the last saved page when run again
+// with the same key; the videos of a page that was not saved are passed to fn again. As with
+// RunVideoQuery, videos returned again by the next window are skipped, except for the first window
+// crawled after resuming. When the daily quota is exhausted, or TikTok reports it is, the crawl
+// pauses until the next UTC day.
+func Crawl(ctx context.Context, cfg CrawlConfig, fn func(ResearchVideo) error) error {
+	if cfg.Key == "" {
+		return fmt.Errorf("tiktok-oauth2: Crawl: key cannot be empty")
+	}
+
+	if cfg.Store == nil {
+		return fmt.Errorf("tiktok-oauth2: Crawl: checkpoint store cannot be nil")
+	}
+
+	if cfg.TokenSource == nil {
+		return fmt.Errorf("tiktok-oauth2: Crawl: token source cannot be nil")
+	}
+
+	if cfg.Request == nil || cfg.Request.Query == nil {
+		return fmt.Errorf("tiktok-oauth2: Crawl: query cannot be nil")
+	}
+
+	if fn == nil {
+		return fmt.Errorf("tiktok-oauth2: Crawl: callback cannot be nil")
+	}
+
+	if cfg.DailyQuota <= 0 {
+		cfg.DailyQuota = DefaultDailyQuota
+	}
+
+	windows := SplitDateRange(cfg.Request.StartDate, cfg.Request.EndDate, int(MaxResearchDateRange/(24*time.Hour)))
+	if len(windows) == 0 {
+		return fmt.Errorf("tiktok-oauth2: Crawl: end date cannot be before start date")
+	}
+
+	checkpoint, err := cfg.Store.Load(ctx, cfg.Key)
+	if err != nil {
+		return fmt.Errorf("tiktok-oauth2: Crawl: %w", err)
+	}
+
+	if checkpoint == nil {
+		checkpoint = &CrawlCheckpoint{WindowStart: windows[0].Start}
+	}
+
+	c := &crawler{cfg: cfg, checkpoint: checkpoint}
+
+	for _, window := range windows {
+		if checkpoint.Done {
+			break
+		}
+
+		if window.End.Before(checkpoint.WindowStart) {
+			continue
+		}
+
+		if err = c.crawlWindow(ctx, window, fn); err != nil {
+			return fmt.Errorf("tiktok-oauth2: Crawl: %w", err)
+		}
+	}
+
+	checkpoint.Done = true
+	if err = cfg.Store.Save(ctx, cfg.Key, checkpoint); err != nil {
+		return fmt.Errorf("tiktok-oauth2: Crawl: %w", err)
+	}
+
+	return nil
+}
+
+type crawler struct {
+	cfg        CrawlConfig
+	checkpoint *CrawlCheckpoint
+	// previous holds the ids of the videos of the previous window, as adjacent windows may return
+	// the same videos.
+	previous map[int64]bool
+}
+
+func (c *crawler) crawlWindow(ctx context.Context, window DateWindow, fn func(ResearchVideo) error) error {
+	if !c.checkpoint.WindowStart.Equal(window.Start) {
+		c.checkpoint.WindowStart, c.checkpoint.Cursor, c.checkpoint.SearchID = window.Start, 0, ""
+	}
+
+	req := *c.cfg.Request
+	req.StartDate, req.EndDate = window.Start, window.End
+
+	var videos []ResearchVideo
+
+	pager := &researchPager{
+		ctx:      ctx,
+		cursor:   c.checkpoint.Cursor,
+		searchID: c.checkpoint.SearchID,
+		fetch: func(ctx context.Context, cursor int64, searchID string) (researchPage, error) {
+			req.Cursor, req.SearchID = cursor, searchID
+
+			page, err := c.queryPage(ctx, &req)
+			if err != nil {
+				return researchPage{}, err
+			}
+
+			videos = page.Videos
+
+			return researchPage{count: len(page.Videos), cursor: page.Cursor, hasMore: page.HasMore, searchID: page.SearchID}, nil
+		},
+	}
+
+	current := make(map[int64]bool)
+
+	for pager.next() {
+		for _, video := range videos {
+			if c.previous[video.ID] || current[video.ID] {
+				continue
+			}
+
+			current[video.ID] = true
+
+			if err := fn(video); err != nil {
+				return err
+			}
+
+			c.checkpoint.Count++
+		}
+
+		if err := c.save(ctx, window, pager); err != nil {
+			return err
+		}
+	}
+
+	if pager.err != nil {
+		return pager.err
+	}
+
+	c.previous = current
+
+	// The last page was empty, so the window is yet to be moved past.
+	if c.checkpoint.WindowStart.Equal(window.Start) {
+		return c.save(ctx, window, pager)
+	}
+
+	return nil
+}
+
+// save saves the cursor and search id of the pager, moving the checkpoint past the window once
+// the pager is done so the window is not crawled again after a crash.
+func (c *crawler) save(ctx context.Context, window DateWindow, pager *researchPager) error {
+	c.checkpoint.Cursor, c.checkpoint.SearchID = pager.cursor, pager.searchID
+	if pager.done {
+		c.checkpoint.WindowStart, c.checkpoint.Cursor, c.checkpoint.SearchID = window.End.AddDate(0, 0, 1), 0, ""
+	}
+
+	return c.cfg.Store.Save(ctx, c.cfg.Key, c.checkpoint)
+}
+
+// queryPage requests the page at the cursor of the request, waiting for the quota to reset if
+// needed.
+func (c *crawler) queryPage(ctx context.Context, req *VideoQueryRequest) (*VideoQueryPage, error) {
+	for {
+		if err := c.acquireQuota(ctx); err != nil {
+			return nil, err
+		}
+
+		token, err := c.cfg.TokenSource.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		page, err := QueryResearchVideos(ctx, token, req)
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusTooManyRequests || apiErr.Code == "rate_limit_exceeded") {
+			// TikTok counts requests differently than expected, trust it and wait for the reset.
+			c.checkpoint.QuotaUsed = c.cfg.DailyQuota
+			continue
+		}
+
+		return page, err
+	}
+}
+
+// acquireQuota counts a request against the daily quota and saves it before the request is sent,
+// so requests are never undercounted after a crash.
+func (c *crawler) acquireQuota(ctx context.Context) error {
+	for {
+		now := time.Now().UTC()
+		today := now.Format(researchDateLayout)
+
+		if c.checkpoint.QuotaDay != today {
+			c.checkpoint.QuotaDay, c.checkpoint.QuotaUsed = today, 0
+		}
+
+		if c.checkpoint.QuotaUsed < c.cfg.DailyQuota {
+			c.checkpoint.QuotaUsed++
+			return c.cfg.Store.Save(ctx, c.cfg.Key, c.checkpoint)
+		}
+
+		if err := c.cfg.Store.Save(ctx, c.cfg.Key, c.checkpoint); err != nil {
+			return err
+		}
+
+		resetAt := truncateDate(now).AddDate(0, 0, 1)
+		if c.cfg.OnPause != nil {
+			c.cfg.OnPause(resetAt)
+		}
+
+		timer := time.NewTimer(time.Until(resetAt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// clientCredentialsTokenSource is an oauth2.TokenSource requesting client access tokens.
+type clientCredentialsTokenSource struct {
+	mu           sync.Mutex
+	ctx          context.Context
+	clientID     string
+	clientSecret string
+}
+
+// NewClientCredentialsTokenSource returns an oauth2.TokenSource returning a client access token
+// and requesting a new one when it expires.
+func NewClientCredentialsTokenSource(ctx context.Context, clientID, clientSecret string) oauth2.TokenSource {
+	return oauth2.ReuseTokenSource(nil, &clientCredentialsTokenSource{ctx: ctx, clientID: clientID, clientSecret: clientSecret})
+}
+
+// Token implements oauth2.TokenSource.
+func (s *clientCredentialsTokenSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return ClientCredentialsToken(s.ctx, s.clientID, s.clientSecret)
+}
@@ -0,0 +1,132 @@
+package tiktok_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"golang.org/x/oauth2"
+)
+
+func newTestCrawlConfig(t *testing.T) tiktok.CrawlConfig {
+	t.Helper()
+
+	store, err := tiktok.NewFileCrawlCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tiktok.CrawlConfig{
+		Key:         "test-crawl",
+		Store:       store,
+		TokenSource: oauth2.StaticTokenSource(testNewOauthToken(t)),
+		Request:     testVideoQueryRequest(t),
+	}
+}
+
+func TestCrawlSuccess(t *testing.T) {
+	received := registerTestResearchPages(t, 2)
+
+	cfg := newTestCrawlConfig(t)
+	cfg.Request.StartDate, cfg.Request.EndDate = testDate(t, "2022-01-01"), testDate(t, "2022-02-09")
+
+	count := 0
+	err := tiktok.Crawl(context.Background(), cfg, func(tiktok.ResearchVideo) error {
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// Two windows of two pages of the same two videos each, which are passed once.
+	if count != 4 || len(*received) != 4 {
+		t.Fatalf("expected 4 videos in 4 requests, but got %d in %d", count, len(*received))
+	}
+
+	checkpoint, err := cfg.Store.Load(context.Background(), cfg.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !checkpoint.Done || checkpoint.Count != 4 || checkpoint.QuotaUsed != 4 {
+		t.Fatalf("expected done checkpoint with 4 videos and 4 requests, but got %+v", checkpoint)
+	}
+}
+
+func TestCrawlResume(t *testing.T) {
+	received := registerTestResearchPages(t, 3)
+
+	cfg := newTestCrawlConfig(t)
+	errCrash := errors.New("crash")
+
+	err := tiktok.Crawl(context.Background(), cfg, func(video tiktok.ResearchVideo) error {
+		if video.ID == 2 {
+			return errCrash
+		}
+
+		return nil
+	})
+	if !errors.Is(err, errCrash) {
+		t.Fatalf("expected error '%v', but got '%v'", errCrash, err)
+	}
+
+	var ids []int64
+	err = tiktok.Crawl(context.Background(), cfg, func(video tiktok.ResearchVideo) error {
+		ids = append(ids, video.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// The second page was not saved before the crash, so it is crawled again.
+	if fmt.Sprint(ids) != "[2 3 4 5]" {
+		t.Fatalf("expected resumed video ids '[2 3 4 5]', but got %v", ids)
+	}
+
+	expected := "[0: 2:test-search-id 2:test-search-id 4:test-search-id]"
+	if fmt.Sprint(*received) != expected {
+		t.Fatalf("expected requests '%s', but got %v", expected, *received)
+	}
+}
+
+func TestCrawlQuotaExhausted(t *testing.T) {
+	registerTestResearchPages(t, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	cfg := newTestCrawlConfig(t)
+	cfg.DailyQuota = 1
+
+	var pausedUntil time.Time
+	cfg.OnPause = func(until time.Time) {
+		pausedUntil = until
+		cancel()
+	}
+
+	err := tiktok.Crawl(ctx, cfg, func(tiktok.ResearchVideo) error {
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected error '%v', but got '%v'", context.Canceled, err)
+	}
+
+	expectedReset := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	if !pausedUntil.Equal(expectedReset) {
+		t.Fatalf("expected pause until '%v', but got %v", expectedReset, pausedUntil)
+	}
+
+	checkpoint, err := cfg.Store.Load(context.Background(), cfg.Key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if checkpoint.QuotaUsed != 1 || checkpoint.Cursor != 2 || checkpoint.SearchID != "test-search-id" {
+		t.Fatalf("expected checkpoint after one request at cursor 2, but got %+v", checkpoint)
+	}
+}
@@ -0,0 +1,185 @@
+package tiktok
+
+import (
+	"context"
+	"fmt"
+
+	"golang.org/x/oauth2"
+)
+
+// researchPage is the pagination state returned by a Research API list endpoint.
+type researchPage struct {
+	count    int
+	cursor   int64
+	hasMore  bool
+	searchID string
+}
+
+// researchPager drives the cursor based pagination shared by the Research API list endpoints,
+// and provides the Next, Cursor and Err methods of the iterators embedding it. The fetch function
+// requests the page at the cursor and stores its items for the iterator, which reads the current
+// item at index.
+type researchPager struct {
+	// name is the name of the iterator, reported in its errors.
+	name string
+	ctx  context.Context
+	// searchIDCursor is set for endpoints where every page returns a new search id to request the
+	// next page with, instead of a cursor.
+	searchIDCursor bool
+	fetch          func(ctx context.Context, cursor int64, searchID string) (researchPage, error)
+	cursor         int64
+	searchID       string
+	started        bool
+	done           bool
+	err            error
+	// index is the index of the current item in the page of count items last fetched.
+	index int
+	count int
+}
+
+// Next advances to the next item and reports whether there is one. It returns false when all
+// items have been read, the context is done or a request failed; see Err.
+func (p *researchPager) Next() bool {
+	if p.index+1 < p.count {
+		p.index++
+		return true
+	}
+
+	if !p.next() {
+		return false
+	}
+
+	p.index = 0
+
+	return true
+}
+
+// Cursor returns the cursor of the next page to fetch. It is zero for the lists paged by search id
+// instead, such as ads and advertisers.
+func (p *researchPager) Cursor() int64 {
+	return p.cursor
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (p *researchPager) Err() error {
+	if p.err != nil {
+		return fmt.Errorf("tiktok-oauth2: %s: %w", p.name, p.err)
+	}
+
+	return nil
+}
+
+// next fetches the next non empty page and reports whether there is one.
+func (p *researchPager) next() bool {
+	for !p.done && p.err == nil {
+		if err := p.ctx.Err(); err != nil {
+			p.err = err
+			return false
+		}
+
+		page, err := p.fetch(p.ctx, p.cursor, p.searchID)
+		if err != nil {
+			p.err = err
+			return false
+		}
+
+		if p.searchIDCursor {
+			if page.hasMore && (page.searchID == "" || page.searchID == p.searchID) {
+				p.err = fmt.Errorf("search id did not change from %q", p.searchID)
+				return false
+			}
+		} else {
+			if p.started && p.searchID != "" && page.searchID != "" && page.searchID != p.searchID {
+				p.err = fmt.Errorf("search id changed from %s to %s", p.searchID, page.searchID)
+				return false
+			}
+
+			if page.hasMore && p.started && page.cursor == p.cursor {
+				p.err = fmt.Errorf("cursor did not change from %d", p.cursor)
+				return false
+			}
+		}
+
+		p.started = true
+		p.done = !page.hasMore
+		p.count = page.count
+		p.cursor = page.cursor
+		if page.searchID != "" {
+			p.searchID = page.searchID
+		}
+
+		if page.count > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// VideoIterator iterates over all the videos of a Research API list, such as the results of a
+// query or the videos liked by a user, fetching the pages as needed with the cursor and search id
+// of the previous page.
+type VideoIterator struct {
+	// OnPage, if set, is called with every page fetched. Returning an error stops the iteration
+	// and the error is reported by Err.
+	OnPage func(*VideoQueryPage) error
+
+	*researchPager
+	videos []ResearchVideo
+}
+
+// NewVideoIterator returns a new VideoIterator for the query, starting at the cursor and search id
+// of the request.
+func NewVideoIterator(ctx context.Context, token *oauth2.Token, req *VideoQueryRequest) *VideoIterator {
+	var pageReq VideoQueryRequest
+	if req != nil {
+		pageReq = *req
+	}
+
+	it := newVideoIterator(ctx, func(ctx context.Context, cursor int64, searchID string) (*VideoQueryPage, error) {
+		pageReq.Cursor, pageReq.SearchID = cursor, searchID
+		return QueryResearchVideos(ctx, token, &pageReq)
+	})
+
+	it.cursor, it.searchID = pageReq.Cursor, pageReq.SearchID
+
+	return it
+}
+
+// newVideoIterator returns a new VideoIterator over the pages returned by fetch.
+func newVideoIterator(ctx context.Context, fetch func(ctx context.Context, cursor int64, searchID string) (*VideoQueryPage, error)) *VideoIterator {
+	it := &VideoIterator{}
+
+	it.researchPager = &researchPager{
+		name: "VideoIterator",
+		ctx:  ctx,
+		fetch: func(ctx context.Context, cursor int64, searchID string) (researchPage, error) {
+			page, err := fetch(ctx, cursor, searchID)
+			if err != nil {
+				return researchPage{}, err
+			}
+
+			if it.OnPage != nil {
+				if err = it.OnPage(page); err != nil {
+					return researchPage{}, err
+				}
+			}
+
+			it.videos = page.Videos
+
+			return researchPage{count: len(page.Videos), cursor: page.Cursor, hasMore: page.HasMore, searchID: page.SearchID}, nil
+		},
+	}
+
+	return it
+}
+
+// Video returns the current video.
+func (it *VideoIterator) Video() ResearchVideo {
+	return it.videos[it.index]
+}
+
+// SearchID returns the search id of the query.
+func (it *VideoIterator) SearchID() string {
+	return it.searchID
+}
@@ -0,0 +1,165 @@
+package tiktok_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+// registerTestResearchPages serves pages of two videos each, up to the given number of pages, and
+// fails if a request after the first one does not echo the search id.
+func registerTestResearchPages(t *testing.T, pages int, searchIDs ...string) *[]string {
+	t.Helper()
+
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	var received []string
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointResearchVideo,
+		func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				Cursor   int64  `json:"cursor"`
+				SearchID string `json:"search_id"`
+			}
+
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+
+			received = append(received, fmt.Sprintf("%d:%s", body.Cursor, body.SearchID))
+
+			page := int(body.Cursor / 2)
+			searchID := "test-search-id"
+			if page < len(searchIDs) {
+				searchID = searchIDs[page]
+			}
+
+			response := fmt.Sprintf(
+				`{"data":{"videos":[{"id":%d},{"id":%d}],"cursor":%d,"has_more":%t,"search_id":"%s"},"error":{"code":"ok"}}`,
+				body.Cursor, body.Cursor+1, body.Cursor+2, page+1 < pages, searchID,
+			)
+
+			return httpmock.NewStringResponse(http.StatusOK, response), nil
+		},
+	)
+
+	return &received
+}
+
+func testVideoQueryRequest(t *testing.T) *tiktok.VideoQueryRequest {
+	t.Helper()
+
+	query, err := tiktok.NewVideoQuery().And(tiktok.Eq(tiktok.FieldKeyword, "cat")).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tiktok.VideoQueryRequest{Query: query, StartDate: testDate(t, "2022-06-01"), EndDate: testDate(t, "2022-06-10")}
+}
+
+func TestVideoIteratorSuccess(t *testing.T) {
+	received := registerTestResearchPages(t, 3)
+
+	it := tiktok.NewVideoIterator(context.Background(), testNewOauthToken(t), testVideoQueryRequest(t))
+
+	pages := 0
+	it.OnPage = func(page *tiktok.VideoQueryPage) error {
+		pages++
+		return nil
+	}
+
+	var ids []int64
+	for it.Next() {
+		ids = append(ids, it.Video().ID)
+	}
+
+	if err := it.Err(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if fmt.Sprint(ids) != "[0 1 2 3 4 5]" {
+		t.Fatalf("expected video ids '[0 1 2 3 4 5]', but got %v", ids)
+	}
+
+	if pages != 3 {
+		t.Fatalf("expected 3 pages, but got %d", pages)
+	}
+
+	expected := "[0: 2:test-search-id 4:test-search-id]"
+	if fmt.Sprint(*received) != expected {
+		t.Fatalf("expected requests '%s', but got %v", expected, *received)
+	}
+}
+
+func TestVideoIteratorSearchIDChanged(t *testing.T) {
+	registerTestResearchPages(t, 3, "test-search-id", "test-other-search-id")
+
+	it := tiktok.NewVideoIterator(context.Background(), testNewOauthToken(t), testVideoQueryRequest(t))
+	for it.Next() {
+	}
+
+	if it.Err() == nil {
+		t.Fatal("expected error but got nil")
+	}
+}
+
+func TestVideoIteratorOnPageError(t *testing.T) {
+	registerTestResearchPages(t, 3)
+
+	errStop := errors.New("stop")
+
+	it := tiktok.NewVideoIterator(context.Background(), testNewOauthToken(t), testVideoQueryRequest(t))
+	it.OnPage = func(page *tiktok.VideoQueryPage) error {
+		if page.Cursor > 2 {
+			return errStop
+		}
+
+		return nil
+	}
+
+	count := 0
+	for it.Next() {
+		count++
+	}
+
+	if !errors.Is(it.Err(), errStop) {
+		t.Fatalf("expected error '%v', but got '%v'", errStop, it.Err())
+	}
+
+	if count != 2 {
+		t.Fatalf("expected 2 videos before stopping, but got %d", count)
+	}
+}
+
+func TestVideoIteratorContextCancelled(t *testing.T) {
+	registerTestResearchPages(t, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	it := tiktok.NewVideoIterator(ctx, testNewOauthToken(t), testVideoQueryRequest(t))
+
+	count := 0
+	for it.Next() {
+		count++
+		if count == 2 {
+			cancel()
+		}
+	}
+
+	if !errors.Is(it.Err(), context.Canceled) {
+		t.Fatalf("expected error '%v', but got '%v'", context.Canceled, it.Err())
+	}
+
+	if it.Cursor() != 2 || it.SearchID() != "test-search-id" {
+		t.Fatalf("expected to stop at cursor 2 of search 'test-search-id', but got %d %s", it.Cursor(), it.SearchID())
+	}
+}
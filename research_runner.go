@@ -0,0 +1,170 @@
+package tiktok
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DateWindow is a range of dates, both inclusive.
+type DateWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// SplitDateRange splits the dates from start to end, both inclusive, into consecutive windows of at
+// most the given number of days.
+func SplitDateRange(start, end time.Time, days int) []DateWindow {
+	start, end = truncateDate(start), truncateDate(end)
+	if days <= 0 || end.Before(start) {
+		return nil
+	}
+
+	var windows []DateWindow
+	for windowStart := start; !windowStart.After(end); windowStart = windowStart.AddDate(0, 0, days) {
+		windowEnd := windowStart.AddDate(0, 0, days-1)
+		if windowEnd.After(end) {
+			windowEnd = end
+		}
+
+		windows = append(windows, DateWindow{Start: windowStart, End: windowEnd})
+	}
+
+	return windows
+}
+
+// RunOptions configures RunVideoQuery.
+type RunOptions struct {
+	// WindowDays is the number of days queried by every request. Defaults to 30, the maximum the
+	// Research API accepts.
+	WindowDays int
+	// Concurrency is the number of windows queried at the same time. Defaults to 1.
+	Concurrency int
+	// OnWindow, if set, is called once all the videos of a window have been passed to the callback.
+	OnWindow func(window DateWindow, videos int)
+}
+
+type windowResult struct {
+	videos []ResearchVideo
+	err    error
+}
+
+// RunVideoQuery runs a Research API query over a date range of any length by splitting it into
+// windows the API accepts, and calls fn with every video in creation time order. Videos returned by
+// two adjacent windows are passed only once. The videos of a window are buffered until the window
+// is complete, so at most Concurrency windows are held in memory.
+func RunVideoQuery(ctx context.Context, token *oauth2.Token, req *VideoQueryRequest, opts *RunOptions, fn func(ResearchVideo) error) error {
+	if req == nil || req.Query == nil {
+		return fmt.Errorf("tiktok-oauth2: RunVideoQuery: query cannot be nil")
+	}
+
+	if fn == nil {
+		return fmt.Errorf("tiktok-oauth2: RunVideoQuery: callback cannot be nil")
+	}
+
+	if opts == nil {
+		opts = &RunOptions{}
+	}
+
+	days := opts.WindowDays
+	if days <= 0 || days > int(MaxResearchDateRange/(24*time.Hour)) {
+		days = int(MaxResearchDateRange / (24 * time.Hour))
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	windows := SplitDateRange(req.StartDate, req.EndDate, days)
+	if len(windows) == 0 {
+		return fmt.Errorf("tiktok-oauth2: RunVideoQuery: end date cannot be before start date")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]chan windowResult, len(windows))
+	for i := range results {
+		results[i] = make(chan windowResult, 1)
+	}
+
+	// Every started window holds a slot until its videos are consumed below, bounding both the
+	// concurrent requests and the buffered windows.
+	slots := make(chan struct{}, concurrency)
+	go func() {
+		for i, window := range windows {
+			select {
+			case <-ctx.Done():
+				results[i] <- windowResult{err: ctx.Err()}
+				continue
+			case slots <- struct{}{}:
+			}
+
+			go func(i int, window DateWindow) {
+				videos, err := queryWindow(ctx, token, req, window)
+				results[i] <- windowResult{videos: videos, err: err}
+			}(i, window)
+		}
+	}()
+
+	previous := make(map[int64]bool)
+	for i, window := range windows {
+		result := <-results[i]
+		if result.err != nil {
+			return fmt.Errorf("tiktok-oauth2: RunVideoQuery: window %s-%s: %w", window.Start.Format(researchDateLayout), window.End.Format(researchDateLayout), result.err)
+		}
+
+		current := make(map[int64]bool, len(result.videos))
+		count := 0
+
+		for _, video := range result.videos {
+			if previous[video.ID] || current[video.ID] {
+				continue
+			}
+
+			current[video.ID] = true
+			count++
+
+			if err := fn(video); err != nil {
+				return err
+			}
+		}
+
+		previous = current
+		<-slots
+
+		if opts.OnWindow != nil {
+			opts.OnWindow(window, count)
+		}
+	}
+
+	return nil
+}
+
+// queryWindow returns all the videos of the query created in the window, sorted by creation time.
+func queryWindow(ctx context.Context, token *oauth2.Token, req *VideoQueryRequest, window DateWindow) ([]ResearchVideo, error) {
+	windowReq := *req
+	windowReq.StartDate, windowReq.EndDate = window.Start, window.End
+	windowReq.Cursor, windowReq.SearchID = 0, ""
+
+	var videos []ResearchVideo
+
+	it := NewVideoIterator(ctx, token, &windowReq)
+	for it.Next() {
+		videos = append(videos, it.Video())
+	}
+
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(videos, func(i, j int) bool {
+		return videos[i].CreateTime < videos[j].CreateTime
+	})
+
+	return videos, nil
+}
@@ -0,0 +1,119 @@
+package tiktok_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+func TestSplitDateRange(t *testing.T) {
+	windows := tiktok.SplitDateRange(testDate(t, "2022-01-01"), testDate(t, "2022-03-05"), 30)
+
+	expected := "[20220101-20220130 20220131-20220301 20220302-20220305]"
+
+	got := make([]string, 0, len(windows))
+	for _, window := range windows {
+		got = append(got, window.Start.Format("20060102")+"-"+window.End.Format("20060102"))
+	}
+
+	if fmt.Sprint(got) != expected {
+		t.Fatalf("expected windows '%s', but got %v", expected, got)
+	}
+}
+
+func TestRunVideoQuerySuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	// Every window returns two videos created on its start date, out of order, and the first video
+	// of the next window, as TikTok may do around window boundaries.
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointResearchVideo,
+		func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				StartDate string `json:"start_date"`
+			}
+
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+
+			start, err := time.Parse("20060102", body.StartDate)
+			if err != nil {
+				return nil, err
+			}
+
+			// Later windows respond faster, so concurrent windows complete out of order.
+			time.Sleep(time.Duration(testDate(t, "2022-04-01").Sub(start).Hours()/24) * time.Millisecond / 10)
+
+			id := start.Unix()
+			next := start.AddDate(0, 0, 10).Unix()
+			response := fmt.Sprintf(
+				`{"data":{"videos":[{"id":%d,"create_time":%d},{"id":%d,"create_time":%d},{"id":%d,"create_time":%d}],"cursor":3,"has_more":false,"search_id":"test-search-id"},"error":{"code":"ok"}}`,
+				id+1, id+1, id, id, next, next,
+			)
+
+			return httpmock.NewStringResponse(http.StatusOK, response), nil
+		},
+	)
+
+	req := testVideoQueryRequest(t)
+	req.StartDate, req.EndDate = testDate(t, "2022-01-01"), testDate(t, "2022-01-30")
+
+	var windows []int
+	opts := &tiktok.RunOptions{
+		WindowDays:  10,
+		Concurrency: 3,
+		OnWindow: func(window tiktok.DateWindow, videos int) {
+			windows = append(windows, videos)
+		},
+	}
+
+	var createTimes []int64
+	err := tiktok.RunVideoQuery(context.Background(), testNewOauthToken(t), req, opts, func(video tiktok.ResearchVideo) error {
+		createTimes = append(createTimes, video.CreateTime)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	for i := 1; i < len(createTimes); i++ {
+		if createTimes[i] <= createTimes[i-1] {
+			t.Fatalf("expected videos in strictly increasing creation time, but got %v", createTimes)
+		}
+	}
+
+	// The first window has 3 videos, the next ones 2 as their first video was already returned.
+	if fmt.Sprint(windows) != "[3 2 2]" {
+		t.Fatalf("expected videos per window '[3 2 2]', but got %v", windows)
+	}
+}
+
+func TestRunVideoQueryError(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		endpointResearchVideo,
+		httpmock.NewStringResponder(http.StatusUnauthorized, responseV2Error),
+	)
+
+	req := testVideoQueryRequest(t)
+	req.StartDate, req.EndDate = testDate(t, "2022-01-01"), testDate(t, "2022-12-31")
+
+	err := tiktok.RunVideoQuery(context.Background(), testNewOauthToken(t), req, &tiktok.RunOptions{Concurrency: 4}, func(tiktok.ResearchVideo) error {
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+}
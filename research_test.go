@@ -0,0 +1,209 @@
+package tiktok_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+const endpointResearchVideo = "https://open.tiktokapis.com/v2/research/video/query/"
+
+func testDate(t *testing.T, value string) time.Time {
+	t.Helper()
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return date
+}
+
+func TestVideoQueryBuilderSuccess(t *testing.T) {
+	query, err := tiktok.NewVideoQuery().
+		And(tiktok.In(tiktok.FieldRegionCode, "JP", "US"), tiktok.Eq(tiktok.FieldHashtagName, "animal")).
+		Or(tiktok.Gte(tiktok.FieldCreateDate, "20220615")).
+		Not(tiktok.Eq(tiktok.FieldVideoLength, "SHORT")).
+		Build()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	got, err := json.Marshal(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"and":[{"operation":"IN","field_name":"region_code","field_values":["JP","US"]},{"operation":"EQ","field_name":"hashtag_name","field_values":["animal"]}],"or":[{"operation":"GTE","field_name":"create_date","field_values":["20220615"]}],"not":[{"operation":"EQ","field_name":"video_length","field_values":["SHORT"]}]}`
+	if string(got) != expected {
+		t.Fatalf("expected query '%s', but got '%s'", expected, got)
+	}
+}
+
+func TestVideoQueryBuilderError(t *testing.T) {
+	tests := []struct {
+		name          string
+		builder       *tiktok.VideoQueryBuilder
+		errorContains string
+	}{
+		{
+			name:          "no conditions",
+			builder:       tiktok.NewVideoQuery(),
+			errorContains: "VideoQueryBuilder: query has no conditions",
+		},
+		{
+			name:          "unsupported operation",
+			builder:       tiktok.NewVideoQuery().And(tiktok.Gt(tiktok.FieldRegionCode, "US")),
+			errorContains: `VideoQueryBuilder: operation "GT" is not supported by field "region_code"`,
+		},
+		{
+			name:          "invalid date",
+			builder:       tiktok.NewVideoQuery().And(tiktok.Eq(tiktok.FieldCreateDate, "2022-06-15")),
+			errorContains: `VideoQueryBuilder: value "2022-06-15" of field "create_date" is not a YYYYMMDD date`,
+		},
+		{
+			name:          "invalid video length",
+			builder:       tiktok.NewVideoQuery().And(tiktok.In(tiktok.FieldVideoLength, "TINY")),
+			errorContains: `VideoQueryBuilder: value "TINY" of field "video_length" must be one of`,
+		},
+		{
+			name:          "unknown field",
+			builder:       tiktok.NewVideoQuery().And(tiktok.Eq("color", "red")),
+			errorContains: `VideoQueryBuilder: unsupported field "color"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.errorContains) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestQueryResearchVideosInvalidArguments(t *testing.T) {
+	query, err := tiktok.NewVideoQuery().And(tiktok.Eq(tiktok.FieldKeyword, "cat")).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		req           *tiktok.VideoQueryRequest
+		errorContains string
+	}{
+		{
+			name:          "nil request",
+			req:           nil,
+			errorContains: "QueryResearchVideos: query cannot be nil",
+		},
+		{
+			name:          "empty dates",
+			req:           &tiktok.VideoQueryRequest{Query: query},
+			errorContains: "QueryResearchVideos: start and end date cannot be empty",
+		},
+		{
+			name:          "end before start",
+			req:           &tiktok.VideoQueryRequest{Query: query, StartDate: testDate(t, "2022-06-15"), EndDate: testDate(t, "2022-06-14")},
+			errorContains: "QueryResearchVideos: end date cannot be before start date",
+		},
+		{
+			name:          "range too long",
+			req:           &tiktok.VideoQueryRequest{Query: query, StartDate: testDate(t, "2022-06-01"), EndDate: testDate(t, "2022-07-02")},
+			errorContains: "QueryResearchVideos: date range cannot exceed 30 days",
+		},
+		{
+			name:          "max count too large",
+			req:           &tiktok.VideoQueryRequest{Query: query, StartDate: testDate(t, "2022-06-01"), EndDate: testDate(t, "2022-06-02"), MaxCount: 101},
+			errorContains: "QueryResearchVideos: max count must be between 0 (default) and 100",
+		},
+		{
+			name:          "negative max count",
+			req:           &tiktok.VideoQueryRequest{Query: query, StartDate: testDate(t, "2022-06-01"), EndDate: testDate(t, "2022-06-02"), MaxCount: -1},
+			errorContains: "QueryResearchVideos: max count must be between 0 (default) and 100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tiktok.QueryResearchVideos(context.Background(), testNewOauthToken(t), tt.req)
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.errorContains) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestQueryResearchVideosSuccess(t *testing.T) {
+	query, err := tiktok.NewVideoQuery().And(tiktok.Eq(tiktok.FieldKeyword, "cat")).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	var gotBody string
+	httpmock.RegisterResponderWithQuery(
+		http.MethodPost,
+		endpointResearchVideo,
+		map[string]string{"fields": "id,username,hashtag_names"},
+		func(req *http.Request) (*http.Response, error) {
+			body, err := ioutil.ReadAll(req.Body)
+			if err != nil {
+				return nil, err
+			}
+
+			gotBody = string(body)
+
+			return httpmock.NewStringResponse(http.StatusOK, responseResearchVideos), nil
+		},
+	)
+
+	req := &tiktok.VideoQueryRequest{
+		Query:     query,
+		StartDate: testDate(t, "2022-06-15"),
+		EndDate:   testDate(t, "2022-06-28"),
+		MaxCount:  2,
+		Fields:    []string{"id", "username", "hashtag_names"},
+	}
+
+	page, err := tiktok.QueryResearchVideos(context.Background(), testNewOauthToken(t), req)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	expectedBody := `{"query":{"and":[{"operation":"EQ","field_name":"keyword","field_values":["cat"]}]},"start_date":"20220615","end_date":"20220628","max_count":2}`
+	if gotBody != expectedBody {
+		t.Fatalf("expected request body '%s', but got '%s'", expectedBody, gotBody)
+	}
+
+	if len(page.Videos) != 2 {
+		t.Fatalf("expected 2 videos, but got %d", len(page.Videos))
+	}
+
+	if page.Videos[0].ID != 702874395068494830 || page.Videos[0].Username != "test-user-1" {
+		t.Fatalf("expected first video '702874395068494830' of 'test-user-1', but got %d of %s", page.Videos[0].ID, page.Videos[0].Username)
+	}
+
+	if !page.HasMore || page.Cursor != 2 || page.SearchID != "test-search-id" {
+		t.Fatalf("expected more results at cursor 2 of search 'test-search-id', but got %v %d %s", page.HasMore, page.Cursor, page.SearchID)
+	}
+}
@@ -0,0 +1,213 @@
+package tiktok
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// Errors reported by AccountError.
+var (
+	ErrAccountPrivate     = errors.New("account is private")
+	ErrAccountUnavailable = errors.New("account is unavailable")
+)
+
+// AccountError is returned by the Research API user calls when the account cannot be read. Use
+// errors.Is with ErrAccountPrivate or ErrAccountUnavailable to tell the reason.
+type AccountError struct {
+	Username string
+	Reason   error
+	APIError *APIError
+}
+
+// Error implements the error interface.
+func (e *AccountError) Error() string {
+	return fmt.Sprintf("%s %s: %v", e.Username, e.Reason, e.APIError)
+}
+
+// Is reports whether the target is the reason of the error.
+func (e *AccountError) Is(target error) bool {
+	return target == e.Reason
+}
+
+// Unwrap returns the underlying *APIError.
+func (e *AccountError) Unwrap() error {
+	return e.APIError
+}
+
+// accountError converts the errors TikTok returns for private, deleted or banned accounts into an
+// *AccountError, and returns any other error unchanged.
+//
+// TikTok has no error code for these accounts: it returns them as invalid_params, like a malformed
+// request, and only the message tells them apart. They are therefore classified by the words of
+// the message, but only for invalid_params client errors, so that the message of other errors,
+// such as authorization, rate limit or server errors, is never looked at.
+func accountError(username string, err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "invalid_params" || apiErr.StatusCode >= http.StatusInternalServerError {
+		return err
+	}
+
+	// The wording of the messages is not documented; these words cover the messages seen so far.
+	message := strings.ToLower(apiErr.Message)
+
+	switch {
+	case strings.Contains(message, "private"):
+		return &AccountError{Username: username, Reason: ErrAccountPrivate, APIError: apiErr}
+	case strings.Contains(message, "not exist"), strings.Contains(message, "not found"),
+		strings.Contains(message, "banned"), strings.Contains(message, "deleted"),
+		strings.Contains(message, "unavailable"), strings.Contains(message, "not available"):
+		return &AccountError{Username: username, Reason: ErrAccountUnavailable, APIError: apiErr}
+	}
+
+	return err
+}
+
+// ResearchUserFields are the user fields requested when none are configured.
+var ResearchUserFields = []string{
+	"display_name", "bio_description", "avatar_url", "is_verified", "follower_count", "following_count",
+	"likes_count", "video_count",
+}
+
+// ResearchUser is the profile of a user returned by the Research API.
+type ResearchUser struct {
+	DisplayName    string `json:"display_name"`
+	BioDescription string `json:"bio_description"`
+	AvatarURL      string `json:"avatar_url"`
+	IsVerified     bool   `json:"is_verified"`
+	FollowerCount  int64  `json:"follower_count"`
+	FollowingCount int64  `json:"following_count"`
+	LikesCount     int64  `json:"likes_count"`
+	VideoCount     int64  `json:"video_count"`
+}
+
+// ResearchUserRef is a user listed as a follower or a following of another user.
+type ResearchUserRef struct {
+	DisplayName string `json:"display_name"`
+	Username    string `json:"username"`
+}
+
+// QueryResearchUser returns the profile of a user. Fields defaults to ResearchUserFields.
+func QueryResearchUser(ctx context.Context, token *oauth2.Token, username string, fields ...string) (*ResearchUser, error) {
+	if username == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchUser: username cannot be empty")
+	}
+
+	if len(fields) == 0 {
+		fields = ResearchUserFields
+	}
+
+	var user ResearchUser
+	if err := doAPIRequest(ctx, http.MethodPost, withFields(endpointResearchUserInfo, fields), token, researchUserRequest{Username: username}, &user); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchUser: %w", accountError(username, err))
+	}
+
+	return &user, nil
+}
+
+// UserListPage is a page of the followers or the following of a user.
+type UserListPage struct {
+	Users   []ResearchUserRef
+	Cursor  int64
+	HasMore bool
+}
+
+// QueryResearchFollowers returns a page of the followers of a user, starting at the cursor of the
+// previous page. MaxCount defaults to 20 and may be up to MaxResearchPageSize.
+func QueryResearchFollowers(ctx context.Context, token *oauth2.Token, username string, maxCount int, cursor int64) (*UserListPage, error) {
+	page, err := queryUserList(ctx, token, endpointResearchUserFollowers, username, maxCount, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchFollowers: %w", err)
+	}
+
+	return page, nil
+}
+
+// QueryResearchFollowing returns a page of the users a user follows, starting at the cursor of the
+// previous page. MaxCount defaults to 20 and may be up to MaxResearchPageSize.
+func QueryResearchFollowing(ctx context.Context, token *oauth2.Token, username string, maxCount int, cursor int64) (*UserListPage, error) {
+	page, err := queryUserList(ctx, token, endpointResearchUserFollowing, username, maxCount, cursor)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: QueryResearchFollowing: %w", err)
+	}
+
+	return page, nil
+}
+
+func queryUserList(ctx context.Context, token *oauth2.Token, endpoint, username string, maxCount int, cursor int64) (*UserListPage, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty")
+	}
+
+	if maxCount < 0 || maxCount > MaxResearchPageSize {
+		return nil, fmt.Errorf("max count must be between 0 (default) and %d", MaxResearchPageSize)
+	}
+
+	var data researchUserListData
+	if err := doAPIRequest(ctx, http.MethodPost, endpoint, token, researchUserRequest{Username: username, MaxCount: maxCount, Cursor: cursor}, &data); err != nil {
+		return nil, accountError(username, err)
+	}
+
+	users := data.UserFollowers
+	if endpoint == endpointResearchUserFollowing {
+		users = data.UserFollowing
+	}
+
+	return &UserListPage{Users: users, Cursor: data.Cursor, HasMore: data.HasMore}, nil
+}
+
+// UserListIterator iterates over all the followers or the following of a user.
+type UserListIterator struct {
+	// OnPage, if set, is called with every page fetched. Returning an error stops the iteration
+	// and the error is reported by Err.
+	OnPage func(*UserListPage) error
+
+	*researchPager
+	users []ResearchUserRef
+}
+
+// NewFollowerIterator returns a new UserListIterator over the followers of a user.
+func NewFollowerIterator(ctx context.Context, token *oauth2.Token, username string, maxCount int) *UserListIterator {
+	return newUserListIterator(ctx, token, endpointResearchUserFollowers, username, maxCount)
+}
+
+// NewFollowingIterator returns a new UserListIterator over the users a user follows.
+func NewFollowingIterator(ctx context.Context, token *oauth2.Token, username string, maxCount int) *UserListIterator {
+	return newUserListIterator(ctx, token, endpointResearchUserFollowing, username, maxCount)
+}
+
+func newUserListIterator(ctx context.Context, token *oauth2.Token, endpoint, username string, maxCount int) *UserListIterator {
+	it := &UserListIterator{}
+
+	it.researchPager = &researchPager{
+		name: "UserListIterator",
+		ctx:  ctx,
+		fetch: func(ctx context.Context, cursor int64, _ string) (researchPage, error) {
+			page, err := queryUserList(ctx, token, endpoint, username, maxCount, cursor)
+			if err != nil {
+				return researchPage{}, err
+			}
+
+			if it.OnPage != nil {
+				if err = it.OnPage(page); err != nil {
+					return researchPage{}, err
+				}
+			}
+
+			it.users = page.Users
+
+			return researchPage{count: len(page.Users), cursor: page.Cursor, hasMore: page.HasMore}, nil
+		},
+	}
+
+	return it
+}
+
+// User returns the current user.
+func (it *UserListIterator) User() ResearchUserRef {
+	return it.users[it.index]
+}
@@ -0,0 +1,194 @@
+package tiktok_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+const (
+	endpointResearchUserInfo      = "https://open.tiktokapis.com/v2/research/user/info/"
+	endpointResearchUserFollowers = "https://open.tiktokapis.com/v2/research/user/followers/"
+	endpointResearchUserFollowing = "https://open.tiktokapis.com/v2/research/user/following/"
+)
+
+func TestQueryResearchUserSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponderWithQuery(
+		http.MethodPost,
+		endpointResearchUserInfo,
+		map[string]string{"fields": "display_name,follower_count"},
+		httpmock.NewStringResponder(http.StatusOK, responseResearchUser),
+	)
+
+	user, err := tiktok.QueryResearchUser(context.Background(), testNewOauthToken(t), "test-user", "display_name", "follower_count")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if user.DisplayName != "test-display-name" || user.FollowerCount != 1500 {
+		t.Fatalf("expected user 'test-display-name' with 1500 followers, but got %s with %d", user.DisplayName, user.FollowerCount)
+	}
+}
+
+func TestQueryResearchUserAccountError(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		expected error
+	}{
+		{
+			name:     "private account",
+			response: responseResearchUserPrivate,
+			expected: tiktok.ErrAccountPrivate,
+		},
+		{
+			name:     "unavailable account",
+			response: responseResearchUserNotFound,
+			expected: tiktok.ErrAccountUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			t.Cleanup(httpmock.Deactivate)
+
+			httpmock.RegisterResponder(http.MethodPost, endpointResearchUserInfo, httpmock.NewStringResponder(http.StatusForbidden, tt.response))
+
+			_, err := tiktok.QueryResearchUser(context.Background(), testNewOauthToken(t), "test-user")
+			if !errors.Is(err, tt.expected) {
+				t.Fatalf("expected error '%v', but got '%v'", tt.expected, err)
+			}
+
+			var accountErr *tiktok.AccountError
+			if !errors.As(err, &accountErr) || accountErr.Username != "test-user" {
+				t.Fatalf("expected *tiktok.AccountError for 'test-user', but got %v", err)
+			}
+
+			var apiErr *tiktok.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected error to wrap *tiktok.APIError, but got %v", err)
+			}
+		})
+	}
+}
+
+func TestQueryResearchUserAuthError(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(http.MethodPost, endpointResearchUserInfo, httpmock.NewStringResponder(http.StatusUnauthorized, responseV2Error))
+
+	_, err := tiktok.QueryResearchUser(context.Background(), testNewOauthToken(t), "test-user")
+
+	var accountErr *tiktok.AccountError
+	if errors.As(err, &accountErr) {
+		t.Fatalf("expected access token error not to be an account error, but got '%v'", err)
+	}
+
+	var apiErr *tiktok.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "access_token_invalid" {
+		t.Fatalf("expected access_token_invalid api error, but got '%v'", err)
+	}
+}
+
+func TestFollowerIteratorSuccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		listKey  string
+		iterator func(ctx context.Context, t *testing.T) *tiktok.UserListIterator
+	}{
+		{
+			name:     "followers",
+			endpoint: endpointResearchUserFollowers,
+			listKey:  "user_followers",
+			iterator: func(ctx context.Context, t *testing.T) *tiktok.UserListIterator {
+				return tiktok.NewFollowerIterator(ctx, testNewOauthToken(t), "test-user", 2)
+			},
+		},
+		{
+			name:     "following",
+			endpoint: endpointResearchUserFollowing,
+			listKey:  "user_following",
+			iterator: func(ctx context.Context, t *testing.T) *tiktok.UserListIterator {
+				return tiktok.NewFollowingIterator(ctx, testNewOauthToken(t), "test-user", 2)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpmock.Activate()
+			t.Cleanup(httpmock.Deactivate)
+
+			// Cursors are timestamps going back in time, from 1000 down to 0.
+			httpmock.RegisterResponder(
+				http.MethodPost,
+				tt.endpoint,
+				func(req *http.Request) (*http.Response, error) {
+					var body struct {
+						Cursor int64 `json:"cursor"`
+					}
+
+					if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+						return nil, err
+					}
+
+					next := int64(1000)
+					if body.Cursor != 0 {
+						next = body.Cursor - 500
+					}
+
+					response := fmt.Sprintf(
+						`{"data":{"%s":[{"username":"user-%d-a"},{"username":"user-%d-b"}],"cursor":%d,"has_more":%t},"error":{"code":"ok"}}`,
+						tt.listKey, next, next, next, next > 0,
+					)
+
+					return httpmock.NewStringResponse(http.StatusOK, response), nil
+				},
+			)
+
+			it := tt.iterator(context.Background(), t)
+
+			var usernames []string
+			for it.Next() {
+				usernames = append(usernames, it.User().Username)
+			}
+
+			if err := it.Err(); err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			expected := "[user-1000-a user-1000-b user-500-a user-500-b user-0-a user-0-b]"
+			if fmt.Sprint(usernames) != expected {
+				t.Fatalf("expected usernames '%s', but got %v", expected, usernames)
+			}
+		})
+	}
+}
+
+func TestFollowerIteratorPrivateAccount(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(http.MethodPost, endpointResearchUserFollowers, httpmock.NewStringResponder(http.StatusForbidden, responseResearchUserPrivate))
+
+	it := tiktok.NewFollowerIterator(context.Background(), testNewOauthToken(t), "test-user", 0)
+	if it.Next() {
+		t.Fatal("expected no followers")
+	}
+
+	if !errors.Is(it.Err(), tiktok.ErrAccountPrivate) {
+		t.Fatalf("expected error '%v', but got '%v'", tiktok.ErrAccountPrivate, it.Err())
+	}
+}
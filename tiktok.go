@@ -8,6 +8,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"golang.org/x/oauth2"
@@ -17,6 +19,16 @@ var (
 	httpClient = &http.Client{Timeout: time.Second * 10}
 )
 
+// contextClient returns the *http.Client set in the context under the oauth2.HTTPClient key, the
+// same way the oauth2 package does, or the fallback client if there is none.
+func contextClient(ctx context.Context, fallback *http.Client) *http.Client {
+	if client, ok := ctx.Value(oauth2.HTTPClient).(*http.Client); ok && client != nil {
+		return client
+	}
+
+	return fallback
+}
+
 // NewConfig returns a new TikTok oauth2 config based on provided arguments.
 func NewConfig(clientID, clientSecret, redirectURL string, scopes ...string) (*oauth2.Config, error) {
 	if clientID == "" {
@@ -60,57 +72,23 @@ func ConfigExchange(ctx context.Context, config *oauth2.Config, code string) (*o
 		return nil, fmt.Errorf("tiktok-oauth2: ConfigExchange: code cannot be empty")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointToken, nil)
-	if err != nil {
-		return nil, fmt.Errorf("tiktok-oauth2: ConfigExchange: %w", err)
-	}
-
-	q := req.URL.Query()
-	q.Add("client_key", config.ClientID)
-	q.Add("client_secret", config.ClientSecret)
-	q.Add("code", code)
-	q.Add("grant_type", "authorization_code")
-	req.URL.RawQuery = q.Encode()
+	form := url.Values{}
+	form.Add("client_key", config.ClientID)
+	form.Add("client_secret", config.ClientSecret)
+	form.Add("code", code)
+	form.Add("grant_type", "authorization_code")
 
-	response, err := httpClient.Do(req)
+	req, err := newFormRequest(ctx, endpointToken, form)
 	if err != nil {
 		return nil, fmt.Errorf("tiktok-oauth2: ConfigExchange: %w", err)
 	}
 
-	defer response.Body.Close()
-
-	bodyBytes, err := ioutil.ReadAll(response.Body)
+	token, err := invokeTokenRequest(ctx, OperationExchange, req, config.ClientSecret, code)
 	if err != nil {
 		return nil, fmt.Errorf("tiktok-oauth2: ConfigExchange: %w", err)
 	}
 
-	var body tokenResponse
-	if err = json.Unmarshal(bodyBytes, &body); err != nil {
-		return nil, fmt.Errorf("tiktok-oauth2: ConfigExchange: %w", err)
-	}
-
-	if body == (tokenResponse{}) {
-		return nil, fmt.Errorf("tiktok-oauth2: ConfigExchange: %w", handleErrorResponse(bodyBytes))
-	}
-
-	token := &oauth2.Token{
-		AccessToken:  body.Data.AccessToken,
-		TokenType:    "Bearer",
-		RefreshToken: body.Data.RefreshToken,
-		Expiry:       time.Now().Add(time.Second * time.Duration(body.Data.ExpiresIn)),
-	}
-
-	if token.AccessToken == "" {
-		return nil, fmt.Errorf("tiktok-oauth2: ConfigExchange: server response missing access_token")
-	}
-
-	tokenExtra := map[string]interface{}{
-		"open_id":            body.Data.OpenID,
-		"scope":              body.Data.Scope,
-		"refresh_expires_in": body.Data.RefreshExpiresIn,
-	}
-
-	return token.WithExtra(tokenExtra), nil
+	return token, nil
 }
 
 // RefreshToken refreshes the access token of the user.
@@ -123,56 +101,95 @@ func RefreshToken(ctx context.Context, clientID, refreshToken string) (*oauth2.T
 		return nil, fmt.Errorf("tiktok-oauth2: RefreshToken: refresh token cannot be empty")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointRefresh, nil)
-	if err != nil {
-		return nil, fmt.Errorf("tiktok-oauth2: %w", err)
-	}
-
-	q := req.URL.Query()
-	q.Add("client_key", clientID)
-	q.Add("refresh_token", refreshToken)
-	q.Add("grant_type", "refresh_token")
-	req.URL.RawQuery = q.Encode()
+	form := url.Values{}
+	form.Add("client_key", clientID)
+	form.Add("refresh_token", refreshToken)
+	form.Add("grant_type", "refresh_token")
 
-	response, err := httpClient.Do(req)
+	req, err := newFormRequest(ctx, endpointRefresh, form)
 	if err != nil {
 		return nil, fmt.Errorf("tiktok-oauth2: RefreshToken: %w", err)
 	}
 
-	defer response.Body.Close()
-
-	bodyBytes, err := ioutil.ReadAll(response.Body)
+	token, err := invokeTokenRequest(ctx, OperationRefresh, req, refreshToken)
 	if err != nil {
 		return nil, fmt.Errorf("tiktok-oauth2: RefreshToken: %w", err)
 	}
 
-	var body tokenResponse
-	if err = json.Unmarshal(bodyBytes, &body); err != nil {
-		return nil, fmt.Errorf("tiktok-oauth2: RefreshToken: %w", err)
-	}
+	return token, nil
+}
 
-	if body == (tokenResponse{}) {
-		return nil, fmt.Errorf("tiktok-oauth2: RefreshToken: %w", handleErrorResponse(bodyBytes))
+// ClientCredentialsToken returns a client access token, used to call APIs on behalf of the app
+// rather than of a user, such as the Research API.
+func ClientCredentialsToken(ctx context.Context, clientID, clientSecret string) (*oauth2.Token, error) {
+	if clientID == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: ClientCredentialsToken: client id cannot be empty")
 	}
 
-	token := &oauth2.Token{
-		AccessToken:  body.Data.AccessToken,
-		TokenType:    "Bearer",
-		RefreshToken: body.Data.RefreshToken,
-		Expiry:       time.Now().Add(time.Second * time.Duration(body.Data.ExpiresIn)),
+	if clientSecret == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: ClientCredentialsToken: client secret cannot be empty")
 	}
 
-	if token.AccessToken == "" {
-		return nil, fmt.Errorf("tiktok-oauth2: RefreshToken: server response missing access_token")
-	}
+	form := url.Values{}
+	form.Add("client_key", clientID)
+	form.Add("client_secret", clientSecret)
+	form.Add("grant_type", "client_credentials")
 
-	tokenExtra := map[string]interface{}{
-		"open_id":            body.Data.OpenID,
-		"scope":              body.Data.Scope,
-		"refresh_expires_in": body.Data.RefreshExpiresIn,
+	req, err := newFormRequest(ctx, endpointClientToken, form)
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: ClientCredentialsToken: %w", err)
+	}
+
+	var token *oauth2.Token
+
+	call := &Call{Operation: OperationClientToken, Request: req}
+	err = invoke(ctx, call, func(call *Call) error {
+		response, err := sendRequest(ctx, httpClient, call.Request, clientSecret)
+		if err != nil {
+			return err
+		}
+
+		defer response.Body.Close()
+
+		call.Response = response
+
+		bodyBytes, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+
+		var body clientTokenResponse
+		if err = json.Unmarshal(bodyBytes, &body); err != nil {
+			return err
+		}
+
+		if body.Error != "" {
+			return &APIError{
+				StatusCode: response.StatusCode,
+				Code:       body.Error,
+				Message:    redactString(body.ErrorDescription, clientSecret),
+				LogID:      body.LogID,
+			}
+		}
+
+		if body.AccessToken == "" {
+			return fmt.Errorf("server response missing access_token")
+		}
+
+		token = &oauth2.Token{
+			AccessToken: body.AccessToken,
+			TokenType:   "Bearer",
+			Expiry:      time.Now().Add(time.Second * time.Duration(body.ExpiresIn)),
+		}
+		call.Result = token
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: ClientCredentialsToken: %w", err)
 	}
 
-	return token.WithExtra(tokenExtra), nil
+	return token, nil
 }
 
 // RevokeAccess revokes a user's access token.
@@ -182,35 +199,44 @@ func RevokeAccess(ctx context.Context, token *oauth2.Token) error {
 		return fmt.Errorf("tiktok-oauth2: RevokeAccess: failed to get open_id from token")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpointRevoke, nil)
+	form := url.Values{}
+	form.Add("access_token", token.AccessToken)
+	form.Add("open_id", openID)
+
+	req, err := newFormRequest(ctx, endpointRevoke, form)
 	if err != nil {
 		return fmt.Errorf("tiktok-oauth2: RevokeAccess: %w", err)
 	}
 
-	q := req.URL.Query()
-	q.Add("access_token", token.AccessToken)
-	q.Add("open_id", openID)
-	req.URL.RawQuery = q.Encode()
+	call := &Call{Operation: OperationRevoke, Request: req}
+	err = invoke(ctx, call, func(call *Call) error {
+		response, err := sendRequest(ctx, httpClient, call.Request, token.AccessToken)
+		if err != nil {
+			return err
+		}
 
-	response, err := httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("tiktok-oauth2: RevokeAccess: %w", err)
-	}
+		defer response.Body.Close()
 
-	defer response.Body.Close()
+		call.Response = response
 
-	bodyBytes, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return fmt.Errorf("tiktok-oauth2: RevokeAccess: %w", err)
-	}
+		bodyBytes, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
 
-	var body revokeResponse
-	if err = json.Unmarshal(bodyBytes, &body); err != nil {
-		return fmt.Errorf("tiktok-oauth2: RevokeAccess: %w", err)
-	}
+		var body revokeResponse
+		if err = json.Unmarshal(bodyBytes, &body); err != nil {
+			return err
+		}
+
+		if body.Message != "success" {
+			return handleErrorResponse(bodyBytes, token.AccessToken)
+		}
 
-	if body.Message != "success" {
-		return fmt.Errorf("tiktok-oauth2: RevokeAccess: %w", handleErrorResponse(bodyBytes))
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("tiktok-oauth2: RevokeAccess: %w", err)
 	}
 
 	return nil
@@ -223,51 +249,132 @@ func RetrieveUserInfo(ctx context.Context, token *oauth2.Token) (*UserInfo, erro
 		return nil, fmt.Errorf("tiktok-oauth2: RetrieveUserInfo: failed to get open_id from token")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpointUserInfo, nil)
+	form := url.Values{}
+	form.Add("access_token", token.AccessToken)
+	form.Add("open_id", openID)
+
+	req, err := newFormRequest(ctx, endpointUserInfo, form)
 	if err != nil {
 		return nil, fmt.Errorf("tiktok-oauth2: RetrieveUserInfo: %w", err)
 	}
 
-	q := req.URL.Query()
-	q.Add("access_token", token.AccessToken)
-	q.Add("open_id", openID)
-	req.URL.RawQuery = q.Encode()
+	var info *UserInfo
 
-	response, err := httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("tiktok-oauth2: RetrieveUserInfo: %w", err)
-	}
+	call := &Call{Operation: OperationUserInfo, Request: req}
+	err = invoke(ctx, call, func(call *Call) error {
+		response, err := sendRequest(ctx, httpClient, call.Request, token.AccessToken)
+		if err != nil {
+			return err
+		}
+
+		defer response.Body.Close()
+
+		call.Response = response
+
+		bodyBytes, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+
+		var body userInfoResponse
+		if err = json.Unmarshal(bodyBytes, &body); err != nil {
+			return err
+		}
+
+		if body == (userInfoResponse{}) {
+			return handleErrorResponse(bodyBytes, token.AccessToken)
+		}
 
-	defer response.Body.Close()
+		info = &UserInfo{
+			OpenID:       body.Data.OpenID,
+			UnionID:      body.Data.UnionID,
+			Avatar:       body.Data.Avatar,
+			AvatarLarger: body.Data.AvatarLarger,
+			DisplayName:  body.Data.DisplayName,
+		}
+		call.Result = info
 
-	bodyBytes, err := ioutil.ReadAll(response.Body)
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("tiktok-oauth2: RetrieveUserInfo: %w", err)
 	}
 
-	var body userInfoResponse
-	if err = json.Unmarshal(bodyBytes, &body); err != nil {
-		return nil, fmt.Errorf("tiktok-oauth2: RetrieveUserInfo: %w", err)
-	}
+	return info, nil
+}
 
-	if body == (userInfoResponse{}) {
-		return nil, fmt.Errorf("tiktok-oauth2: RetrieveUserInfo: %w", handleErrorResponse(bodyBytes))
+// invokeTokenRequest sends a request to the token or refresh endpoint through the interceptors of
+// the context and returns the token of the response.
+func invokeTokenRequest(ctx context.Context, op Operation, req *http.Request, secrets ...string) (*oauth2.Token, error) {
+	var token *oauth2.Token
+
+	call := &Call{Operation: op, Request: req}
+	err := invoke(ctx, call, func(call *Call) error {
+		response, err := sendRequest(ctx, httpClient, call.Request, secrets...)
+		if err != nil {
+			return err
+		}
+
+		defer response.Body.Close()
+
+		call.Response = response
+
+		bodyBytes, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return err
+		}
+
+		var body tokenResponse
+		if err = json.Unmarshal(bodyBytes, &body); err != nil {
+			return err
+		}
+
+		if body == (tokenResponse{}) {
+			return handleErrorResponse(bodyBytes, secrets...)
+		}
+
+		if body.Data.AccessToken == "" {
+			return fmt.Errorf("server response missing access_token")
+		}
+
+		token = (&oauth2.Token{
+			AccessToken:  body.Data.AccessToken,
+			TokenType:    "Bearer",
+			RefreshToken: body.Data.RefreshToken,
+			Expiry:       time.Now().Add(time.Second * time.Duration(body.Data.ExpiresIn)),
+		}).WithExtra(map[string]interface{}{
+			"open_id":            body.Data.OpenID,
+			"scope":              body.Data.Scope,
+			"refresh_expires_in": body.Data.RefreshExpiresIn,
+		})
+		call.Result = token
+
+		return nil
+	})
+
+	return token, err
+}
+
+// newFormRequest returns a POST request sending the form in its body, rather than in the url
+// where credentials would end up in errors and logs.
+func newFormRequest(ctx context.Context, endpoint string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
 	}
 
-	return &UserInfo{
-		OpenID:       body.Data.OpenID,
-		UnionID:      body.Data.UnionID,
-		Avatar:       body.Data.Avatar,
-		AvatarLarger: body.Data.AvatarLarger,
-		DisplayName:  body.Data.DisplayName,
-	}, nil
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return req, nil
 }
 
-func handleErrorResponse(data []byte) error {
+// handleErrorResponse returns the error of a response of the OAuth endpoints, with the secrets
+// scrubbed from the description.
+func handleErrorResponse(data []byte, secrets ...string) error {
 	var errBody errorResponse
 	if err := json.Unmarshal(data, &errBody); err != nil {
 		return err
 	}
 
-	return fmt.Errorf("%s [%d]", errBody.Data.Description, errBody.Data.ErrorCode)
+	return fmt.Errorf("%s [%d]", redactString(errBody.Data.Description, secrets...), errBody.Data.ErrorCode)
 }
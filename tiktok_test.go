@@ -159,11 +159,10 @@ func TestConfigExchangeSuccess(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
+	httpmock.RegisterResponder(
 		http.MethodPost,
 		cfg.Endpoint.TokenURL,
-		accessTokenParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseSuccessToken),
+		testFormResponder(accessTokenParameters, responseSuccessToken),
 	)
 
 	token, err := tiktok.ConfigExchange(context.Background(), testNewOauthConfig(t), "test-code")
@@ -205,11 +204,10 @@ func TestConfigExchangeError(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
+	httpmock.RegisterResponder(
 		http.MethodPost,
 		cfg.Endpoint.TokenURL,
-		accessTokenParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseError),
+		testFormResponder(accessTokenParameters, responseError),
 	)
 
 	_, err := tiktok.ConfigExchange(context.Background(), testNewOauthConfig(t), "test-code")
@@ -228,11 +226,10 @@ func TestConfigExchangeEmptyAccessToken(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
+	httpmock.RegisterResponder(
 		http.MethodPost,
 		cfg.Endpoint.TokenURL,
-		accessTokenParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseEmptyAccessToken),
+		testFormResponder(accessTokenParameters, responseEmptyAccessToken),
 	)
 
 	_, err := tiktok.ConfigExchange(context.Background(), testNewOauthConfig(t), "test-code")
@@ -283,11 +280,10 @@ func TestRefreshTokenSuccess(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
+	httpmock.RegisterResponder(
 		http.MethodPost,
 		"https://open-api.tiktok.com/oauth/refresh_token/",
-		refreshTokenParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseSuccessToken),
+		testFormResponder(refreshTokenParameters, responseSuccessToken),
 	)
 
 	token, err := tiktok.RefreshToken(context.Background(), "test-client-id", "test-refresh-token")
@@ -327,11 +323,10 @@ func TestRefreshTokenError(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
+	httpmock.RegisterResponder(
 		http.MethodPost,
 		"https://open-api.tiktok.com/oauth/refresh_token/",
-		refreshTokenParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseError),
+		testFormResponder(refreshTokenParameters, responseError),
 	)
 
 	_, err := tiktok.RefreshToken(context.Background(), "test-client-id", "test-refresh-token")
@@ -348,11 +343,10 @@ func TestRefreshTokenEmptyAccessToken(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
+	httpmock.RegisterResponder(
 		http.MethodPost,
 		"https://open-api.tiktok.com/oauth/refresh_token/",
-		refreshTokenParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseEmptyAccessToken),
+		testFormResponder(refreshTokenParameters, responseEmptyAccessToken),
 	)
 
 	_, err := tiktok.RefreshToken(context.Background(), "test-client-id", "test-refresh-token")
@@ -365,6 +359,96 @@ func TestRefreshTokenEmptyAccessToken(t *testing.T) {
 	}
 }
 
+func TestClientCredentialsTokenInvalidArguments(t *testing.T) {
+	tests := []struct {
+		name          string
+		clientID      string
+		clientSecret  string
+		errorContains string
+	}{
+		{
+			name:          "empty client id",
+			clientID:      "",
+			errorContains: "ClientCredentialsToken: client id cannot be empty",
+		},
+		{
+			name:          "empty client secret",
+			clientID:      "test-client-id",
+			clientSecret:  "",
+			errorContains: "ClientCredentialsToken: client secret cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tiktok.ClientCredentialsToken(context.Background(), tt.clientID, tt.clientSecret)
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.errorContains) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestClientCredentialsTokenSuccess(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		"https://open.tiktokapis.com/v2/oauth/token/",
+		func(req *http.Request) (*http.Response, error) {
+			if err := req.ParseForm(); err != nil {
+				return nil, err
+			}
+
+			for key, value := range clientCredentialsParameters {
+				if req.PostForm.Get(key) != value {
+					return httpmock.NewStringResponse(http.StatusUnauthorized, responseClientTokenError), nil
+				}
+			}
+
+			return httpmock.NewStringResponse(http.StatusOK, responseSuccessClientToken), nil
+		},
+	)
+
+	token, err := tiktok.ClientCredentialsToken(context.Background(), "test-client-id", "test-client-secret")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if token.AccessToken != "clt.test-access-token" {
+		t.Fatalf("expected access token 'clt.test-access-token', but got %s", token.AccessToken)
+	}
+
+	if !token.Valid() {
+		t.Fatal("expected token to be valid")
+	}
+}
+
+func TestClientCredentialsTokenError(t *testing.T) {
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	httpmock.RegisterResponder(
+		http.MethodPost,
+		"https://open.tiktokapis.com/v2/oauth/token/",
+		httpmock.NewStringResponder(http.StatusUnauthorized, responseClientTokenError),
+	)
+
+	_, err := tiktok.ClientCredentialsToken(context.Background(), "test-client-id", "test-client-secret")
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if !strings.Contains(err.Error(), "ClientCredentialsToken: Client key or secret is incorrect. [invalid_client]") {
+		t.Fatalf("expected error to contain 'ClientCredentialsToken: Client key or secret is incorrect. [invalid_client]', but got '%v'", err)
+	}
+}
+
 func TestRevokeAccessInvalidArguments(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -408,11 +492,10 @@ func TestRevokeAccessSuccess(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
+	httpmock.RegisterResponder(
 		http.MethodPost,
 		"https://open-api.tiktok.com/oauth/revoke/",
-		revokeParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseSuccessRevoke),
+		testFormResponder(revokeParameters, responseSuccessRevoke),
 	)
 
 	err := tiktok.RevokeAccess(context.Background(), token)
@@ -427,11 +510,10 @@ func TestRevokeAccessError(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
+	httpmock.RegisterResponder(
 		http.MethodPost,
 		"https://open-api.tiktok.com/oauth/revoke/",
-		revokeParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseError),
+		testFormResponder(revokeParameters, responseError),
 	)
 
 	err := tiktok.RevokeAccess(context.Background(), token)
@@ -487,11 +569,10 @@ func TestRetrieveUserInfoSuccess(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
-		http.MethodGet,
+	httpmock.RegisterResponder(
+		http.MethodPost,
 		"https://open-api.tiktok.com/oauth/userinfo/",
-		userInfoParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseSuccessUserInfo),
+		testFormResponder(userInfoParameters, responseSuccessUserInfo),
 	)
 
 	user, err := tiktok.RetrieveUserInfo(context.Background(), token)
@@ -526,11 +607,10 @@ func TestRetrieveUserInfoError(t *testing.T) {
 	httpmock.Activate()
 	t.Cleanup(httpmock.Deactivate)
 
-	httpmock.RegisterResponderWithQuery(
-		http.MethodGet,
+	httpmock.RegisterResponder(
+		http.MethodPost,
 		"https://open-api.tiktok.com/oauth/userinfo/",
-		userInfoParameters,
-		httpmock.NewStringResponder(http.StatusOK, responseError),
+		testFormResponder(userInfoParameters, responseError),
 	)
 
 	_, err := tiktok.RetrieveUserInfo(context.Background(), token)
@@ -0,0 +1,429 @@
+package tiktoktest
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/oauth2"
+)
+
+// Redacted replaces the values of secrets in cassettes.
+const Redacted = "REDACTED"
+
+// EncodingBase64 is the encoding of the binary bodies of cassettes, such as uploaded videos.
+const EncodingBase64 = "base64"
+
+var (
+	// secretParams are the query and form parameters redacted from cassettes.
+	secretParams = []string{"client_secret", "access_token", "refresh_token", "code"}
+	// secretFields are the JSON fields redacted from cassettes. Unlike the code parameter, which is
+	// an authorization code, JSON code fields are error codes and are kept.
+	secretFields = []string{"client_secret", "access_token", "refresh_token"}
+)
+
+// Cassette is a sequence of recorded HTTP interactions, saved as a JSON file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request    CassetteRequest  `json:"request"`
+	Response   CassetteResponse `json:"response"`
+	RecordedAt time.Time        `json:"recorded_at"`
+}
+
+// CassetteRequest is a recorded request, with its secrets redacted.
+type CassetteRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+	// BodyEncoding is EncodingBase64 for binary bodies, or empty for text ones.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// CassetteResponse is a recorded response, with its secrets redacted.
+type CassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+	// BodyEncoding is EncodingBase64 for binary bodies, or empty for text ones.
+	BodyEncoding string `json:"body_encoding,omitempty"`
+}
+
+// LoadCassetteFile reads a cassette from a JSON file.
+func LoadCassetteFile(path string) (*Cassette, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("tiktoktest: LoadCassetteFile: %w", err)
+	}
+
+	var cassette Cassette
+	if err = json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("tiktoktest: LoadCassetteFile: %w", err)
+	}
+
+	return &cassette, nil
+}
+
+// Save writes the cassette to a JSON file.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("tiktoktest: Save: %w", err)
+	}
+
+	if err = ioutil.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("tiktoktest: Save: %w", err)
+	}
+
+	return nil
+}
+
+// Recorder is an http.RoundTripper sending requests through another one and recording them along
+// with their responses. The client_secret, access_token, refresh_token and code parameters, the
+// client_secret, access_token and refresh_token JSON fields and the Authorization and cookie
+// headers are redacted. Binary bodies are recorded base64 encoded.
+type Recorder struct {
+	base     http.RoundTripper
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder returns a Recorder sending requests through base, or http.DefaultTransport if nil.
+func NewRecorder(base http.RoundTripper) *Recorder {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	return &Recorder{base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := readBody(req.Body)
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	sent := req.Clone(req.Context())
+	if req.Body != nil {
+		sent.Body = ioutil.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	response, err := r.base.RoundTrip(sent)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := readBody(response.Body)
+	response.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	response.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: CassetteRequest{
+			Method: req.Method,
+			URL:    redactURL(req.URL),
+			Header: redactHeader(req.Header),
+		},
+		Response: CassetteResponse{
+			StatusCode: response.StatusCode,
+			Header:     redactHeader(response.Header),
+		},
+		RecordedAt: time.Now(),
+	}
+
+	interaction.Request.Body, interaction.Request.BodyEncoding = encodeBody(reqBody, req.Header.Get("Content-Type"))
+	interaction.Response.Body, interaction.Response.BodyEncoding = encodeBody(respBody, response.Header.Get("Content-Type"))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+
+	return response, nil
+}
+
+// Cassette returns the interactions recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Save writes the interactions recorded so far to a JSON file.
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
+
+// Context returns a copy of the context carrying a client recording through the Recorder under
+// the oauth2.HTTPClient key.
+func (r *Recorder) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: r})
+}
+
+// Replayer is an http.RoundTripper serving the responses of a cassette instead of sending the
+// requests. A request is served the first interaction not yet replayed with the same method, path
+// and parameters, ignoring the secret ones, so that repeated requests are served in the order they
+// were recorded.
+type Replayer struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	replayed []bool
+}
+
+// NewReplayer returns a Replayer serving the interactions of the cassette.
+func NewReplayer(cassette *Cassette) *Replayer {
+	return &Replayer{
+		cassette: cassette,
+		replayed: make([]bool, len(cassette.Interactions)),
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	method, path := req.Method, req.URL.Path
+
+	encoded, encoding := encodeBody(body, req.Header.Get("Content-Type"))
+	params := requestParams(req.URL, encoded, encoding, req.Header.Get("Content-Type"))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.replayed[i] || interaction.Request.Method != method {
+			continue
+		}
+
+		u, err := url.Parse(interaction.Request.URL)
+		if err != nil || u.Path != path {
+			continue
+		}
+
+		recorded := requestParams(u, interaction.Request.Body, interaction.Request.BodyEncoding, interaction.Request.Header.Get("Content-Type"))
+		if !reflect.DeepEqual(params, recorded) {
+			continue
+		}
+
+		respBody, err := decodeBody(interaction.Response.Body, interaction.Response.BodyEncoding)
+		if err != nil {
+			return nil, fmt.Errorf("tiktoktest: invalid recorded body for %s %s: %w", method, path, err)
+		}
+
+		r.replayed[i] = true
+
+		header := interaction.Response.Header.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          ioutil.NopCloser(bytes.NewReader(respBody)),
+			ContentLength: int64(len(respBody)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("tiktoktest: no recorded interaction for %s %s", method, path)
+}
+
+// Context returns a copy of the context carrying a client replaying through the Replayer under
+// the oauth2.HTTPClient key.
+func (r *Replayer) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, &http.Client{Transport: r})
+}
+
+// Verify checks that all the interactions of the cassette were replayed.
+func (r *Replayer) Verify() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var failures []string
+	for i, interaction := range r.cassette.Interactions {
+		if !r.replayed[i] {
+			u, _ := url.Parse(interaction.Request.URL)
+			failures = append(failures, fmt.Sprintf("%s %s was not replayed", interaction.Request.Method, u.Path))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("tiktoktest: Verify: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// requestParams returns the query, form and body parameters of a request without the secrets, for
+// matching, from its body as encoded in cassettes. Other bodies are kept whole under an empty key.
+func requestParams(u *url.URL, body, encoding, contentType string) url.Values {
+	params := u.Query()
+
+	switch {
+	case encoding == EncodingBase64:
+		params[""] = []string{body}
+	case strings.HasPrefix(contentType, "application/x-www-form-urlencoded"):
+		form, _ := url.ParseQuery(body)
+		for key, values := range form {
+			params[key] = append(params[key], values...)
+		}
+	case body != "":
+		params[""] = []string{redactBody([]byte(body), contentType)}
+	}
+
+	for _, secret := range secretParams {
+		params.Del(secret)
+	}
+
+	return params
+}
+
+func readBody(body io.Reader) ([]byte, error) {
+	if body == nil || body == http.NoBody {
+		return nil, nil
+	}
+
+	return ioutil.ReadAll(body)
+}
+
+// encodeBody returns the body as recorded in cassettes and its encoding: base64 encoded if it is
+// not valid UTF-8, or with its secrets redacted otherwise.
+func encodeBody(body []byte, contentType string) (string, string) {
+	if !utf8.Valid(body) {
+		return base64.StdEncoding.EncodeToString(body), EncodingBase64
+	}
+
+	return redactBody(body, contentType), ""
+}
+
+// decodeBody returns the body of a cassette in its encoding.
+func decodeBody(body, encoding string) ([]byte, error) {
+	switch encoding {
+	case "":
+		return []byte(body), nil
+	case EncodingBase64:
+		return base64.StdEncoding.DecodeString(body)
+	default:
+		return nil, fmt.Errorf("unsupported body encoding %q", encoding)
+	}
+}
+
+// redactURL returns the url with the values of the secret parameters redacted.
+func redactURL(u *url.URL) string {
+	redacted := *u
+	redacted.RawQuery = redactValues(u.Query()).Encode()
+
+	return redacted.String()
+}
+
+func redactValues(values url.Values) url.Values {
+	for _, secret := range secretParams {
+		if _, ok := values[secret]; ok {
+			values.Set(secret, Redacted)
+		}
+	}
+
+	return values
+}
+
+// redactHeader returns a copy of the header with the credentials and cookies redacted, and the
+// code of redirect locations. The content length is dropped, as redacting changes it.
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	redacted.Del("Content-Length")
+
+	for _, key := range []string{"Authorization", "Cookie", "Set-Cookie"} {
+		if redacted.Get(key) != "" {
+			redacted.Set(key, Redacted)
+		}
+	}
+
+	if location, err := url.Parse(redacted.Get("Location")); err == nil && location.RawQuery != "" {
+		redacted.Set("Location", redactURL(location))
+	}
+
+	return redacted
+}
+
+// redactBody returns the body with the values of the secret form parameters or JSON fields
+// redacted. Other bodies are returned unchanged.
+func redactBody(body []byte, contentType string) string {
+	if strings.HasPrefix(contentType, "application/x-www-form-urlencoded") {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return string(body)
+		}
+
+		return redactValues(form).Encode()
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return string(body)
+	}
+
+	redacted, err := json.Marshal(redactJSON(v))
+	if err != nil {
+		return string(body)
+	}
+
+	return string(redacted)
+}
+
+func redactJSON(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if _, ok := field.(string); ok && hasScope(secretFields, key) {
+				value[key] = Redacted
+				continue
+			}
+
+			value[key] = redactJSON(field)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = redactJSON(item)
+		}
+	}
+
+	return v
+}
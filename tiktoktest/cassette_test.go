@@ -0,0 +1,186 @@
+package tiktoktest_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/chanioxaris/tiktok-oauth2/tiktoktest"
+)
+
+func TestRecorderReplayer(t *testing.T) {
+	srv, cfg := testNewServer(t)
+	path := filepath.Join(t.TempDir(), "cassette.json")
+
+	redirect, err := srv.Authorize(cfg.AuthCodeURL("test-state"), "")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	code := redirect.Query().Get("code")
+
+	recorder := tiktoktest.NewRecorder(srv.Client().Transport)
+	ctx := recorder.Context(context.Background())
+
+	token, err := tiktok.ConfigExchange(ctx, cfg, code)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err = tiktok.RetrieveUserInfo(ctx, token); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if _, err = tiktok.RefreshToken(ctx, cfg.ClientID, token.RefreshToken); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err = recorder.Save(path); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, secret := range []string{cfg.ClientSecret, token.AccessToken, token.RefreshToken, code} {
+		if strings.Contains(string(data), secret) {
+			t.Fatalf("expected cassette not to contain '%s'", secret)
+		}
+	}
+
+	cassette, err := tiktoktest.LoadCassetteFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if len(cassette.Interactions) != 3 {
+		t.Fatalf("expected 3 interactions, but got %d", len(cassette.Interactions))
+	}
+
+	replayer := tiktoktest.NewReplayer(cassette)
+	ctx = replayer.Context(context.Background())
+
+	// The secrets sent on replay differ from the recorded ones, which are redacted anyway.
+	replayed, err := tiktok.ConfigExchange(ctx, cfg, "other-code")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if replayed.AccessToken != tiktoktest.Redacted {
+		t.Fatalf("expected access token '%s', but got '%s'", tiktoktest.Redacted, replayed.AccessToken)
+	}
+
+	openID, err := tiktok.OpenIDFromToken(replayed)
+	if err != nil || openID != "test-open-id" {
+		t.Fatalf("expected open id 'test-open-id', but got '%s', %v", openID, err)
+	}
+
+	if err = replayer.Verify(); err == nil || !strings.Contains(err.Error(), "/oauth/userinfo/ was not replayed") {
+		t.Fatalf("expected error to contain '%s', but got '%v'", "/oauth/userinfo/ was not replayed", err)
+	}
+
+	info, err := tiktok.RetrieveUserInfo(ctx, replayed)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.DisplayName != "test-display-name" {
+		t.Fatalf("expected display name 'test-display-name', but got '%s'", info.DisplayName)
+	}
+
+	if _, err = tiktok.RefreshToken(ctx, cfg.ClientID, replayed.RefreshToken); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err = replayer.Verify(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	_, err = tiktok.RefreshToken(ctx, cfg.ClientID, replayed.RefreshToken)
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction for POST /oauth/refresh_token/") {
+		t.Fatalf("expected error to contain '%s', but got '%v'", "no recorded interaction for POST /oauth/refresh_token/", err)
+	}
+}
+
+func TestReplayerMatchesNonSecretParams(t *testing.T) {
+	srv, cfg := testNewServer(t)
+	token := testLogin(t, srv, cfg, "")
+
+	recorder := tiktoktest.NewRecorder(srv.Client().Transport)
+
+	if _, err := tiktok.RefreshToken(recorder.Context(context.Background()), cfg.ClientID, token.RefreshToken); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	ctx := tiktoktest.NewReplayer(recorder.Cassette()).Context(context.Background())
+
+	_, err := tiktok.RefreshToken(ctx, "other-client-id", token.RefreshToken)
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction") {
+		t.Fatalf("expected error to contain '%s', but got '%v'", "no recorded interaction", err)
+	}
+}
+
+func TestRecorderReplayerUpload(t *testing.T) {
+	srv, _ := testNewServer(t)
+	token := testLoginWithScopes(t, srv, "video.upload")
+
+	// The video is not valid UTF-8, as most video files.
+	video := make([]byte, 512)
+	for i := range video {
+		video[i] = byte(i)
+	}
+
+	// Without post info, the video is sent to the inbox of the user.
+	opts := &tiktok.UploadOptions{}
+
+	recorder := tiktoktest.NewRecorder(srv.Client().Transport)
+
+	if _, err := tiktok.UploadVideo(recorder.Context(context.Background()), token, bytes.NewReader(video), int64(len(video)), opts); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	cassette, err := tiktoktest.LoadCassetteFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	upload := cassette.Interactions[len(cassette.Interactions)-1].Request
+	if upload.Method != http.MethodPut || upload.BodyEncoding != tiktoktest.EncodingBase64 {
+		t.Fatalf("expected base64 encoded upload body, but got %s request with encoding '%s'", upload.Method, upload.BodyEncoding)
+	}
+
+	replayer := tiktoktest.NewReplayer(cassette)
+
+	publishID, err := tiktok.UploadVideo(replayer.Context(context.Background()), token, bytes.NewReader(video), int64(len(video)), opts)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if publishID == "" {
+		t.Fatal("expected publish id")
+	}
+
+	if err = replayer.Verify(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	// A different video does not match the recorded upload.
+	video[0] = 0xff
+
+	_, err = tiktok.UploadVideo(tiktoktest.NewReplayer(cassette).Context(context.Background()), token, bytes.NewReader(video), int64(len(video)), opts)
+	if err == nil || !strings.Contains(err.Error(), "no recorded interaction for PUT /upload/") {
+		t.Fatalf("expected error to contain '%s', but got '%v'", "no recorded interaction for PUT /upload/", err)
+	}
+}
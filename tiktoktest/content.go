@@ -0,0 +1,527 @@
+package tiktoktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Video is a video of a user known to the server.
+type Video struct {
+	ID               string `json:"id"`
+	Title            string `json:"title"`
+	VideoDescription string `json:"video_description"`
+	Duration         int    `json:"duration"`
+	CoverImageURL    string `json:"cover_image_url"`
+	ShareURL         string `json:"share_url"`
+	EmbedLink        string `json:"embed_link"`
+	LikeCount        int64  `json:"like_count"`
+	CommentCount     int64  `json:"comment_count"`
+	ShareCount       int64  `json:"share_count"`
+	ViewCount        int64  `json:"view_count"`
+	CreateTime       int64  `json:"create_time"`
+}
+
+// Publish states reported by the status endpoint.
+const (
+	publishStatusProcessingUpload = "PROCESSING_UPLOAD"
+	publishStatusSendToUserInbox  = "SEND_TO_USER_INBOX"
+	publishStatusComplete         = "PUBLISH_COMPLETE"
+)
+
+// publish is a post initiated through the posting endpoints.
+type publish struct {
+	id            string
+	openID        string
+	direct        bool
+	title         string
+	size          int64
+	uploadedBytes int64
+	status        string
+	postID        int64
+}
+
+// v2Error is an error of the v2 API.
+type v2Error struct {
+	status  int
+	code    string
+	message string
+}
+
+// AddVideo adds a video to the videos of the user, listed most recent first.
+func (s *Server) AddVideo(openID string, video Video) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if video.CreateTime == 0 {
+		video.CreateTime = s.now().Unix()
+	}
+
+	s.videos[openID] = append([]Video{video}, s.videos[openID]...)
+}
+
+// handleClientToken issues a client access token for the client credentials of an app.
+func (s *Server) handleClientToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.FormValue("grant_type") != "client_credentials" {
+		writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+			"error":             "invalid_request",
+			"error_description": "grant_type must be client_credentials",
+			"log_id":            randomValue(""),
+		})
+
+		return
+	}
+
+	c, lerr := s.authenticateClient(r.FormValue("client_key"), r.FormValue("client_secret"))
+	if lerr != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]interface{}{
+			"error":             "invalid_client",
+			"error_description": lerr.description,
+			"log_id":            randomValue(""),
+		})
+
+		return
+	}
+
+	s.pruneExpired()
+
+	token := &issuedToken{
+		accessToken: randomValue("clt."),
+		clientKey:   c.key,
+		scopes:      c.scopes,
+		expiry:      s.now().Add(time.Hour * 2),
+	}
+	s.accessTokens[token.accessToken] = token
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": token.accessToken,
+		"expires_in":   int64(time.Hour * 2 / time.Second),
+		"token_type":   "Bearer",
+	})
+}
+
+// handleUserInfoV2 returns the information of the user of the bearer token.
+func (s *Server) handleUserInfoV2(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, verr := s.authenticateUser(r, "user.info.basic")
+	if verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	writeV2Data(w, map[string]interface{}{
+		"user": map[string]interface{}{
+			"open_id":          user.OpenID,
+			"union_id":         user.UnionID,
+			"avatar_url":       user.Avatar,
+			"avatar_large_url": user.AvatarLarger,
+			"display_name":     user.DisplayName,
+		},
+	})
+}
+
+// handleVideoList returns a page of the videos of the user of the bearer token.
+func (s *Server) handleVideoList(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Cursor   int64 `json:"cursor"`
+		MaxCount int   `json:"max_count"`
+	}
+
+	if verr := decodeV2Request(r, &req); verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, verr := s.authenticateBearer(r, "video.list")
+	if verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	if req.MaxCount <= 0 || req.MaxCount > 20 {
+		req.MaxCount = 20
+	}
+
+	videos := s.videos[token.openID]
+
+	start := int(req.Cursor)
+	if start > len(videos) {
+		start = len(videos)
+	}
+
+	end := start + req.MaxCount
+	if end > len(videos) {
+		end = len(videos)
+	}
+
+	writeV2Data(w, map[string]interface{}{
+		"videos":   videos[start:end],
+		"cursor":   end,
+		"has_more": end < len(videos),
+	})
+}
+
+// handleVideoQuery returns the videos of the user of the bearer token among the requested ids.
+func (s *Server) handleVideoQuery(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Filters struct {
+			VideoIDs []string `json:"video_ids"`
+		} `json:"filters"`
+	}
+
+	if verr := decodeV2Request(r, &req); verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, verr := s.authenticateBearer(r, "video.list")
+	if verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	videos := make([]Video, 0)
+	for _, video := range s.videos[token.openID] {
+		for _, id := range req.Filters.VideoIDs {
+			if video.ID == id {
+				videos = append(videos, video)
+			}
+		}
+	}
+
+	writeV2Data(w, map[string]interface{}{"videos": videos})
+}
+
+// handleCreatorInfo returns the posting settings of the user of the bearer token.
+func (s *Server) handleCreatorInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, verr := s.authenticateUser(r, "video.publish")
+	if verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	writeV2Data(w, map[string]interface{}{
+		"creator_avatar_url":          user.Avatar,
+		"creator_username":            user.OpenID,
+		"creator_nickname":            user.DisplayName,
+		"privacy_level_options":       []string{"PUBLIC_TO_EVERYONE", "MUTUAL_FOLLOW_FRIENDS", "SELF_ONLY"},
+		"comment_disabled":            false,
+		"duet_disabled":               false,
+		"stitch_disabled":             false,
+		"max_video_post_duration_sec": 600,
+	})
+}
+
+// handleVideoInit initializes a video post, returning the url to upload the video to for file
+// uploads.
+func (s *Server) handleVideoInit(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PostInfo struct {
+			Title string `json:"title"`
+		} `json:"post_info"`
+		SourceInfo struct {
+			Source    string `json:"source"`
+			VideoSize int64  `json:"video_size"`
+			VideoURL  string `json:"video_url"`
+		} `json:"source_info"`
+	}
+
+	if verr := decodeV2Request(r, &req); verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	direct := endpointName(r.URL.Path) == EndpointVideoInit
+
+	scope := "video.upload"
+	if direct {
+		scope = "video.publish"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, verr := s.authenticateBearer(r, scope)
+	if verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	switch req.SourceInfo.Source {
+	case "FILE_UPLOAD":
+		if req.SourceInfo.VideoSize <= 0 {
+			writeV2Error(w, &v2Error{status: http.StatusBadRequest, code: "invalid_params", message: "video_size must be positive"})
+			return
+		}
+	case "PULL_FROM_URL":
+	default:
+		writeV2Error(w, &v2Error{status: http.StatusBadRequest, code: "invalid_params", message: "unsupported source"})
+		return
+	}
+
+	p := &publish{
+		id:     randomValue("v_pub_"),
+		openID: token.openID,
+		direct: direct,
+		title:  req.PostInfo.Title,
+		status: publishStatusProcessingUpload,
+	}
+	s.publishes[p.id] = p
+
+	data := map[string]interface{}{"publish_id": p.id}
+
+	if req.SourceInfo.Source == "FILE_UPLOAD" {
+		p.size = req.SourceInfo.VideoSize
+		data["upload_url"] = fmt.Sprintf("%s/upload/?upload_id=%s", requestBaseURL(r), p.id)
+	} else {
+		s.completePublish(p)
+	}
+
+	writeV2Data(w, data)
+}
+
+// handleContentInit initializes a photo post, pulling the photos from their urls.
+func (s *Server) handleContentInit(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PostInfo struct {
+			Title string `json:"title"`
+		} `json:"post_info"`
+		PostMode string `json:"post_mode"`
+	}
+
+	if verr := decodeV2Request(r, &req); verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	direct := req.PostMode == "DIRECT_POST"
+
+	scope := "video.upload"
+	if direct {
+		scope = "video.publish"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, verr := s.authenticateBearer(r, scope)
+	if verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	p := &publish{id: randomValue("p_pub_"), openID: token.openID, direct: direct, title: req.PostInfo.Title}
+	s.publishes[p.id] = p
+	s.completePublish(p)
+
+	writeV2Data(w, map[string]interface{}{"publish_id": p.id})
+}
+
+// handleUpload receives a chunk of an uploaded video.
+func (s *Server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var start, end, total int64
+	if _, err := fmt.Sscanf(r.Header.Get("Content-Range"), "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		http.Error(w, "invalid Content-Range", http.StatusBadRequest)
+		return
+	}
+
+	n, err := discard(r)
+	if err != nil || n != end-start+1 {
+		http.Error(w, "chunk size does not match Content-Range", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.publishes[r.URL.Query().Get("upload_id")]
+	if !ok || p.size == 0 {
+		http.Error(w, "unknown upload", http.StatusNotFound)
+		return
+	}
+
+	if total != p.size {
+		http.Error(w, "total size does not match the initialized video size", http.StatusBadRequest)
+		return
+	}
+
+	if end+1 > p.uploadedBytes {
+		p.uploadedBytes = end + 1
+	}
+
+	if p.uploadedBytes < p.size {
+		w.WriteHeader(http.StatusPartialContent)
+		return
+	}
+
+	s.completePublish(p)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handlePublishStatus returns the status of a post of the user of the bearer token.
+func (s *Server) handlePublishStatus(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PublishID string `json:"publish_id"`
+	}
+
+	if verr := decodeV2Request(r, &req); verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, verr := s.authenticateBearer(r, "")
+	if verr != nil {
+		writeV2Error(w, verr)
+		return
+	}
+
+	p, ok := s.publishes[req.PublishID]
+	if !ok || p.openID != token.openID {
+		writeV2Error(w, &v2Error{status: http.StatusBadRequest, code: "invalid_publish_id", message: "publish_id does not exist"})
+		return
+	}
+
+	data := map[string]interface{}{
+		"status":         p.status,
+		"uploaded_bytes": p.uploadedBytes,
+	}
+
+	if p.postID != 0 {
+		data["publicaly_available_post_id"] = []int64{p.postID}
+	}
+
+	writeV2Data(w, data)
+}
+
+// completePublish publishes a post whose media was received, directly to the profile of the user
+// or to their inbox. It must be called with mu held.
+func (s *Server) completePublish(p *publish) {
+	if !p.direct {
+		p.status = publishStatusSendToUserInbox
+		return
+	}
+
+	s.nextPostID++
+	p.status = publishStatusComplete
+	p.postID = s.nextPostID
+
+	id := strconv.FormatInt(p.postID, 10)
+	s.videos[p.openID] = append([]Video{{
+		ID:         id,
+		Title:      p.title,
+		ShareURL:   "https://www.tiktok.com/@" + p.openID + "/video/" + id,
+		CreateTime: s.now().Unix(),
+	}}, s.videos[p.openID]...)
+}
+
+// authenticateBearer returns the valid token of the Authorization header, which must hold the
+// scope if set. It must be called with mu held.
+func (s *Server) authenticateBearer(r *http.Request, scope string) (*issuedToken, *v2Error) {
+	token, ok := s.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+	if !ok || token.revoked || !s.now().Before(token.expiry) {
+		return nil, &v2Error{
+			status:  http.StatusUnauthorized,
+			code:    "access_token_invalid",
+			message: "The access token is invalid or not found in the request.",
+		}
+	}
+
+	if scope != "" && !hasScope(token.scopes, scope) {
+		return nil, &v2Error{
+			status:  http.StatusForbidden,
+			code:    "scope_not_authorized",
+			message: "The user did not authorize the scope required for completing this request.",
+		}
+	}
+
+	return token, nil
+}
+
+// authenticateUser returns the user of the bearer token, rejecting client access tokens, which have
+// no user. It must be called with mu held.
+func (s *Server) authenticateUser(r *http.Request, scope string) (*User, *v2Error) {
+	token, verr := s.authenticateBearer(r, scope)
+	if verr != nil {
+		return nil, verr
+	}
+
+	user, ok := s.users[token.openID]
+	if token.openID == "" || !ok {
+		return nil, &v2Error{
+			status:  http.StatusUnauthorized,
+			code:    "access_token_invalid",
+			message: "The access token is invalid or not found in the request.",
+		}
+	}
+
+	return user, nil
+}
+
+func decodeV2Request(r *http.Request, v interface{}) *v2Error {
+	if r.Method != http.MethodPost {
+		return &v2Error{status: http.StatusMethodNotAllowed, code: "invalid_params", message: "method not allowed"}
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return &v2Error{status: http.StatusBadRequest, code: "invalid_params", message: err.Error()}
+	}
+
+	return nil
+}
+
+func writeV2Data(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": data,
+		"error": map[string]interface{}{
+			"code":    "ok",
+			"message": "",
+			"log_id":  randomValue(""),
+		},
+	})
+}
+
+func writeV2Error(w http.ResponseWriter, err *v2Error) {
+	writeError(w, true, err.status, err.code, 0, err.message, "")
+}
+
+// requestBaseURL returns the base url the request was sent to.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// discard reads the body of the request, returning its size.
+func discard(r *http.Request) (int64, error) {
+	return io.Copy(ioutil.Discard, r.Body)
+}
@@ -0,0 +1,207 @@
+package tiktoktest_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/chanioxaris/tiktok-oauth2/tiktoktest"
+	"golang.org/x/oauth2"
+)
+
+// testLoginWithScopes runs the authorization code flow for the first user with the scopes.
+func testLoginWithScopes(t *testing.T, srv *tiktoktest.Server, scopes ...string) *oauth2.Token {
+	t.Helper()
+
+	cfg, err := tiktok.NewConfig("test-client-id", "test-client-secret", "https://example.com/callback", scopes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testLogin(t, srv, cfg, "")
+}
+
+// testListVideos returns the ids of the videos listed by the video list endpoint for the token.
+func testListVideos(t *testing.T, srv *tiktoktest.Server, token *oauth2.Token) []string {
+	t.Helper()
+
+	ids := make([]string, 0)
+	cursor := int64(0)
+
+	for {
+		body, _ := json.Marshal(map[string]interface{}{"cursor": cursor, "max_count": 1})
+
+		req, err := http.NewRequest(http.MethodPost, srv.URL+"/v2/video/list/", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+		req.Header.Set("Content-Type", "application/json")
+
+		response, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		var page struct {
+			Data struct {
+				Videos  []tiktoktest.Video `json:"videos"`
+				Cursor  int64              `json:"cursor"`
+				HasMore bool               `json:"has_more"`
+			} `json:"data"`
+		}
+
+		err = json.NewDecoder(response.Body).Decode(&page)
+		response.Body.Close()
+
+		if err != nil || response.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected response %d, %v", response.StatusCode, err)
+		}
+
+		for _, video := range page.Data.Videos {
+			ids = append(ids, video.ID)
+		}
+
+		if !page.Data.HasMore {
+			return ids
+		}
+
+		cursor = page.Data.Cursor
+	}
+}
+
+func TestServerDirectPostVideo(t *testing.T) {
+	srv, _ := testNewServer(t)
+	ctx := srv.Context(context.Background())
+
+	srv.AddVideo("test-open-id", tiktoktest.Video{ID: "test-video-id"})
+
+	token := testLoginWithScopes(t, srv, "video.publish", "video.list")
+
+	info, err := tiktok.QueryCreatorInfo(ctx, token)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.Nickname != "test-display-name" {
+		t.Fatalf("expected nickname 'test-display-name', but got '%s'", info.Nickname)
+	}
+
+	video := bytes.Repeat([]byte("v"), 1024)
+
+	publishID, err := tiktok.UploadVideo(ctx, token, bytes.NewReader(video), int64(len(video)), &tiktok.UploadOptions{
+		PostInfo: &tiktok.PostInfo{Title: "test-title", PrivacyLevel: "SELF_ONLY"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	status, err := tiktok.WaitForPublish(ctx, token, publishID, nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if status.Status != tiktok.PublishStatusPublishComplete || len(status.PubliclyAvailablePostID) != 1 {
+		t.Fatalf("unexpected publish status %+v", status)
+	}
+
+	if status.UploadedBytes != int64(len(video)) {
+		t.Fatalf("expected %d uploaded bytes, but got %d", len(video), status.UploadedBytes)
+	}
+
+	ids := testListVideos(t, srv, token)
+	if len(ids) != 2 || ids[1] != "test-video-id" {
+		t.Fatalf("expected the new video listed before 'test-video-id', but got %v", ids)
+	}
+}
+
+func TestServerInboxVideo(t *testing.T) {
+	srv, _ := testNewServer(t)
+	ctx := srv.Context(context.Background())
+
+	token := testLoginWithScopes(t, srv, "video.upload", "video.list")
+
+	video := bytes.Repeat([]byte("v"), 1024)
+
+	publishID, err := tiktok.UploadVideo(ctx, token, bytes.NewReader(video), int64(len(video)), nil)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	status, err := tiktok.FetchPublishStatus(ctx, token, publishID)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if status.Status != tiktok.PublishStatusSendToUserInbox {
+		t.Fatalf("expected status '%s', but got '%s'", tiktok.PublishStatusSendToUserInbox, status.Status)
+	}
+
+	if ids := testListVideos(t, srv, token); len(ids) != 0 {
+		t.Fatalf("expected no videos, but got %v", ids)
+	}
+}
+
+func TestServerPostPhotos(t *testing.T) {
+	srv, _ := testNewServer(t)
+	ctx := srv.Context(context.Background())
+
+	token := testLoginWithScopes(t, srv, "video.publish")
+
+	publishID, err := tiktok.PostPhotos(ctx, token, []string{"https://example.com/1.jpg"}, 0, &tiktok.PostInfo{PrivacyLevel: "SELF_ONLY"})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	status, err := tiktok.FetchPublishStatus(ctx, token, publishID)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if status.Status != tiktok.PublishStatusPublishComplete {
+		t.Fatalf("expected status '%s', but got '%s'", tiktok.PublishStatusPublishComplete, status.Status)
+	}
+}
+
+func TestServerV2Errors(t *testing.T) {
+	srv, cfg := testNewServer(t)
+	ctx := srv.Context(context.Background())
+
+	token := testLogin(t, srv, cfg, "")
+
+	_, err := tiktok.QueryCreatorInfo(ctx, token)
+
+	var apiErr *tiktok.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "scope_not_authorized" || apiErr.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected scope_not_authorized error, but got '%v'", err)
+	}
+
+	_, err = tiktok.QueryCreatorInfo(ctx, &oauth2.Token{AccessToken: "invalid-access-token"})
+	if !errors.As(err, &apiErr) || apiErr.Code != "access_token_invalid" || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected access_token_invalid error, but got '%v'", err)
+	}
+
+	// Client access tokens have no user.
+	srv.SetClientScopes("test-client-id", "video.publish")
+
+	clientToken, err := tiktok.ClientCredentialsToken(ctx, "test-client-id", "test-client-secret")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	_, err = tiktok.QueryCreatorInfo(ctx, clientToken)
+	if !errors.As(err, &apiErr) || apiErr.Code != "access_token_invalid" || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected access_token_invalid error, but got '%v'", err)
+	}
+
+	_, err = tiktok.FetchPublishStatus(ctx, token, "unknown-publish-id")
+	if err == nil || !strings.Contains(err.Error(), "publish_id does not exist") {
+		t.Fatalf("expected error to contain '%s', but got '%v'", "publish_id does not exist", err)
+	}
+}
@@ -0,0 +1,429 @@
+package tiktoktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Endpoint names of the server, used by faults, expectations and recorded requests.
+const (
+	EndpointAuthorize      = "authorize"
+	EndpointToken          = "token"
+	EndpointRefresh        = "refresh"
+	EndpointRevoke         = "revoke"
+	EndpointUserInfo       = "userinfo"
+	EndpointClientToken    = "client_token"
+	EndpointUserInfoV2     = "user_info"
+	EndpointVideoList      = "video_list"
+	EndpointVideoQuery     = "video_query"
+	EndpointCreatorInfo    = "creator_info"
+	EndpointVideoInit      = "video_init"
+	EndpointInboxVideoInit = "inbox_video_init"
+	EndpointContentInit    = "content_init"
+	EndpointPublishStatus  = "publish_status"
+	EndpointUpload         = "upload"
+)
+
+// endpointPaths maps the paths served to their endpoint names.
+var endpointPaths = map[string]string{
+	"/platform/oauth/connect/": EndpointAuthorize,
+	"/v2/auth/authorize/":      EndpointAuthorize,
+	"/oauth/access_token/":     EndpointToken,
+	"/oauth/refresh_token/":    EndpointRefresh,
+	"/oauth/revoke/":           EndpointRevoke,
+	"/oauth/userinfo/":         EndpointUserInfo,
+
+	"/v2/oauth/token/":                     EndpointClientToken,
+	"/v2/user/info/":                       EndpointUserInfoV2,
+	"/v2/video/list/":                      EndpointVideoList,
+	"/v2/video/query/":                     EndpointVideoQuery,
+	"/v2/post/publish/creator_info/query/": EndpointCreatorInfo,
+	"/v2/post/publish/video/init/":         EndpointVideoInit,
+	"/v2/post/publish/inbox/video/init/":   EndpointInboxVideoInit,
+	"/v2/post/publish/content/init/":       EndpointContentInit,
+	"/v2/post/publish/status/fetch/":       EndpointPublishStatus,
+	"/upload/":                             EndpointUpload,
+}
+
+// FaultKind is the kind of failure a Fault produces.
+type FaultKind string
+
+// Fault kinds.
+const (
+	// FaultRateLimit responds with status 429 and a rate limit error.
+	FaultRateLimit FaultKind = "rate_limit"
+	// FaultServerError responds with the Fault status, 500 by default, and an internal error.
+	FaultServerError FaultKind = "server_error"
+	// FaultMalformedJSON responds with status 200 and a truncated JSON body.
+	FaultMalformedJSON FaultKind = "malformed_json"
+	// FaultSlow delays the response by the Fault delay, then responds normally.
+	FaultSlow FaultKind = "slow"
+	// FaultExpiredRefreshToken responds with an expired refresh token error.
+	FaultExpiredRefreshToken FaultKind = "expired_refresh_token"
+	// FaultCaptcha responds with an error requiring the user to solve a captcha.
+	FaultCaptcha FaultKind = "captcha"
+)
+
+// Fault makes the server fail some requests of an endpoint.
+type Fault struct {
+	// Endpoint is the name or path of the endpoint, or empty for all endpoints.
+	Endpoint string `json:"endpoint"`
+	// OpenID, if set, restricts the fault to the requests of the user.
+	OpenID string `json:"open_id"`
+	// Call is the 1-based number of the matching request the fault starts at. Zero means the
+	// first one.
+	Call int `json:"call"`
+	// Times is the number of consecutive matching requests failed. Zero means all of them.
+	Times int       `json:"times"`
+	Kind  FaultKind `json:"kind"`
+	// Status is the status code of FaultServerError. Defaults to 500.
+	Status int `json:"status"`
+	// Delay is the delay of FaultSlow, as a duration string such as "2s" in scenario files.
+	Delay time.Duration `json:"-"`
+
+	seen  int
+	fired int
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding the delay from a duration string.
+func (f *Fault) UnmarshalJSON(data []byte) error {
+	type fault Fault
+
+	var v struct {
+		*fault
+		Delay string `json:"delay"`
+	}
+
+	v.fault = (*fault)(f)
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	if v.Delay != "" {
+		delay, err := time.ParseDuration(v.Delay)
+		if err != nil {
+			return err
+		}
+
+		f.Delay = delay
+	}
+
+	return nil
+}
+
+// Expectation is the number of requests an endpoint is expected to receive, checked by Verify.
+type Expectation struct {
+	// Endpoint is the name or path of the endpoint.
+	Endpoint string `json:"endpoint"`
+	// OpenID, if set, only counts the requests of the user.
+	OpenID string `json:"open_id"`
+	Calls  int    `json:"calls"`
+}
+
+// Scenario is a set of faults and expectations, usually loaded from a JSON file.
+type Scenario struct {
+	Faults []Fault       `json:"faults"`
+	Expect []Expectation `json:"expect"`
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Endpoint string
+	Method   string
+	Path     string
+	// Form holds the query and form parameters of the request.
+	Form   url.Values
+	OpenID string
+	Time   time.Time
+	// Fault is the kind of the fault the request failed with, if any.
+	Fault FaultKind
+}
+
+// LoadScenarioFile reads a scenario from a JSON file.
+func LoadScenarioFile(path string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("tiktoktest: LoadScenarioFile: %w", err)
+	}
+
+	var scenario Scenario
+	if err = json.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("tiktoktest: LoadScenarioFile: %w", err)
+	}
+
+	return &scenario, nil
+}
+
+// AddFault schedules a fault.
+func (s *Server) AddFault(fault Fault) error {
+	switch fault.Kind {
+	case FaultRateLimit, FaultServerError, FaultMalformedJSON, FaultSlow, FaultExpiredRefreshToken, FaultCaptcha:
+	default:
+		return fmt.Errorf("tiktoktest: AddFault: unknown fault kind %q", fault.Kind)
+	}
+
+	fault.Endpoint = endpointName(fault.Endpoint)
+	fault.seen, fault.fired = 0, 0
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = append(s.faults, &fault)
+
+	return nil
+}
+
+// LoadScenario schedules the faults of the scenario and adds its expectations.
+func (s *Server) LoadScenario(scenario *Scenario) error {
+	for _, fault := range scenario.Faults {
+		if err := s.AddFault(fault); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, expectation := range scenario.Expect {
+		expectation.Endpoint = endpointName(expectation.Endpoint)
+		s.expectations = append(s.expectations, expectation)
+	}
+
+	return nil
+}
+
+// ClearFaults removes all the scheduled faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.faults = nil
+}
+
+// Requests returns the requests received by the endpoint, or all of them when endpoint is empty, in
+// the order they were received.
+func (s *Server) Requests(endpoint string) []Request {
+	endpoint = endpointName(endpoint)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	requests := make([]Request, 0)
+	for _, r := range s.requests {
+		if endpoint == "" || r.Endpoint == endpoint {
+			requests = append(requests, r)
+		}
+	}
+
+	return requests
+}
+
+// Verify checks that the endpoints received the number of requests of the expectations.
+func (s *Server) Verify() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var failures []string
+
+	for _, expectation := range s.expectations {
+		calls := 0
+		for _, r := range s.requests {
+			if r.Endpoint == expectation.Endpoint && (expectation.OpenID == "" || r.OpenID == expectation.OpenID) {
+				calls++
+			}
+		}
+
+		if calls != expectation.Calls {
+			failures = append(failures, fmt.Sprintf("%s: expected %d calls, but got %d", expectation.Endpoint, expectation.Calls, calls))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("tiktoktest: Verify: %s", strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// recordRequest keeps the request, up to MaxRequests. It must be called with mu held.
+func (s *Server) recordRequest(req Request) {
+	if s.MaxRequests < 0 {
+		return
+	}
+
+	s.requests = append(s.requests, req)
+
+	if s.MaxRequests > 0 && len(s.requests) > s.MaxRequests {
+		n := copy(s.requests, s.requests[len(s.requests)-s.MaxRequests:])
+		s.requests = s.requests[:n]
+	}
+}
+
+// serveWithFaults records the request and fails it if a fault matches, or serves it otherwise.
+func (s *Server) serveWithFaults(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	endpoint := endpointName(r.URL.Path)
+
+	s.mu.Lock()
+
+	req := Request{
+		Endpoint: endpoint,
+		Method:   r.Method,
+		Path:     r.URL.Path,
+		Form:     cloneValues(r.Form),
+		OpenID:   s.requestOpenID(r),
+		Time:     s.now(),
+	}
+
+	fault := s.matchFault(req)
+	if fault != nil {
+		req.Fault = fault.Kind
+	}
+
+	s.recordRequest(req)
+
+	var f Fault
+	if fault != nil {
+		f = *fault
+	}
+
+	s.mu.Unlock()
+
+	if fault == nil {
+		s.mux.ServeHTTP(w, r)
+		return
+	}
+
+	v2 := strings.HasPrefix(r.URL.Path, "/v2/")
+
+	switch f.Kind {
+	case FaultSlow:
+		timer := time.NewTimer(f.Delay)
+		select {
+		case <-r.Context().Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.mux.ServeHTTP(w, r)
+	case FaultRateLimit:
+		writeError(w, v2, http.StatusTooManyRequests, "rate_limit_exceeded", ErrorCodeRateLimit, "Rate limit exceeded", "")
+	case FaultServerError:
+		status := f.Status
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+
+		writeError(w, v2, status, "internal_error", ErrorCodeInternal, "Internal server error", "")
+	case FaultMalformedJSON:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"open_id":`))
+	case FaultExpiredRefreshToken:
+		writeError(w, v2, http.StatusOK, "invalid_grant", ErrorCodeRefreshTokenExpired, "Refresh token is expired", "")
+	case FaultCaptcha:
+		writeError(w, v2, http.StatusOK, "captcha_required", ErrorCodeCaptcha, "Captcha verification required", "test-captcha")
+	}
+}
+
+// matchFault returns the first fault firing for the request, if any. It must be called with mu
+// held.
+func (s *Server) matchFault(req Request) *Fault {
+	for _, fault := range s.faults {
+		if fault.Endpoint != "" && fault.Endpoint != req.Endpoint {
+			continue
+		}
+
+		if fault.OpenID != "" && fault.OpenID != req.OpenID {
+			continue
+		}
+
+		fault.seen++
+
+		if fault.seen < fault.Call || (fault.Times > 0 && fault.fired >= fault.Times) {
+			continue
+		}
+
+		fault.fired++
+
+		return fault
+	}
+
+	return nil
+}
+
+// requestOpenID returns the open id of the user a request is made for, from its parameters or the
+// code or token it carries. It must be called with mu held.
+func (s *Server) requestOpenID(r *http.Request) string {
+	if openID := r.Form.Get("open_id"); openID != "" {
+		return openID
+	}
+
+	if token, ok := s.refreshTokens[r.Form.Get("refresh_token")]; ok {
+		return token.openID
+	}
+
+	if code, ok := s.codes[r.Form.Get("code")]; ok {
+		return code.openID
+	}
+
+	if token, ok := s.accessTokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]; ok {
+		return token.openID
+	}
+
+	return ""
+}
+
+// endpointName returns the endpoint name of a path, or the value unchanged if it is already a
+// name or an unknown path.
+func endpointName(value string) string {
+	if name, ok := endpointPaths[value]; ok {
+		return name
+	}
+
+	return value
+}
+
+// writeError writes an error in the format of the v2 API or of the OAuth endpoints.
+func writeError(w http.ResponseWriter, v2 bool, status int, code string, legacyCode int, message, captcha string) {
+	if v2 {
+		writeJSON(w, status, map[string]interface{}{
+			"data": map[string]interface{}{},
+			"error": map[string]interface{}{
+				"code":    code,
+				"message": message,
+				"log_id":  randomValue(""),
+			},
+		})
+
+		return
+	}
+
+	writeJSON(w, status, map[string]interface{}{
+		"data": map[string]interface{}{
+			"captcha":     captcha,
+			"desc_url":    "",
+			"description": message,
+			"error_code":  legacyCode,
+		},
+		"message": "error",
+	})
+}
+
+func cloneValues(values url.Values) url.Values {
+	c := make(url.Values, len(values))
+	for key, value := range values {
+		c[key] = append([]string(nil), value...)
+	}
+
+	return c
+}
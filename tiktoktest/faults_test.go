@@ -0,0 +1,199 @@
+package tiktoktest_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/chanioxaris/tiktok-oauth2/tiktoktest"
+)
+
+func TestServerFaults(t *testing.T) {
+	tests := []struct {
+		name          string
+		fault         tiktoktest.Fault
+		expectedError string
+	}{
+		{
+			name:          "Rate limit",
+			fault:         tiktoktest.Fault{Endpoint: tiktoktest.EndpointUserInfo, Kind: tiktoktest.FaultRateLimit},
+			expectedError: "Rate limit exceeded [10004]",
+		},
+		{
+			name:          "Server error",
+			fault:         tiktoktest.Fault{Endpoint: tiktoktest.EndpointUserInfo, Kind: tiktoktest.FaultServerError, Status: 503},
+			expectedError: "Internal server error [10005]",
+		},
+		{
+			name:          "Malformed JSON",
+			fault:         tiktoktest.Fault{Endpoint: "/oauth/userinfo/", Kind: tiktoktest.FaultMalformedJSON},
+			expectedError: "unexpected end of JSON input",
+		},
+		{
+			name:          "Captcha",
+			fault:         tiktoktest.Fault{Endpoint: tiktoktest.EndpointUserInfo, Kind: tiktoktest.FaultCaptcha},
+			expectedError: "Captcha verification required [10011]",
+		},
+		{
+			name:          "Slow",
+			fault:         tiktoktest.Fault{Endpoint: tiktoktest.EndpointUserInfo, Kind: tiktoktest.FaultSlow, Delay: time.Second},
+			expectedError: "context deadline exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			srv, cfg := testNewServer(t)
+			token := testLogin(t, srv, cfg, "")
+
+			if err := srv.AddFault(tt.fault); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithTimeout(srv.Context(context.Background()), time.Millisecond*50)
+			defer cancel()
+
+			_, err := tiktok.RetrieveUserInfo(ctx, token)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.expectedError, err)
+			}
+
+			requests := srv.Requests(tiktoktest.EndpointUserInfo)
+			if len(requests) != 1 || requests[0].Fault != tt.fault.Kind || requests[0].OpenID != "test-open-id" {
+				t.Fatalf("expected a single request failed with '%s', but got %+v", tt.fault.Kind, requests)
+			}
+		})
+	}
+}
+
+func TestServerFaultSchedule(t *testing.T) {
+	srv, cfg := testNewServer(t)
+	ctx := srv.Context(context.Background())
+
+	token := testLogin(t, srv, cfg, "")
+	other := testLogin(t, srv, cfg, "other-open-id")
+
+	// Only the second and third requests of the user fail.
+	err := srv.AddFault(tiktoktest.Fault{Endpoint: tiktoktest.EndpointUserInfo, OpenID: "test-open-id", Call: 2, Times: 2, Kind: tiktoktest.FaultServerError})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var results []string
+	for i := 0; i < 4; i++ {
+		if _, err = tiktok.RetrieveUserInfo(ctx, token); err != nil {
+			results = append(results, "failed")
+		} else {
+			results = append(results, "ok")
+		}
+
+		if _, err = tiktok.RetrieveUserInfo(ctx, other); err != nil {
+			t.Fatalf("unexpected error for other user %v", err)
+		}
+	}
+
+	if got := strings.Join(results, ","); got != "ok,failed,failed,ok" {
+		t.Fatalf("expected results 'ok,failed,failed,ok', but got '%s'", got)
+	}
+}
+
+func TestServerMaxRequests(t *testing.T) {
+	srv, cfg := testNewServer(t)
+	srv.MaxRequests = 2
+
+	token := testLogin(t, srv, cfg, "")
+	ctx := srv.Context(context.Background())
+
+	for i := 0; i < 3; i++ {
+		if _, err := tiktok.RetrieveUserInfo(ctx, token); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	requests := srv.Requests("")
+	if len(requests) != 2 || requests[0].Endpoint != tiktoktest.EndpointUserInfo || requests[1].Endpoint != tiktoktest.EndpointUserInfo {
+		t.Fatalf("expected the last 2 user info requests, but got %+v", requests)
+	}
+
+	srv.MaxRequests = -1
+
+	if _, err := tiktok.RetrieveUserInfo(ctx, token); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if requests = srv.Requests(""); len(requests) != 2 {
+		t.Fatalf("expected no request to be recorded, but got %d requests", len(requests))
+	}
+}
+
+func TestServerExpiredRefreshTokenFault(t *testing.T) {
+	srv, cfg := testNewServer(t)
+	token := testLogin(t, srv, cfg, "")
+
+	if err := srv.AddFault(tiktoktest.Fault{Endpoint: tiktoktest.EndpointRefresh, Kind: tiktoktest.FaultExpiredRefreshToken}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := tiktok.RefreshToken(srv.Context(context.Background()), cfg.ClientID, token.RefreshToken)
+
+	expectedError := "Refresh token is expired [10010]"
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("expected error to contain '%s', but got '%v'", expectedError, err)
+	}
+}
+
+func TestServerScenarioFile(t *testing.T) {
+	srv, cfg := testNewServer(t)
+
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	scenario := `{
+		"faults": [{"endpoint": "refresh", "call": 1, "times": 1, "kind": "rate_limit"}],
+		"expect": [{"endpoint": "token", "calls": 1}, {"endpoint": "refresh", "calls": 2, "open_id": "test-open-id"}]
+	}`
+
+	if err := ioutil.WriteFile(path, []byte(scenario), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := tiktoktest.LoadScenarioFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err = srv.LoadScenario(loaded); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	token := testLogin(t, srv, cfg, "")
+	ctx := srv.Context(context.Background())
+
+	if _, err = tiktok.RefreshToken(ctx, cfg.ClientID, token.RefreshToken); err == nil {
+		t.Fatal("expected the first refresh to be rate limited")
+	}
+
+	if err = srv.Verify(); err == nil || !strings.Contains(err.Error(), "refresh: expected 2 calls, but got 1") {
+		t.Fatalf("expected verification to fail, but got '%v'", err)
+	}
+
+	if _, err = tiktok.RefreshToken(ctx, cfg.ClientID, token.RefreshToken); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if err = srv.Verify(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestServerAddFaultInvalidKind(t *testing.T) {
+	srv, _ := testNewServer(t)
+
+	err := srv.AddFault(tiktoktest.Fault{Kind: "unknown"})
+	if err == nil || !strings.Contains(err.Error(), `unknown fault kind "unknown"`) {
+		t.Fatalf("expected error to contain 'unknown fault kind', but got '%v'", err)
+	}
+}
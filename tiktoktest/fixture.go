@@ -0,0 +1,78 @@
+package tiktoktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// Fixture seeds a server with apps, users and their videos.
+type Fixture struct {
+	Clients []FixtureClient `json:"clients"`
+	Users   []FixtureUser   `json:"users"`
+}
+
+// FixtureClient is an app of a Fixture.
+type FixtureClient struct {
+	ClientKey    string `json:"client_key"`
+	ClientSecret string `json:"client_secret"`
+	// RedirectURL is the redirect url of the app, or empty to allow any.
+	RedirectURL string `json:"redirect_url"`
+	// Scopes restricts the scopes granted to the app, or is empty to grant all the requested ones.
+	Scopes []string `json:"scopes"`
+}
+
+// FixtureUser is a user of a Fixture along with their videos, most recent first.
+type FixtureUser struct {
+	User
+	Videos []Video `json:"videos"`
+}
+
+// LoadFixtureFile reads a JSON fixture from the file at path.
+func LoadFixtureFile(path string) (*Fixture, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("tiktoktest: LoadFixtureFile: %w", err)
+	}
+
+	var fixture Fixture
+	if err = json.Unmarshal(data, &fixture); err != nil {
+		return nil, fmt.Errorf("tiktoktest: LoadFixtureFile: %w", err)
+	}
+
+	return &fixture, nil
+}
+
+// LoadFixture adds the apps, users and videos of the fixture to the server.
+func (s *Server) LoadFixture(fixture *Fixture) error {
+	for _, c := range fixture.Clients {
+		if c.ClientKey == "" || c.ClientSecret == "" {
+			return fmt.Errorf("tiktoktest: LoadFixture: client key and secret cannot be empty")
+		}
+	}
+
+	for _, u := range fixture.Users {
+		if u.OpenID == "" {
+			return fmt.Errorf("tiktoktest: LoadFixture: user open id cannot be empty")
+		}
+	}
+
+	for _, c := range fixture.Clients {
+		s.AddClient(c.ClientKey, c.ClientSecret, c.RedirectURL)
+
+		if len(c.Scopes) > 0 {
+			s.SetClientScopes(c.ClientKey, c.Scopes...)
+		}
+	}
+
+	for _, u := range fixture.Users {
+		s.AddUser(u.User)
+
+		// AddVideo prepends, so the videos are added oldest first to keep their order.
+		for i := len(u.Videos) - 1; i >= 0; i-- {
+			s.AddVideo(u.OpenID, u.Videos[i])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package tiktoktest_test
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/chanioxaris/tiktok-oauth2/tiktoktest"
+)
+
+func TestServerLoadFixture(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fixture.json")
+
+	fixture := `{
+		"clients": [{"client_key": "test-client-id", "client_secret": "test-client-secret", "scopes": ["user.info.basic", "video.list"]}],
+		"users": [{"open_id": "test-open-id", "display_name": "test-display-name", "videos": [{"id": "test-video-2"}, {"id": "test-video-1"}]}]
+	}`
+
+	if err := ioutil.WriteFile(path, []byte(fixture), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := tiktoktest.LoadFixtureFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	srv := tiktoktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	if err = srv.LoadFixture(f); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	token := testLoginWithScopes(t, srv, "user.info.basic", "video.list", "video.publish")
+
+	scope, err := tiktok.ScopeFromToken(token)
+	if err != nil || scope != "user.info.basic,video.list" {
+		t.Fatalf("expected scope 'user.info.basic,video.list', but got '%s', %v", scope, err)
+	}
+
+	info, err := tiktok.RetrieveUserInfo(srv.Context(context.Background()), token)
+	if err != nil || info.DisplayName != "test-display-name" {
+		t.Fatalf("unexpected user info %+v, %v", info, err)
+	}
+
+	ids := testListVideos(t, srv, token)
+	if strings.Join(ids, ",") != "test-video-2,test-video-1" {
+		t.Fatalf("expected videos 'test-video-2,test-video-1', but got %v", ids)
+	}
+}
+
+func TestServerLoadFixtureInvalid(t *testing.T) {
+	srv := tiktoktest.NewHandler()
+
+	err := srv.LoadFixture(&tiktoktest.Fixture{Users: []tiktoktest.FixtureUser{{}}})
+	if err == nil || !strings.Contains(err.Error(), "user open id cannot be empty") {
+		t.Fatalf("expected error to contain '%s', but got '%v'", "user open id cannot be empty", err)
+	}
+
+	_, err = tiktoktest.LoadFixtureFile(filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil {
+		t.Fatal("expected error, but got nil")
+	}
+}
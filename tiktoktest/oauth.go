@@ -0,0 +1,369 @@
+package tiktoktest
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Error codes of the TikTok OAuth endpoints returned by the server.
+const (
+	ErrorCodeParams              = 10002
+	ErrorCodeClient              = 10003
+	ErrorCodeRateLimit           = 10004
+	ErrorCodeInternal            = 10005
+	ErrorCodeCodeExpired         = 10007
+	ErrorCodeAccessTokenInvalid  = 10008
+	ErrorCodeScopeNotAuthorized  = 10009
+	ErrorCodeRefreshTokenExpired = 10010
+	ErrorCodeCaptcha             = 10011
+)
+
+// legacyError is an error of the TikTok OAuth endpoints.
+type legacyError struct {
+	code        int
+	description string
+}
+
+func (s *Server) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/platform/oauth/connect/", s.handleAuthorize)
+	mux.HandleFunc("/v2/auth/authorize/", s.handleAuthorize)
+	mux.HandleFunc("/oauth/access_token/", s.handleToken)
+	mux.HandleFunc("/oauth/refresh_token/", s.handleRefresh)
+	mux.HandleFunc("/oauth/revoke/", s.handleRevoke)
+	mux.HandleFunc("/oauth/userinfo/", s.handleUserInfo)
+	mux.HandleFunc("/v2/oauth/token/", s.handleClientToken)
+	mux.HandleFunc("/v2/user/info/", s.handleUserInfoV2)
+	mux.HandleFunc("/v2/video/list/", s.handleVideoList)
+	mux.HandleFunc("/v2/video/query/", s.handleVideoQuery)
+	mux.HandleFunc("/v2/post/publish/creator_info/query/", s.handleCreatorInfo)
+	mux.HandleFunc("/v2/post/publish/video/init/", s.handleVideoInit)
+	mux.HandleFunc("/v2/post/publish/inbox/video/init/", s.handleVideoInit)
+	mux.HandleFunc("/v2/post/publish/content/init/", s.handleContentInit)
+	mux.HandleFunc("/v2/post/publish/status/fetch/", s.handlePublishStatus)
+	mux.HandleFunc("/upload/", s.handleUpload)
+
+	return mux
+}
+
+// handleAuthorize approves the authorization request on behalf of the selected user and redirects
+// to the redirect url of the app with a new code.
+func (s *Server) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// TikTok names the client id client_key, while oauth2.Config.AuthCodeURL sends client_id.
+	clientKey := q.Get("client_key")
+	if clientKey == "" {
+		clientKey = q.Get("client_id")
+	}
+
+	c, ok := s.clients[clientKey]
+	if !ok {
+		http.Error(w, "unknown client_key", http.StatusBadRequest)
+		return
+	}
+
+	if q.Get("response_type") != "code" {
+		http.Error(w, "response_type must be code", http.StatusBadRequest)
+		return
+	}
+
+	redirectURL := q.Get("redirect_uri")
+	if c.redirectURL != "" && redirectURL != c.redirectURL {
+		http.Error(w, "redirect_uri does not match the app", http.StatusBadRequest)
+		return
+	}
+
+	openID := q.Get("open_id")
+	if openID == "" && len(s.userOrder) > 0 {
+		openID = s.userOrder[0]
+	}
+
+	if _, ok = s.users[openID]; !ok {
+		http.Error(w, "unknown user", http.StatusBadRequest)
+		return
+	}
+
+	scopes := splitScopes(q.Get("scope"))
+	if c.scopes != nil {
+		granted := make([]string, 0, len(scopes))
+		for _, scope := range scopes {
+			if hasScope(c.scopes, scope) {
+				granted = append(granted, scope)
+			}
+		}
+
+		scopes = granted
+	}
+
+	s.pruneExpired()
+
+	code := randomValue("code.")
+	s.codes[code] = &authCode{
+		clientKey:   c.key,
+		openID:      openID,
+		scopes:      scopes,
+		redirectURL: redirectURL,
+		expiry:      s.now().Add(s.CodeTTL),
+	}
+
+	target, err := url.Parse(redirectURL)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	rq := target.Query()
+	rq.Set("code", code)
+	rq.Set("scopes", strings.Join(scopes, ","))
+	if state := q.Get("state"); state != "" {
+		rq.Set("state", state)
+	}
+	target.RawQuery = rq.Encode()
+
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// handleToken exchanges an authorization code for an access token.
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.FormValue("grant_type") != "authorization_code" {
+		writeLegacyError(w, &legacyError{code: ErrorCodeParams, description: "grant_type must be authorization_code"})
+		return
+	}
+
+	c, lerr := s.authenticateClient(r.FormValue("client_key"), r.FormValue("client_secret"))
+	if lerr != nil {
+		writeLegacyError(w, lerr)
+		return
+	}
+
+	code, ok := s.codes[r.FormValue("code")]
+	if !ok || code.clientKey != c.key {
+		writeLegacyError(w, &legacyError{code: ErrorCodeParams, description: "Authorization code is invalid"})
+		return
+	}
+
+	if code.used || !s.now().Before(code.expiry) {
+		writeLegacyError(w, &legacyError{code: ErrorCodeCodeExpired, description: "Authorization code is expired"})
+		return
+	}
+
+	code.used = true
+
+	s.writeToken(w, s.issueToken(c.key, code.openID, code.scopes, ""))
+}
+
+// handleRefresh issues a new access token for a refresh token, invalidating the previous one.
+func (s *Server) handleRefresh(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.FormValue("grant_type") != "refresh_token" {
+		writeLegacyError(w, &legacyError{code: ErrorCodeParams, description: "grant_type must be refresh_token"})
+		return
+	}
+
+	token, ok := s.refreshTokens[r.FormValue("refresh_token")]
+	if !ok || token.clientKey != r.FormValue("client_key") {
+		writeLegacyError(w, &legacyError{code: ErrorCodeParams, description: "Refresh token is invalid"})
+		return
+	}
+
+	if token.revoked || !s.now().Before(token.refreshExpiry) {
+		writeLegacyError(w, &legacyError{code: ErrorCodeRefreshTokenExpired, description: "Refresh token is expired"})
+		return
+	}
+
+	token.revoked = true
+
+	s.writeToken(w, s.issueToken(token.clientKey, token.openID, token.scopes, token.refreshToken))
+}
+
+// handleRevoke revokes all the tokens of the user of an access token.
+func (s *Server) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, lerr := s.authenticateToken(r.FormValue("access_token"), r.FormValue("open_id"))
+	if lerr != nil {
+		writeLegacyError(w, lerr)
+		return
+	}
+
+	for _, issued := range s.accessTokens {
+		if issued.clientKey == token.clientKey && issued.openID == token.openID {
+			issued.revoked = true
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"captcha":     "",
+			"desc_url":    "",
+			"description": "",
+			"error_code":  0,
+			"log_id":      randomValue(""),
+		},
+		"message": "success",
+	})
+}
+
+// handleUserInfo returns the information of the user of an access token with the user.info.basic
+// scope.
+func (s *Server) handleUserInfo(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, lerr := s.authenticateToken(r.FormValue("access_token"), r.FormValue("open_id"))
+	if lerr != nil {
+		writeLegacyError(w, lerr)
+		return
+	}
+
+	if !hasScope(token.scopes, "user.info.basic") {
+		writeLegacyError(w, &legacyError{code: ErrorCodeScopeNotAuthorized, description: "Scope user.info.basic is not authorized"})
+		return
+	}
+
+	user := s.users[token.openID]
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"open_id":       user.OpenID,
+			"union_id":      user.UnionID,
+			"avatar":        user.Avatar,
+			"avatar_larger": user.AvatarLarger,
+			"display_name":  user.DisplayName,
+		},
+		"message": "success",
+	})
+}
+
+// authenticateClient returns the app of the credentials. It must be called with mu held.
+func (s *Server) authenticateClient(clientKey, clientSecret string) (*client, *legacyError) {
+	c, ok := s.clients[clientKey]
+	if !ok || c.secret != clientSecret {
+		return nil, &legacyError{code: ErrorCodeClient, description: "Client key or secret is invalid"}
+	}
+
+	return c, nil
+}
+
+// authenticateToken returns the valid token of the access token and open id. It must be called
+// with mu held.
+func (s *Server) authenticateToken(accessToken, openID string) (*issuedToken, *legacyError) {
+	token, ok := s.accessTokens[accessToken]
+	if !ok || token.openID != openID || token.revoked || !s.now().Before(token.expiry) {
+		return nil, &legacyError{code: ErrorCodeAccessTokenInvalid, description: "Access token is invalid or expired"}
+	}
+
+	return token, nil
+}
+
+// issueToken issues a new access token, reusing the refresh token if set. It must be called with
+// mu held.
+func (s *Server) issueToken(clientKey, openID string, scopes []string, refreshToken string) *issuedToken {
+	s.pruneExpired()
+
+	now := s.now()
+
+	token := &issuedToken{
+		accessToken:   randomValue("act."),
+		refreshToken:  refreshToken,
+		clientKey:     clientKey,
+		openID:        openID,
+		scopes:        scopes,
+		expiry:        now.Add(s.AccessTokenTTL),
+		refreshExpiry: now.Add(s.RefreshTokenTTL),
+	}
+
+	if refreshToken == "" {
+		token.refreshToken = randomValue("rft.")
+	} else {
+		token.refreshExpiry = s.refreshTokens[refreshToken].refreshExpiry
+	}
+
+	s.accessTokens[token.accessToken] = token
+	s.refreshTokens[token.refreshToken] = token
+
+	return token
+}
+
+// pruneExpired removes the expired codes, the revoked or expired access tokens and the expired
+// refresh tokens, so that a long running server does not keep every code and token it issued. It
+// must be called with mu held.
+func (s *Server) pruneExpired() {
+	now := s.now()
+
+	for value, code := range s.codes {
+		if !now.Before(code.expiry) {
+			delete(s.codes, value)
+		}
+	}
+
+	for value, token := range s.accessTokens {
+		// Client access tokens have no refresh token and end with their own expiry.
+		end := token.refreshExpiry
+		if token.expiry.After(end) {
+			end = token.expiry
+		}
+
+		if token.revoked || !now.Before(end) {
+			delete(s.accessTokens, value)
+		}
+	}
+
+	for value, token := range s.refreshTokens {
+		if !now.Before(token.refreshExpiry) {
+			delete(s.refreshTokens, value)
+		}
+	}
+}
+
+// writeToken writes the token response of a token. It must be called with mu held.
+func (s *Server) writeToken(w http.ResponseWriter, token *issuedToken) {
+	now := s.now()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"data": map[string]interface{}{
+			"open_id":            token.openID,
+			"scope":              strings.Join(token.scopes, ","),
+			"access_token":       token.accessToken,
+			"expires_in":         int64(token.expiry.Sub(now).Seconds()),
+			"refresh_token":      token.refreshToken,
+			"refresh_expires_in": int64(token.refreshExpiry.Sub(now).Seconds()),
+		},
+		"message": "success",
+	})
+}
+
+func writeLegacyError(w http.ResponseWriter, err *legacyError) {
+	writeError(w, false, http.StatusOK, "", err.code, err.description, "")
+}
+
+func splitScopes(value string) []string {
+	scopes := make([]string, 0)
+	// TikTok separates scopes with commas, while oauth2.Config.AuthCodeURL uses spaces.
+	for _, scope := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		scopes = append(scopes, scope)
+	}
+
+	return scopes
+}
+
+func hasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,290 @@
+// Package tiktoktest provides a fake TikTok server for end-to-end tests of code using the tiktok
+// package, without reaching TikTok.
+package tiktoktest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// tiktokHosts are the hosts of the TikTok APIs routed to the fake server by Server.Client.
+var tiktokHosts = map[string]bool{
+	"open-api.tiktok.com":         true,
+	"open.tiktokapis.com":         true,
+	"open-upload.tiktokapis.com":  true,
+	"www.tiktok.com":              true,
+	"open-api-test.tiktok.com":    true,
+	"open-sandbox.tiktokapis.com": true,
+}
+
+// User is a TikTok user known to the fake server.
+type User struct {
+	OpenID       string `json:"open_id"`
+	UnionID      string `json:"union_id"`
+	DisplayName  string `json:"display_name"`
+	Avatar       string `json:"avatar"`
+	AvatarLarger string `json:"avatar_larger"`
+}
+
+// Server is a fake TikTok server implementing the authorize, token, refresh, revoke and user info
+// endpoints, along with the v2 user info, video and posting endpoints, keeping track of the codes
+// and tokens it issues and of the videos of its users. Expired codes and tokens are removed
+// whenever a new one is issued, after which they are reported as invalid rather than expired.
+type Server struct {
+	// URL is the base url of the server.
+	URL string
+
+	// CodeTTL is the lifetime of authorization codes. Defaults to 10 minutes.
+	CodeTTL time.Duration
+	// AccessTokenTTL is the lifetime of access tokens. Defaults to 24 hours.
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is the lifetime of refresh tokens. Defaults to 365 days.
+	RefreshTokenTTL time.Duration
+	// MaxRequests caps the number of requests kept for Requests and Verify, dropping the oldest
+	// ones. Zero keeps every request and a negative value keeps none.
+	MaxRequests int
+
+	srv *httptest.Server
+	mux *http.ServeMux
+
+	mu            sync.Mutex
+	offset        time.Duration
+	clients       map[string]*client
+	users         map[string]*User
+	userOrder     []string
+	codes         map[string]*authCode
+	accessTokens  map[string]*issuedToken
+	refreshTokens map[string]*issuedToken
+	videos        map[string][]Video
+	publishes     map[string]*publish
+	nextPostID    int64
+	faults        []*Fault
+	expectations  []Expectation
+	requests      []Request
+}
+
+type client struct {
+	key         string
+	secret      string
+	redirectURL string
+	scopes      []string
+}
+
+type authCode struct {
+	clientKey   string
+	openID      string
+	scopes      []string
+	redirectURL string
+	expiry      time.Time
+	used        bool
+}
+
+type issuedToken struct {
+	accessToken   string
+	refreshToken  string
+	clientKey     string
+	openID        string
+	scopes        []string
+	expiry        time.Time
+	refreshExpiry time.Time
+	revoked       bool
+}
+
+// NewServer starts and returns a new Server. The caller should call Close when finished, to shut
+// it down.
+func NewServer() *Server {
+	s := NewHandler()
+	s.srv = httptest.NewServer(s)
+	s.URL = s.srv.URL
+
+	return s
+}
+
+// NewHandler returns a new Server which is not started, to be served as an http.Handler by the
+// caller.
+func NewHandler() *Server {
+	s := &Server{
+		CodeTTL:         time.Minute * 10,
+		AccessTokenTTL:  time.Hour * 24,
+		RefreshTokenTTL: time.Hour * 24 * 365,
+		clients:         make(map[string]*client),
+		users:           make(map[string]*User),
+		codes:           make(map[string]*authCode),
+		accessTokens:    make(map[string]*issuedToken),
+		refreshTokens:   make(map[string]*issuedToken),
+		videos:          make(map[string][]Video),
+		publishes:       make(map[string]*publish),
+		nextPostID:      7300000000000000000,
+	}
+
+	s.mux = s.routes()
+
+	return s
+}
+
+// Close shuts a server started by NewServer down.
+func (s *Server) Close() {
+	if s.srv != nil {
+		s.srv.Close()
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.serveWithFaults(w, r)
+}
+
+// AddClient registers an app allowed to authorize users with the redirect url.
+func (s *Server) AddClient(clientKey, clientSecret, redirectURL string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clients[clientKey] = &client{key: clientKey, secret: clientSecret, redirectURL: redirectURL}
+}
+
+// SetClientScopes restricts the scopes granted to an app to the given ones. By default an app is
+// granted all the scopes it requests.
+func (s *Server) SetClientScopes(clientKey string, scopes ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.clients[clientKey]; ok {
+		c.scopes = scopes
+	}
+}
+
+// AddUser registers a user. The first user added is the one authorizing apps unless another is
+// selected with the open_id parameter of the authorize request.
+func (s *Server) AddUser(user User) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[user.OpenID]; !ok {
+		s.userOrder = append(s.userOrder, user.OpenID)
+	}
+
+	u := user
+	s.users[user.OpenID] = &u
+}
+
+// Advance moves the clock of the server forward, to expire codes and tokens.
+func (s *Server) Advance(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset += d
+}
+
+// Revoked reports whether all the tokens issued to the user are revoked or expired.
+func (s *Server) Revoked(openID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for _, token := range s.accessTokens {
+		if token.openID == openID && !token.revoked && now.Before(token.refreshExpiry) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Client returns an *http.Client sending the requests to the TikTok APIs to a server started by
+// NewServer instead.
+func (s *Server) Client() *http.Client {
+	target, _ := url.Parse(s.srv.URL)
+
+	return &http.Client{Transport: &routingTransport{target: target, base: s.srv.Client().Transport}}
+}
+
+// Context returns a copy of the context carrying Client under the oauth2.HTTPClient key, which
+// makes the tiktok package and the oauth2 package send their requests to the server.
+func (s *Server) Context(ctx context.Context) context.Context {
+	return context.WithValue(ctx, oauth2.HTTPClient, s.Client())
+}
+
+// Authorize approves the authorization url of an oauth2 config, as returned by AuthCodeURL, on
+// behalf of the user, or of the first user added when openID is empty, and returns the redirect url
+// carrying the code and state.
+func (s *Server) Authorize(authCodeURL, openID string) (*url.URL, error) {
+	u, err := url.Parse(authCodeURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if openID != "" {
+		q := u.Query()
+		q.Set("open_id", openID)
+		u.RawQuery = q.Encode()
+	}
+
+	c := s.Client()
+	c.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+
+	response, err := c.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusFound {
+		return nil, fmt.Errorf("tiktoktest: Authorize: unexpected status code %d", response.StatusCode)
+	}
+
+	return response.Location()
+}
+
+// now returns the current time of the server clock. It must be called with mu held.
+func (s *Server) now() time.Time {
+	return time.Now().Add(s.offset)
+}
+
+// routingTransport sends the requests to TikTok hosts to the target server.
+type routingTransport struct {
+	target *url.URL
+	base   http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *routingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !tiktokHosts[req.URL.Host] {
+		return t.base.RoundTrip(req)
+	}
+
+	routed := req.Clone(req.Context())
+	routed.URL.Scheme = t.target.Scheme
+	routed.URL.Host = t.target.Host
+	routed.Host = ""
+
+	return t.base.RoundTrip(routed)
+}
+
+func randomValue(prefix string) string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return prefix + hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_ = json.NewEncoder(w).Encode(body)
+}
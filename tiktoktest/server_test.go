@@ -0,0 +1,245 @@
+package tiktoktest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/chanioxaris/tiktok-oauth2/tiktoktest"
+	"golang.org/x/oauth2"
+)
+
+func testNewServer(t *testing.T) (*tiktoktest.Server, *oauth2.Config) {
+	t.Helper()
+
+	srv := tiktoktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	srv.AddClient("test-client-id", "test-client-secret", "https://example.com/callback")
+	srv.AddUser(tiktoktest.User{OpenID: "test-open-id", UnionID: "test-union-id", DisplayName: "test-display-name"})
+	srv.AddUser(tiktoktest.User{OpenID: "other-open-id", DisplayName: "other-display-name"})
+
+	cfg, err := tiktok.NewConfig("test-client-id", "test-client-secret", "https://example.com/callback", "user.info.basic", "video.list")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return srv, cfg
+}
+
+// testLogin runs the authorization code flow for the user against the server.
+func testLogin(t *testing.T, srv *tiktoktest.Server, cfg *oauth2.Config, openID string) *oauth2.Token {
+	t.Helper()
+
+	redirect, err := srv.Authorize(cfg.AuthCodeURL("test-state"), openID)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if redirect.Query().Get("state") != "test-state" {
+		t.Fatalf("expected state 'test-state', but got '%s'", redirect.Query().Get("state"))
+	}
+
+	token, err := tiktok.ConfigExchange(srv.Context(context.Background()), cfg, redirect.Query().Get("code"))
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	return token
+}
+
+func TestServerLoginFlow(t *testing.T) {
+	srv, cfg := testNewServer(t)
+	ctx := srv.Context(context.Background())
+
+	token := testLogin(t, srv, cfg, "")
+
+	scope, err := tiktok.ScopeFromToken(token)
+	if err != nil || scope != "user.info.basic,video.list" {
+		t.Fatalf("expected scope 'user.info.basic,video.list', but got '%s', %v", scope, err)
+	}
+
+	info, err := tiktok.RetrieveUserInfo(ctx, token)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.OpenID != "test-open-id" || info.DisplayName != "test-display-name" {
+		t.Fatalf("unexpected user info %+v", info)
+	}
+
+	refreshed, err := tiktok.RefreshToken(ctx, cfg.ClientID, token.RefreshToken)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if refreshed.AccessToken == token.AccessToken {
+		t.Fatal("expected a new access token")
+	}
+
+	if _, err = tiktok.RetrieveUserInfo(ctx, token); err == nil {
+		t.Fatal("expected the previous access token to be invalid")
+	}
+
+	if err = tiktok.RevokeAccess(ctx, refreshed); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if !srv.Revoked("test-open-id") {
+		t.Fatal("expected tokens of the user to be revoked")
+	}
+
+	if _, err = tiktok.RetrieveUserInfo(ctx, refreshed); err == nil {
+		t.Fatal("expected the revoked access token to be invalid")
+	}
+}
+
+func TestServerSelectedUser(t *testing.T) {
+	srv, cfg := testNewServer(t)
+
+	token := testLogin(t, srv, cfg, "other-open-id")
+
+	info, err := tiktok.RetrieveUserInfo(srv.Context(context.Background()), token)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if info.DisplayName != "other-display-name" {
+		t.Fatalf("expected display name 'other-display-name', but got '%s'", info.DisplayName)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		run           func(t *testing.T, srv *tiktoktest.Server, cfg *oauth2.Config) error
+		expectedError string
+	}{
+		{
+			name: "Reused code",
+			run: func(t *testing.T, srv *tiktoktest.Server, cfg *oauth2.Config) error {
+				redirect, err := srv.Authorize(cfg.AuthCodeURL("test-state"), "")
+				if err != nil {
+					return err
+				}
+
+				ctx := srv.Context(context.Background())
+				if _, err = tiktok.ConfigExchange(ctx, cfg, redirect.Query().Get("code")); err != nil {
+					return err
+				}
+
+				_, err = tiktok.ConfigExchange(ctx, cfg, redirect.Query().Get("code"))
+				return err
+			},
+			expectedError: "Authorization code is expired [10007]",
+		},
+		{
+			name: "Expired code",
+			run: func(t *testing.T, srv *tiktoktest.Server, cfg *oauth2.Config) error {
+				redirect, err := srv.Authorize(cfg.AuthCodeURL("test-state"), "")
+				if err != nil {
+					return err
+				}
+
+				srv.Advance(time.Hour)
+
+				_, err = tiktok.ConfigExchange(srv.Context(context.Background()), cfg, redirect.Query().Get("code"))
+				return err
+			},
+			expectedError: "Authorization code is expired [10007]",
+		},
+		{
+			name: "Wrong client secret",
+			run: func(t *testing.T, srv *tiktoktest.Server, cfg *oauth2.Config) error {
+				redirect, err := srv.Authorize(cfg.AuthCodeURL("test-state"), "")
+				if err != nil {
+					return err
+				}
+
+				cfg.ClientSecret = "wrong-secret"
+
+				_, err = tiktok.ConfigExchange(srv.Context(context.Background()), cfg, redirect.Query().Get("code"))
+				return err
+			},
+			expectedError: "Client key or secret is invalid [10003]",
+		},
+		{
+			name: "Expired access token",
+			run: func(t *testing.T, srv *tiktoktest.Server, cfg *oauth2.Config) error {
+				token := testLogin(t, srv, cfg, "")
+				srv.Advance(time.Hour * 25)
+
+				_, err := tiktok.RetrieveUserInfo(srv.Context(context.Background()), token)
+				return err
+			},
+			expectedError: "Access token is invalid or expired [10008]",
+		},
+		{
+			name: "Expired refresh token",
+			run: func(t *testing.T, srv *tiktoktest.Server, cfg *oauth2.Config) error {
+				token := testLogin(t, srv, cfg, "")
+				srv.Advance(time.Hour * 24 * 400)
+
+				_, err := tiktok.RefreshToken(srv.Context(context.Background()), cfg.ClientID, token.RefreshToken)
+				return err
+			},
+			expectedError: "Refresh token is expired [10010]",
+		},
+		{
+			name: "Missing scope",
+			run: func(t *testing.T, srv *tiktoktest.Server, cfg *oauth2.Config) error {
+				cfg.Scopes = []string{"video.list"}
+				token := testLogin(t, srv, cfg, "")
+
+				_, err := tiktok.RetrieveUserInfo(srv.Context(context.Background()), token)
+				return err
+			},
+			expectedError: "Scope user.info.basic is not authorized [10009]",
+		},
+		{
+			name: "Unknown redirect url",
+			run: func(t *testing.T, srv *tiktoktest.Server, cfg *oauth2.Config) error {
+				cfg.RedirectURL = "https://attacker.example.com/callback"
+
+				_, err := srv.Authorize(cfg.AuthCodeURL("test-state"), "")
+				return err
+			},
+			expectedError: "unexpected status code 400",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			srv, cfg := testNewServer(t)
+
+			err := tt.run(t, srv, cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestServerPrunesExpiredCodes(t *testing.T) {
+	srv, cfg := testNewServer(t)
+
+	redirect, err := srv.Authorize(cfg.AuthCodeURL("test-state"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.Advance(time.Hour)
+
+	// Issuing another code removes the expired one, which is then unknown to the server.
+	testLogin(t, srv, cfg, "")
+
+	_, err = tiktok.ConfigExchange(srv.Context(context.Background()), cfg, redirect.Query().Get("code"))
+
+	expectedError := "Authorization code is invalid [10002]"
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("expected error to contain '%s', but got '%v'", expectedError, err)
+	}
+}
@@ -0,0 +1,57 @@
+package tiktok
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/oauth2"
+)
+
+// TokenStore persists the oauth2 tokens of TikTok users, keyed by their open id.
+type TokenStore interface {
+	// Token returns the token of the user, or nil if there is none.
+	Token(ctx context.Context, openID string) (*oauth2.Token, error)
+	// SaveToken stores the token of the user, replacing any previous token.
+	SaveToken(ctx context.Context, openID string, token *oauth2.Token) error
+	// DeleteToken removes the token of the user, if any.
+	DeleteToken(ctx context.Context, openID string) error
+}
+
+// MemoryTokenStore is a TokenStore keeping tokens in memory.
+type MemoryTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]*oauth2.Token
+}
+
+// NewMemoryTokenStore returns a new empty MemoryTokenStore.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{tokens: make(map[string]*oauth2.Token)}
+}
+
+// Token implements TokenStore.
+func (s *MemoryTokenStore) Token(_ context.Context, openID string) (*oauth2.Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tokens[openID], nil
+}
+
+// SaveToken implements TokenStore.
+func (s *MemoryTokenStore) SaveToken(_ context.Context, openID string, token *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[openID] = token
+
+	return nil
+}
+
+// DeleteToken implements TokenStore.
+func (s *MemoryTokenStore) DeleteToken(_ context.Context, openID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, openID)
+
+	return nil
+}
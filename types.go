@@ -1,11 +1,40 @@
 package tiktok
 
+import "encoding/json"
+
 const (
 	endpointAuth     = "https://open-api.tiktok.com/platform/oauth/connect/"
 	endpointToken    = "https://open-api.tiktok.com/oauth/access_token/"
 	endpointRefresh  = "https://open-api.tiktok.com/oauth/refresh_token/"
 	endpointRevoke   = "https://open-api.tiktok.com/oauth/revoke/"
 	endpointUserInfo = "https://open-api.tiktok.com/oauth/userinfo/"
+
+	endpointClientToken   = "https://open.tiktokapis.com/v2/oauth/token/"
+	endpointResearchVideo = "https://open.tiktokapis.com/v2/research/video/query/"
+
+	endpointResearchUserInfo      = "https://open.tiktokapis.com/v2/research/user/info/"
+	endpointResearchUserFollowers = "https://open.tiktokapis.com/v2/research/user/followers/"
+	endpointResearchUserFollowing = "https://open.tiktokapis.com/v2/research/user/following/"
+	endpointResearchUserLiked     = "https://open.tiktokapis.com/v2/research/user/liked_videos/"
+	endpointResearchUserPinned    = "https://open.tiktokapis.com/v2/research/user/pinned_videos/"
+	endpointResearchUserReposted  = "https://open.tiktokapis.com/v2/research/user/reposted_videos/"
+	endpointResearchComments      = "https://open.tiktokapis.com/v2/research/video/comment/list/"
+	endpointResearchPlaylist      = "https://open.tiktokapis.com/v2/research/playlist/info/"
+
+	endpointAdQuery         = "https://open.tiktokapis.com/v2/research/adlib/ad/query/"
+	endpointAdDetail        = "https://open.tiktokapis.com/v2/research/adlib/ad/detail/"
+	endpointAdvertiserQuery = "https://open.tiktokapis.com/v2/research/adlib/advertiser/query/"
+
+	endpointDataAdd      = "https://open.tiktokapis.com/v2/user/data/add/"
+	endpointDataStatus   = "https://open.tiktokapis.com/v2/user/data/status/"
+	endpointDataCancel   = "https://open.tiktokapis.com/v2/user/data/cancel/"
+	endpointDataDownload = "https://open.tiktokapis.com/v2/user/data/download/"
+
+	endpointCreatorInfo    = "https://open.tiktokapis.com/v2/post/publish/creator_info/query/"
+	endpointPublishStatus  = "https://open.tiktokapis.com/v2/post/publish/status/fetch/"
+	endpointVideoInit      = "https://open.tiktokapis.com/v2/post/publish/video/init/"
+	endpointInboxVideoInit = "https://open.tiktokapis.com/v2/post/publish/inbox/video/init/"
+	endpointContentInit    = "https://open.tiktokapis.com/v2/post/publish/content/init/"
 )
 
 // UserInfo holds some basic information of a given TikTok user.
@@ -38,6 +67,15 @@ type tokenResponse struct {
 	} `json:"data"`
 }
 
+type clientTokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	ExpiresIn        int64  `json:"expires_in"`
+	TokenType        string `json:"token_type"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+	LogID            string `json:"log_id"`
+}
+
 type revokeResponse struct {
 	Data struct {
 		Captcha     string `json:"captcha"`
@@ -58,3 +96,205 @@ type errorResponse struct {
 	} `json:"data"`
 	Message string `json:"message"`
 }
+
+type publishStatusRequest struct {
+	PublishID string `json:"publish_id"`
+}
+
+type publishStatusData struct {
+	Status                  string  `json:"status"`
+	FailReason              string  `json:"fail_reason"`
+	PubliclyAvailablePostID []int64 `json:"publicaly_available_post_id"`
+	UploadedBytes           int64   `json:"uploaded_bytes"`
+	DownloadedBytes         int64   `json:"downloaded_bytes"`
+}
+
+type videoSourceInfo struct {
+	Source          string `json:"source"`
+	VideoSize       int64  `json:"video_size"`
+	ChunkSize       int64  `json:"chunk_size"`
+	TotalChunkCount int64  `json:"total_chunk_count"`
+}
+
+type videoInitRequest struct {
+	PostInfo   *PostInfo       `json:"post_info,omitempty"`
+	SourceInfo videoSourceInfo `json:"source_info"`
+}
+
+type videoInitData struct {
+	PublishID string `json:"publish_id"`
+	UploadURL string `json:"upload_url"`
+}
+
+type creatorInfoData struct {
+	CreatorAvatarURL        string   `json:"creator_avatar_url"`
+	CreatorUsername         string   `json:"creator_username"`
+	CreatorNickname         string   `json:"creator_nickname"`
+	PrivacyLevelOptions     []string `json:"privacy_level_options"`
+	CommentDisabled         bool     `json:"comment_disabled"`
+	DuetDisabled            bool     `json:"duet_disabled"`
+	StitchDisabled          bool     `json:"stitch_disabled"`
+	MaxVideoPostDurationSec int      `json:"max_video_post_duration_sec"`
+}
+
+type photoSourceInfo struct {
+	Source          string   `json:"source"`
+	PhotoCoverIndex int      `json:"photo_cover_index"`
+	PhotoImages     []string `json:"photo_images"`
+}
+
+type photoInitRequest struct {
+	PostInfo   *PostInfo       `json:"post_info"`
+	SourceInfo photoSourceInfo `json:"source_info"`
+	PostMode   string          `json:"post_mode"`
+	MediaType  string          `json:"media_type"`
+}
+
+type photoInitData struct {
+	PublishID string `json:"publish_id"`
+}
+
+type researchVideoRequest struct {
+	Query     *VideoQuery `json:"query"`
+	StartDate string      `json:"start_date"`
+	EndDate   string      `json:"end_date"`
+	MaxCount  int         `json:"max_count,omitempty"`
+	Cursor    int64       `json:"cursor,omitempty"`
+	SearchID  string      `json:"search_id,omitempty"`
+	IsRandom  bool        `json:"is_random,omitempty"`
+}
+
+type researchVideoData struct {
+	Videos   []ResearchVideo `json:"videos"`
+	Cursor   int64           `json:"cursor"`
+	HasMore  bool            `json:"has_more"`
+	SearchID string          `json:"search_id"`
+}
+
+type researchUserRequest struct {
+	Username string `json:"username"`
+	MaxCount int    `json:"max_count,omitempty"`
+	Cursor   int64  `json:"cursor,omitempty"`
+}
+
+type researchUserListData struct {
+	UserFollowers []ResearchUserRef `json:"user_followers"`
+	UserFollowing []ResearchUserRef `json:"user_following"`
+	Cursor        int64             `json:"cursor"`
+	HasMore       bool              `json:"has_more"`
+}
+
+type researchUserVideosData struct {
+	UserLikedVideos    []ResearchVideo `json:"user_liked_videos"`
+	UserRepostedVideos []ResearchVideo `json:"user_reposted_videos"`
+	PinnedVideosList   []ResearchVideo `json:"pinned_videos_list"`
+	Cursor             int64           `json:"cursor"`
+	HasMore            bool            `json:"has_more"`
+}
+
+type researchCommentRequest struct {
+	VideoID  int64 `json:"video_id"`
+	MaxCount int   `json:"max_count,omitempty"`
+	Cursor   int64 `json:"cursor,omitempty"`
+}
+
+type researchCommentData struct {
+	Comments []ResearchComment `json:"comments"`
+	Cursor   int64             `json:"cursor"`
+	HasMore  bool              `json:"has_more"`
+}
+
+type researchPlaylistRequest struct {
+	PlaylistID int64 `json:"playlist_id"`
+	Cursor     int64 `json:"cursor,omitempty"`
+}
+
+type researchPlaylistData struct {
+	PlaylistID        int64   `json:"playlist_id"`
+	PlaylistName      string  `json:"playlist_name"`
+	PlaylistVideoIDs  []int64 `json:"playlist_video_ids"`
+	PlaylistItemTotal int64   `json:"playlist_item_total"`
+	Cursor            int64   `json:"cursor"`
+	HasMore           bool    `json:"has_more"`
+}
+
+type adQueryRequest struct {
+	Filters    adFilters `json:"filters"`
+	SearchTerm string    `json:"search_term,omitempty"`
+	MaxCount   int       `json:"max_count,omitempty"`
+	SearchID   string    `json:"search_id,omitempty"`
+}
+
+type adFilters struct {
+	AdvertiserBusinessIDs    []int64     `json:"advertiser_business_ids,omitempty"`
+	AdPublishedDateRange     *valueRange `json:"ad_published_date_range,omitempty"`
+	CountryCode              string      `json:"country_code,omitempty"`
+	UniqueUsersSeenSizeRange *valueRange `json:"unique_users_seen_size_range,omitempty"`
+}
+
+type valueRange struct {
+	Min string `json:"min"`
+	Max string `json:"max"`
+}
+
+type adQueryData struct {
+	Ads      []AdResult `json:"ads"`
+	HasMore  bool       `json:"has_more"`
+	SearchID string     `json:"search_id"`
+}
+
+type adDetailRequest struct {
+	AdID int64 `json:"ad_id"`
+}
+
+type advertiserQueryRequest struct {
+	SearchTerm string `json:"search_term"`
+	MaxCount   int    `json:"max_count,omitempty"`
+	SearchID   string `json:"search_id,omitempty"`
+}
+
+type advertiserQueryData struct {
+	Advertisers []Advertiser `json:"advertisers"`
+	HasMore     bool         `json:"has_more"`
+	SearchID    string       `json:"search_id"`
+}
+
+type dataAddRequest struct {
+	DataFormat            DataFormat     `json:"data_format"`
+	CategorySelectionList []DataCategory `json:"category_selection_list"`
+}
+
+type dataAddData struct {
+	RequestID int64 `json:"request_id"`
+}
+
+type dataRequestIDRequest struct {
+	RequestID int64 `json:"request_id"`
+}
+
+type dataStatusData struct {
+	RequestID             int64          `json:"request_id"`
+	Status                string         `json:"status"`
+	ApplyTime             int64          `json:"apply_time"`
+	CollectTime           int64          `json:"collect_time"`
+	DataFormat            DataFormat     `json:"data_format"`
+	CategorySelectionList []DataCategory `json:"category_selection_list"`
+}
+
+type webhookPayload struct {
+	ClientKey  string `json:"client_key"`
+	Event      string `json:"event"`
+	CreateTime int64  `json:"create_time"`
+	UserOpenID string `json:"user_openid"`
+	Content    string `json:"content"`
+}
+
+// webhookContent holds the content fields of all the webhook events. The reason is a number for
+// authorization events and a string for post events.
+type webhookContent struct {
+	Reason      json.RawMessage `json:"reason"`
+	ShareID     string          `json:"share_id"`
+	PublishID   string          `json:"publish_id"`
+	PublishType string          `json:"publish_type"`
+	PostID      json.Number     `json:"post_id"`
+}
@@ -0,0 +1,341 @@
+package tiktok
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	// MinChunkSize is the minimum chunk size accepted by TikTok, except for videos smaller than it.
+	MinChunkSize = 5 * 1024 * 1024
+	// MaxChunkSize is the maximum chunk size accepted by TikTok.
+	MaxChunkSize = 64 * 1024 * 1024
+	// DefaultChunkSize is the chunk size used when none is configured.
+	DefaultChunkSize = 10 * 1024 * 1024
+
+	// uploadURLLifetime is how long an upload url returned by TikTok remains valid.
+	uploadURLLifetime = time.Hour
+)
+
+var (
+	// uploadHTTPClient has no timeout as chunk uploads are bounded by the request context instead.
+	uploadHTTPClient = &http.Client{}
+)
+
+// PostInfo holds the post details used when a video is published directly to the creator's profile.
+type PostInfo struct {
+	Title                 string `json:"title,omitempty"`
+	Description           string `json:"description,omitempty"`
+	PrivacyLevel          string `json:"privacy_level"`
+	DisableDuet           bool   `json:"disable_duet,omitempty"`
+	DisableComment        bool   `json:"disable_comment,omitempty"`
+	DisableStitch         bool   `json:"disable_stitch,omitempty"`
+	VideoCoverTimestampMs int64  `json:"video_cover_timestamp_ms,omitempty"`
+	AutoAddMusic          bool   `json:"auto_add_music,omitempty"`
+}
+
+// UploadOptions configures UploadVideo.
+type UploadOptions struct {
+	// PostInfo publishes the video directly when set, otherwise the video is sent to the creator's inbox.
+	PostInfo *PostInfo
+	// ChunkSize is the size of every chunk but the last one. Defaults to DefaultChunkSize.
+	ChunkSize int64
+	// Checkpoint, if set, persists the upload state so an interrupted upload can be resumed.
+	Checkpoint UploadCheckpointStore
+	// CheckpointKey identifies the upload in the checkpoint store. Required when Checkpoint is set.
+	CheckpointKey string
+	// OnProgress, if set, is called as the bytes of every chunk are sent.
+	OnProgress func(UploadProgress)
+	// BytesPerSecond limits the upload bandwidth. Zero means unlimited.
+	BytesPerSecond int64
+}
+
+// UploadProgress describes the progress of a video upload.
+type UploadProgress struct {
+	// BytesSent is the number of bytes of the video sent so far, including chunks sent before a resume.
+	BytesSent int64
+	// TotalBytes is the size of the video.
+	TotalBytes int64
+	// ChunkIndex is the zero based index of the chunk being sent.
+	ChunkIndex int64
+	// TotalChunks is the number of chunks of the video.
+	TotalChunks int64
+	// ETA is the estimated time remaining, based on the average rate of the current upload.
+	ETA time.Duration
+}
+
+// UploadState is the persisted state of a chunked video upload.
+type UploadState struct {
+	PublishID       string    `json:"publish_id"`
+	UploadURL       string    `json:"upload_url"`
+	VideoSize       int64     `json:"video_size"`
+	ChunkSize       int64     `json:"chunk_size"`
+	TotalChunks     int64     `json:"total_chunks"`
+	CompletedChunks int64     `json:"completed_chunks"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// expired reports whether the upload url of the state can no longer be used.
+func (s *UploadState) expired() bool {
+	return time.Since(s.CreatedAt) >= uploadURLLifetime
+}
+
+// chunkRange returns the first byte offset and the length of the chunk at the given index.
+func (s *UploadState) chunkRange(index int64) (int64, int64) {
+	offset := index * s.ChunkSize
+	if index == s.TotalChunks-1 {
+		return offset, s.VideoSize - offset
+	}
+
+	return offset, s.ChunkSize
+}
+
+// errUploadURLExpired is returned by uploadChunk when TikTok no longer accepts the upload url.
+var errUploadURLExpired = fmt.Errorf("upload url expired")
+
+// UploadVideo uploads a video in chunks and returns the publish id of the post. When a checkpoint
+// store is configured, a previous upload with the same key is resumed from the last acknowledged
+// chunk, or started over when its upload url has expired.
+func UploadVideo(ctx context.Context, token *oauth2.Token, video io.ReaderAt, size int64, opts *UploadOptions) (string, error) {
+	if video == nil {
+		return "", fmt.Errorf("tiktok-oauth2: UploadVideo: video cannot be nil")
+	}
+
+	if size <= 0 {
+		return "", fmt.Errorf("tiktok-oauth2: UploadVideo: video size must be positive")
+	}
+
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+
+	if opts.Checkpoint != nil && opts.CheckpointKey == "" {
+		return "", fmt.Errorf("tiktok-oauth2: UploadVideo: checkpoint key cannot be empty")
+	}
+
+	chunkSize := opts.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	if chunkSize < MinChunkSize || chunkSize > MaxChunkSize {
+		return "", fmt.Errorf("tiktok-oauth2: UploadVideo: chunk size must be between %d and %d bytes", MinChunkSize, MaxChunkSize)
+	}
+
+	if opts.PostInfo != nil {
+		if err := ValidateCaption(opts.PostInfo.Title); err != nil {
+			return "", fmt.Errorf("tiktok-oauth2: UploadVideo: %w", err)
+		}
+	}
+
+	if opts.BytesPerSecond < 0 {
+		return "", fmt.Errorf("tiktok-oauth2: UploadVideo: bytes per second cannot be negative")
+	}
+
+	session := &uploadSession{opts: opts, started: time.Now()}
+	if opts.BytesPerSecond > 0 {
+		session.limiter = newBandwidthLimiter(opts.BytesPerSecond)
+	}
+
+	state, err := loadUploadState(ctx, opts, size)
+	if err != nil {
+		return "", fmt.Errorf("tiktok-oauth2: UploadVideo: %w", err)
+	}
+
+	for {
+		resumed := state != nil
+		if !resumed {
+			if state, err = initVideoUpload(ctx, token, size, chunkSize, opts.PostInfo); err != nil {
+				return "", fmt.Errorf("tiktok-oauth2: UploadVideo: %w", err)
+			}
+
+			if err = saveUploadState(ctx, opts, state); err != nil {
+				return "", fmt.Errorf("tiktok-oauth2: UploadVideo: %w", err)
+			}
+		}
+
+		err = session.uploadChunks(ctx, video, state)
+		if errors.Is(err, errUploadURLExpired) && resumed {
+			// The upload url of a resumed upload is no longer valid, so start over with a new one.
+			state = nil
+			continue
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("tiktok-oauth2: UploadVideo: %w", err)
+		}
+
+		break
+	}
+
+	if opts.Checkpoint != nil {
+		if err = opts.Checkpoint.Delete(ctx, opts.CheckpointKey); err != nil {
+			return "", fmt.Errorf("tiktok-oauth2: UploadVideo: %w", err)
+		}
+	}
+
+	return state.PublishID, nil
+}
+
+func loadUploadState(ctx context.Context, opts *UploadOptions, size int64) (*UploadState, error) {
+	if opts.Checkpoint == nil {
+		return nil, nil
+	}
+
+	state, err := opts.Checkpoint.Load(ctx, opts.CheckpointKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if state == nil || state.VideoSize != size || state.expired() {
+		return nil, nil
+	}
+
+	return state, nil
+}
+
+func saveUploadState(ctx context.Context, opts *UploadOptions, state *UploadState) error {
+	if opts.Checkpoint == nil {
+		return nil
+	}
+
+	return opts.Checkpoint.Save(ctx, opts.CheckpointKey, state)
+}
+
+func initVideoUpload(ctx context.Context, token *oauth2.Token, size, chunkSize int64, postInfo *PostInfo) (*UploadState, error) {
+	// Videos smaller than a chunk are uploaded as a single chunk, and the last chunk absorbs any
+	// trailing bytes, as TikTok accepts a final chunk larger than the chunk size.
+	if size < chunkSize {
+		chunkSize = size
+	}
+
+	req := videoInitRequest{
+		PostInfo: postInfo,
+		SourceInfo: videoSourceInfo{
+			Source:          "FILE_UPLOAD",
+			VideoSize:       size,
+			ChunkSize:       chunkSize,
+			TotalChunkCount: size / chunkSize,
+		},
+	}
+
+	endpoint := endpointInboxVideoInit
+	if postInfo != nil {
+		endpoint = endpointVideoInit
+	}
+
+	var data videoInitData
+	if err := doAPIRequest(ctx, http.MethodPost, endpoint, token, req, &data); err != nil {
+		return nil, err
+	}
+
+	return &UploadState{
+		PublishID:   data.PublishID,
+		UploadURL:   data.UploadURL,
+		VideoSize:   size,
+		ChunkSize:   chunkSize,
+		TotalChunks: req.SourceInfo.TotalChunkCount,
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
+// uploadSession holds the throttling and progress reporting state of a single UploadVideo call.
+type uploadSession struct {
+	opts    *UploadOptions
+	limiter *bandwidthLimiter
+	started time.Time
+	sent    int64
+}
+
+func (s *uploadSession) uploadChunks(ctx context.Context, video io.ReaderAt, state *UploadState) error {
+	for state.CompletedChunks < state.TotalChunks {
+		index := state.CompletedChunks
+		offset, length := state.chunkRange(index)
+
+		var chunkSent int64
+		chunk := &meteredReader{
+			ctx:     ctx,
+			r:       io.NewSectionReader(video, offset, length),
+			limiter: s.limiter,
+			onRead: func(n int) {
+				chunkSent += int64(n)
+				s.sent += int64(n)
+				s.reportProgress(state, index, offset+chunkSent)
+			},
+		}
+
+		if err := uploadChunk(ctx, state.UploadURL, chunk, offset, length, state.VideoSize); err != nil {
+			return err
+		}
+
+		state.CompletedChunks++
+
+		if err := saveUploadState(ctx, s.opts, state); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *uploadSession) reportProgress(state *UploadState, index, bytesSent int64) {
+	if s.opts.OnProgress == nil {
+		return
+	}
+
+	var eta time.Duration
+	if elapsed := time.Since(s.started); s.sent > 0 && elapsed > 0 {
+		rate := float64(s.sent) / elapsed.Seconds()
+		eta = time.Duration(float64(state.VideoSize-bytesSent) / rate * float64(time.Second))
+	}
+
+	s.opts.OnProgress(UploadProgress{
+		BytesSent:   bytesSent,
+		TotalBytes:  state.VideoSize,
+		ChunkIndex:  index,
+		TotalChunks: state.TotalChunks,
+		ETA:         eta,
+	})
+}
+
+func uploadChunk(ctx context.Context, uploadURL string, chunk io.Reader, offset, length, total int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, uploadURL, chunk)
+	if err != nil {
+		return err
+	}
+
+	req.ContentLength = length
+	req.Header.Set("Content-Type", "video/mp4")
+	req.Header.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, total))
+
+	call := &Call{Operation: OperationUploadChunk, Request: req}
+
+	return invoke(ctx, call, func(call *Call) error {
+		response, err := sendRequest(ctx, uploadHTTPClient, call.Request)
+		if err != nil {
+			return err
+		}
+
+		defer response.Body.Close()
+
+		call.Response = response
+
+		switch response.StatusCode {
+		case http.StatusCreated, http.StatusPartialContent, http.StatusOK:
+			return nil
+		case http.StatusForbidden, http.StatusNotFound, http.StatusGone:
+			return errUploadURLExpired
+		}
+
+		bodyBytes, _ := ioutil.ReadAll(response.Body)
+
+		return fmt.Errorf("chunk upload failed with status code %d: %s", response.StatusCode, redactString(string(bodyBytes)))
+	})
+}
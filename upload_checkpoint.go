@@ -0,0 +1,89 @@
+package tiktok
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// UploadCheckpointStore persists the state of chunked video uploads.
+type UploadCheckpointStore interface {
+	// Load returns the state stored under key, or nil if there is none.
+	Load(ctx context.Context, key string) (*UploadState, error)
+	// Save stores the state under key, replacing any previous state.
+	Save(ctx context.Context, key string, state *UploadState) error
+	// Delete removes the state stored under key, if any.
+	Delete(ctx context.Context, key string) error
+}
+
+var unsafeFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// FileCheckpointStore is an UploadCheckpointStore keeping every upload state in a JSON file
+// inside a directory.
+type FileCheckpointStore struct {
+	dir string
+}
+
+// NewFileCheckpointStore returns a new FileCheckpointStore writing to dir, creating it if needed.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: NewFileCheckpointStore: directory cannot be empty")
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: NewFileCheckpointStore: %w", err)
+	}
+
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+// Load implements UploadCheckpointStore.
+func (s *FileCheckpointStore) Load(_ context.Context, key string) (*UploadState, error) {
+	data, err := ioutil.ReadFile(s.path(key))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: FileCheckpointStore: %w", err)
+	}
+
+	var state UploadState
+	if err = json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: FileCheckpointStore: %w", err)
+	}
+
+	return &state, nil
+}
+
+// Save implements UploadCheckpointStore. The file is replaced atomically so a crash never leaves a
+// partially written state behind.
+func (s *FileCheckpointStore) Save(_ context.Context, key string, state *UploadState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileCheckpointStore: %w", err)
+	}
+
+	if err = writeFileAtomic(s.path(key), data); err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileCheckpointStore: %w", err)
+	}
+
+	return nil
+}
+
+// Delete implements UploadCheckpointStore.
+func (s *FileCheckpointStore) Delete(_ context.Context, key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("tiktok-oauth2: FileCheckpointStore: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileCheckpointStore) path(key string) string {
+	return filepath.Join(s.dir, unsafeFileNameChars.ReplaceAllString(key, "_")+".json")
+}
@@ -0,0 +1,361 @@
+package tiktok_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"github.com/jarcoal/httpmock"
+)
+
+const (
+	endpointInboxVideoInit = "https://open.tiktokapis.com/v2/post/publish/inbox/video/init/"
+	endpointVideoInit      = "https://open.tiktokapis.com/v2/post/publish/video/init/"
+	testUploadURL          = "https://open-upload.tiktokapis.com/video/?upload_id=test-upload-id"
+)
+
+// testUploadServer registers responders for the video init and chunk upload endpoints and records
+// the Content-Range header of every uploaded chunk.
+type testUploadServer struct {
+	mu           sync.Mutex
+	inits        int
+	ranges       []string
+	expiredCalls int
+}
+
+func newTestUploadServer(t *testing.T) *testUploadServer {
+	t.Helper()
+
+	srv := &testUploadServer{}
+
+	httpmock.Activate()
+	t.Cleanup(httpmock.Deactivate)
+
+	initResponder := func(req *http.Request) (*http.Response, error) {
+		srv.mu.Lock()
+		srv.inits++
+		srv.mu.Unlock()
+
+		return httpmock.NewStringResponse(http.StatusOK, responseVideoInit), nil
+	}
+
+	httpmock.RegisterResponder(http.MethodPost, endpointInboxVideoInit, initResponder)
+	httpmock.RegisterResponder(http.MethodPost, endpointVideoInit, initResponder)
+	httpmock.RegisterResponder(
+		http.MethodPut,
+		testUploadURL,
+		func(req *http.Request) (*http.Response, error) {
+			if _, err := io.Copy(ioutil.Discard, req.Body); err != nil {
+				return nil, err
+			}
+
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+
+			if srv.expiredCalls > 0 {
+				srv.expiredCalls--
+				return httpmock.NewStringResponse(http.StatusGone, ""), nil
+			}
+
+			srv.ranges = append(srv.ranges, req.Header.Get("Content-Range"))
+
+			return httpmock.NewStringResponse(http.StatusPartialContent, ""), nil
+		},
+	)
+
+	return srv
+}
+
+func testVideo(size int) *bytes.Reader {
+	return bytes.NewReader(make([]byte, size))
+}
+
+func TestUploadVideoInvalidArguments(t *testing.T) {
+	tests := []struct {
+		name          string
+		size          int64
+		opts          *tiktok.UploadOptions
+		errorContains string
+	}{
+		{
+			name:          "zero size",
+			size:          0,
+			errorContains: "UploadVideo: video size must be positive",
+		},
+		{
+			name:          "chunk size too small",
+			size:          1024,
+			opts:          &tiktok.UploadOptions{ChunkSize: 1024},
+			errorContains: "UploadVideo: chunk size must be between",
+		},
+		{
+			name:          "checkpoint without key",
+			size:          1024,
+			opts:          &tiktok.UploadOptions{Checkpoint: newTestCheckpointStore(t)},
+			errorContains: "UploadVideo: checkpoint key cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tiktok.UploadVideo(context.Background(), testNewOauthToken(t), testVideo(1024), tt.size, tt.opts)
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.errorContains) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestUploadVideoCaptionTooLong(t *testing.T) {
+	opts := &tiktok.UploadOptions{PostInfo: &tiktok.PostInfo{Title: strings.Repeat("a", 2201)}}
+
+	_, err := tiktok.UploadVideo(context.Background(), testNewOauthToken(t), testVideo(1024), 1024, opts)
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if !strings.Contains(err.Error(), "UploadVideo: caption length 2201 exceeds 2200") {
+		t.Fatalf("expected error to contain 'UploadVideo: caption length 2201 exceeds 2200', but got '%v'", err)
+	}
+}
+
+func TestUploadVideoSuccess(t *testing.T) {
+	srv := newTestUploadServer(t)
+
+	size := tiktok.MinChunkSize*2 + 100
+	opts := &tiktok.UploadOptions{ChunkSize: tiktok.MinChunkSize}
+
+	publishID, err := tiktok.UploadVideo(context.Background(), testNewOauthToken(t), testVideo(size), int64(size), opts)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if publishID != "test-publish-id" {
+		t.Fatalf("expected publish id 'test-publish-id', but got %s", publishID)
+	}
+
+	expectedRanges := []string{"bytes 0-5242879/10485860", "bytes 5242880-10485859/10485860"}
+	if strings.Join(srv.ranges, ",") != strings.Join(expectedRanges, ",") {
+		t.Fatalf("expected chunk ranges '%v', but got %v", expectedRanges, srv.ranges)
+	}
+}
+
+func TestUploadVideoResume(t *testing.T) {
+	srv := newTestUploadServer(t)
+	store := newTestCheckpointStore(t)
+
+	size := tiktok.MinChunkSize * 2
+	state := &tiktok.UploadState{
+		PublishID:       "test-publish-id",
+		UploadURL:       testUploadURL,
+		VideoSize:       int64(size),
+		ChunkSize:       tiktok.MinChunkSize,
+		TotalChunks:     2,
+		CompletedChunks: 1,
+		CreatedAt:       time.Now(),
+	}
+
+	if err := store.Save(context.Background(), "test-key", state); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &tiktok.UploadOptions{ChunkSize: tiktok.MinChunkSize, Checkpoint: store, CheckpointKey: "test-key"}
+
+	if _, err := tiktok.UploadVideo(context.Background(), testNewOauthToken(t), testVideo(size), int64(size), opts); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if srv.inits != 0 {
+		t.Fatalf("expected no upload initialization, but got %d", srv.inits)
+	}
+
+	if len(srv.ranges) != 1 || srv.ranges[0] != "bytes 5242880-10485759/10485760" {
+		t.Fatalf("expected only the second chunk to be uploaded, but got %v", srv.ranges)
+	}
+
+	stored, err := store.Load(context.Background(), "test-key")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored != nil {
+		t.Fatalf("expected checkpoint to be deleted after upload, but got %+v", stored)
+	}
+}
+
+func TestUploadVideoResumeExpired(t *testing.T) {
+	tests := []struct {
+		name         string
+		createdAt    time.Time
+		expiredCalls int
+	}{
+		{
+			name:      "checkpoint older than upload url lifetime",
+			createdAt: time.Now().Add(-time.Hour * 2),
+		},
+		{
+			name:         "upload url rejected by server",
+			createdAt:    time.Now(),
+			expiredCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newTestUploadServer(t)
+			srv.expiredCalls = tt.expiredCalls
+			store := newTestCheckpointStore(t)
+
+			size := tiktok.MinChunkSize * 2
+			state := &tiktok.UploadState{
+				PublishID:       "test-old-publish-id",
+				UploadURL:       testUploadURL,
+				VideoSize:       int64(size),
+				ChunkSize:       tiktok.MinChunkSize,
+				TotalChunks:     2,
+				CompletedChunks: 1,
+				CreatedAt:       tt.createdAt,
+			}
+
+			if err := store.Save(context.Background(), "test-key", state); err != nil {
+				t.Fatal(err)
+			}
+
+			opts := &tiktok.UploadOptions{ChunkSize: tiktok.MinChunkSize, Checkpoint: store, CheckpointKey: "test-key"}
+
+			publishID, err := tiktok.UploadVideo(context.Background(), testNewOauthToken(t), testVideo(size), int64(size), opts)
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if publishID != "test-publish-id" {
+				t.Fatalf("expected new publish id 'test-publish-id', but got %s", publishID)
+			}
+
+			if srv.inits != 1 {
+				t.Fatalf("expected 1 upload initialization, but got %d", srv.inits)
+			}
+
+			if len(srv.ranges) != 2 {
+				t.Fatalf("expected 2 chunks to be uploaded, but got %v", srv.ranges)
+			}
+		})
+	}
+}
+
+func TestUploadVideoProgress(t *testing.T) {
+	newTestUploadServer(t)
+
+	size := tiktok.MinChunkSize*2 + 100
+
+	var last tiktok.UploadProgress
+	opts := &tiktok.UploadOptions{
+		ChunkSize: tiktok.MinChunkSize,
+		OnProgress: func(progress tiktok.UploadProgress) {
+			if progress.BytesSent < last.BytesSent {
+				t.Errorf("expected bytes sent to increase, but got %d after %d", progress.BytesSent, last.BytesSent)
+			}
+
+			last = progress
+		},
+	}
+
+	if _, err := tiktok.UploadVideo(context.Background(), testNewOauthToken(t), testVideo(size), int64(size), opts); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if last.BytesSent != int64(size) || last.TotalBytes != int64(size) {
+		t.Fatalf("expected %d of %d bytes sent, but got %d of %d", size, size, last.BytesSent, last.TotalBytes)
+	}
+
+	if last.ChunkIndex != 1 || last.TotalChunks != 2 {
+		t.Fatalf("expected last chunk index 1 of 2, but got %d of %d", last.ChunkIndex, last.TotalChunks)
+	}
+
+	if last.ETA != 0 {
+		t.Fatalf("expected zero eta when upload is complete, but got %v", last.ETA)
+	}
+}
+
+func TestUploadVideoBandwidthLimit(t *testing.T) {
+	newTestUploadServer(t)
+
+	size := tiktok.MinChunkSize
+	opts := &tiktok.UploadOptions{ChunkSize: tiktok.MinChunkSize, BytesPerSecond: 10 * 1024 * 1024}
+
+	started := time.Now()
+	if _, err := tiktok.UploadVideo(context.Background(), testNewOauthToken(t), testVideo(size), int64(size), opts); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if elapsed := time.Since(started); elapsed < time.Millisecond*200 {
+		t.Fatalf("expected throttled upload to take at least 200ms, but took %v", elapsed)
+	}
+}
+
+func TestUploadVideoCancelledMidChunk(t *testing.T) {
+	newTestUploadServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	t.Cleanup(cancel)
+
+	size := tiktok.MinChunkSize
+	opts := &tiktok.UploadOptions{ChunkSize: tiktok.MinChunkSize, BytesPerSecond: 1024 * 1024}
+
+	_, err := tiktok.UploadVideo(ctx, testNewOauthToken(t), testVideo(size), int64(size), opts)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error '%v', but got '%v'", context.DeadlineExceeded, err)
+	}
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	store := newTestCheckpointStore(t)
+
+	state := &tiktok.UploadState{PublishID: "test-publish-id", CompletedChunks: 3}
+	if err := store.Save(context.Background(), "test/key", state); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	got, err := store.Load(context.Background(), "test/key")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	gotBytes, _ := json.Marshal(got)
+	expectedBytes, _ := json.Marshal(state)
+	if !bytes.Equal(gotBytes, expectedBytes) {
+		t.Fatalf("expected state '%s', but got %s", expectedBytes, gotBytes)
+	}
+
+	if err = store.Delete(context.Background(), "test/key"); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if got, _ = store.Load(context.Background(), "test/key"); got != nil {
+		t.Fatalf("expected nil state after delete, but got %+v", got)
+	}
+}
+
+func newTestCheckpointStore(t *testing.T) *tiktok.FileCheckpointStore {
+	t.Helper()
+
+	store, err := tiktok.NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store
+}
@@ -0,0 +1,55 @@
+package tiktok
+
+import (
+	"context"
+	"sync"
+)
+
+// UserInfoCache caches the information of TikTok users, keyed by their open id.
+type UserInfoCache interface {
+	// UserInfo returns the cached information of the user, or nil if there is none.
+	UserInfo(ctx context.Context, openID string) (*UserInfo, error)
+	// SaveUserInfo caches the information of the user, replacing any previous entry.
+	SaveUserInfo(ctx context.Context, openID string, info *UserInfo) error
+	// DeleteUserInfo removes the cached information of the user, if any.
+	DeleteUserInfo(ctx context.Context, openID string) error
+}
+
+// MemoryUserInfoCache is a UserInfoCache keeping user information in memory.
+type MemoryUserInfoCache struct {
+	mu    sync.RWMutex
+	users map[string]*UserInfo
+}
+
+// NewMemoryUserInfoCache returns a new empty MemoryUserInfoCache.
+func NewMemoryUserInfoCache() *MemoryUserInfoCache {
+	return &MemoryUserInfoCache{users: make(map[string]*UserInfo)}
+}
+
+// UserInfo implements UserInfoCache.
+func (c *MemoryUserInfoCache) UserInfo(_ context.Context, openID string) (*UserInfo, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.users[openID], nil
+}
+
+// SaveUserInfo implements UserInfoCache.
+func (c *MemoryUserInfoCache) SaveUserInfo(_ context.Context, openID string, info *UserInfo) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.users[openID] = info
+
+	return nil
+}
+
+// DeleteUserInfo implements UserInfoCache.
+func (c *MemoryUserInfoCache) DeleteUserInfo(_ context.Context, openID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.users, openID)
+
+	return nil
+}
@@ -0,0 +1,406 @@
+package tiktok
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// VideoInfo holds the properties of a video extracted from its MP4/MOV container.
+type VideoInfo struct {
+	// Brand is the major brand of the container, e.g. "isom", "mp42" or "qt  ".
+	Brand string
+	// Size is the size of the file in bytes.
+	Size     int64
+	Duration time.Duration
+	// Width and Height are the display dimensions, after applying the track rotation.
+	Width     int
+	Height    int
+	Rotation  int
+	FrameRate float64
+	// Codec is the sample entry type of the video track, e.g. "avc1" or "hvc1".
+	Codec string
+	// AudioCodec is the sample entry type of the first audio track, if any.
+	AudioCodec string
+}
+
+// VideoLimits describes the properties TikTok accepts for an uploaded video.
+type VideoLimits struct {
+	MaxSize      int64
+	MaxDuration  time.Duration
+	MinDimension int
+	MaxDimension int
+	MinFrameRate float64
+	MaxFrameRate float64
+	Codecs       []string
+}
+
+// DefaultVideoLimits returns the video limits documented by TikTok for the Content Posting API.
+// The maximum duration depends on the creator and should be set using WithCreatorInfo.
+func DefaultVideoLimits() VideoLimits {
+	return VideoLimits{
+		MaxSize:      4 * 1024 * 1024 * 1024,
+		MaxDuration:  time.Minute * 10,
+		MinDimension: 360,
+		MaxDimension: 4096,
+		MinFrameRate: 23,
+		MaxFrameRate: 60,
+		Codecs:       []string{"avc1", "avc3", "hvc1", "hev1", "vp08", "vp09"},
+	}
+}
+
+// WithCreatorInfo returns a copy of the limits using the maximum post duration of the creator.
+func (l VideoLimits) WithCreatorInfo(info *CreatorInfo) VideoLimits {
+	if info != nil && info.MaxVideoPostDurationSec > 0 {
+		l.MaxDuration = time.Second * time.Duration(info.MaxVideoPostDurationSec)
+	}
+
+	return l
+}
+
+// VideoValidationError lists every limit a video violates.
+type VideoValidationError struct {
+	Problems []string
+}
+
+// Error implements the error interface.
+func (e *VideoValidationError) Error() string {
+	return "video rejected: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks the video against the limits and returns a *VideoValidationError if any is violated.
+func (v *VideoInfo) Validate(limits VideoLimits) error {
+	var problems []string
+
+	if limits.MaxSize > 0 && v.Size > limits.MaxSize {
+		problems = append(problems, fmt.Sprintf("size %d exceeds %d bytes", v.Size, limits.MaxSize))
+	}
+
+	if limits.MaxDuration > 0 && v.Duration > limits.MaxDuration {
+		problems = append(problems, fmt.Sprintf("duration %v exceeds %v", v.Duration, limits.MaxDuration))
+	}
+
+	if v.Width < limits.MinDimension || v.Height < limits.MinDimension {
+		problems = append(problems, fmt.Sprintf("resolution %dx%d is below %d px", v.Width, v.Height, limits.MinDimension))
+	}
+
+	if limits.MaxDimension > 0 && (v.Width > limits.MaxDimension || v.Height > limits.MaxDimension) {
+		problems = append(problems, fmt.Sprintf("resolution %dx%d exceeds %d px", v.Width, v.Height, limits.MaxDimension))
+	}
+
+	if v.FrameRate < limits.MinFrameRate || (limits.MaxFrameRate > 0 && v.FrameRate > limits.MaxFrameRate) {
+		problems = append(problems, fmt.Sprintf("frame rate %.2f is outside %.0f-%.0f fps", v.FrameRate, limits.MinFrameRate, limits.MaxFrameRate))
+	}
+
+	if len(limits.Codecs) > 0 && !containsString(limits.Codecs, v.Codec) {
+		problems = append(problems, fmt.Sprintf("codec %q is not supported", v.Codec))
+	}
+
+	if len(problems) > 0 {
+		return &VideoValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// InspectVideo parses the ISO-BMFF container (MP4 or MOV) of a video and returns its properties.
+// Only the container metadata is read, the media data is skipped.
+func InspectVideo(r io.ReaderAt, size int64) (*VideoInfo, error) {
+	if r == nil {
+		return nil, fmt.Errorf("tiktok-oauth2: InspectVideo: reader cannot be nil")
+	}
+
+	p := &mp4Parser{r: r, info: &VideoInfo{Size: size}}
+
+	if err := p.walk(0, size, p.topLevel); err != nil {
+		return nil, fmt.Errorf("tiktok-oauth2: InspectVideo: %w", err)
+	}
+
+	if !p.foundMoov {
+		return nil, fmt.Errorf("tiktok-oauth2: InspectVideo: moov box not found")
+	}
+
+	if p.info.Codec == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: InspectVideo: video track not found")
+	}
+
+	return p.info, nil
+}
+
+// mp4Parser extracts VideoInfo from the boxes of an ISO-BMFF file.
+type mp4Parser struct {
+	r         io.ReaderAt
+	info      *VideoInfo
+	foundMoov bool
+	track     *mp4Track
+}
+
+// mp4Track holds the properties of the track being parsed.
+type mp4Track struct {
+	handler     string
+	width       int
+	height      int
+	rotation    int
+	timescale   uint32
+	duration    uint64
+	sampleCount uint64
+	codec       string
+}
+
+type boxHandler func(boxType string, offset, size int64) error
+
+// walk calls handle for every box in the range [start, end).
+func (p *mp4Parser) walk(start, end int64, handle boxHandler) error {
+	for offset := start; offset+8 <= end; {
+		header, err := p.read(offset, 8)
+		if err != nil {
+			return err
+		}
+
+		boxSize := int64(binary.BigEndian.Uint32(header[0:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+
+		switch boxSize {
+		case 0:
+			boxSize = end - offset
+		case 1:
+			largeSize, err := p.read(offset+8, 8)
+			if err != nil {
+				return err
+			}
+
+			boxSize = int64(binary.BigEndian.Uint64(largeSize))
+			headerSize = 16
+		}
+
+		if boxSize < headerSize || offset+boxSize > end {
+			return fmt.Errorf("invalid size of box %q at offset %d", boxType, offset)
+		}
+
+		if err = handle(boxType, offset+headerSize, boxSize-headerSize); err != nil {
+			return err
+		}
+
+		offset += boxSize
+	}
+
+	return nil
+}
+
+func (p *mp4Parser) read(offset, n int64) ([]byte, error) {
+	buf := make([]byte, n)
+	if _, err := p.r.ReadAt(buf, offset); err != nil {
+		return nil, err
+	}
+
+	return buf, nil
+}
+
+func (p *mp4Parser) topLevel(boxType string, offset, size int64) error {
+	switch boxType {
+	case "ftyp":
+		if size >= 4 {
+			brand, err := p.read(offset, 4)
+			if err != nil {
+				return err
+			}
+
+			p.info.Brand = string(brand)
+		}
+	case "moov":
+		p.foundMoov = true
+		return p.walk(offset, offset+size, p.moov)
+	}
+
+	return nil
+}
+
+func (p *mp4Parser) moov(boxType string, offset, size int64) error {
+	switch boxType {
+	case "mvhd":
+		data, err := p.read(offset, size)
+		if err != nil {
+			return err
+		}
+
+		timescale, duration, err := parseTimes(data)
+		if err != nil {
+			return fmt.Errorf("mvhd: %w", err)
+		}
+
+		if timescale > 0 {
+			p.info.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+		}
+	case "trak":
+		p.track = &mp4Track{}
+		if err := p.walk(offset, offset+size, p.trak); err != nil {
+			return err
+		}
+
+		p.finishTrack()
+	}
+
+	return nil
+}
+
+func (p *mp4Parser) trak(boxType string, offset, size int64) error {
+	switch boxType {
+	case "tkhd":
+		data, err := p.read(offset, size)
+		if err != nil {
+			return err
+		}
+
+		return p.track.parseTkhd(data)
+	case "mdia", "minf", "stbl":
+		return p.walk(offset, offset+size, p.trak)
+	case "mdhd":
+		data, err := p.read(offset, size)
+		if err != nil {
+			return err
+		}
+
+		timescale, duration, err := parseTimes(data)
+		if err != nil {
+			return fmt.Errorf("mdhd: %w", err)
+		}
+
+		p.track.timescale, p.track.duration = timescale, duration
+	case "hdlr":
+		if size >= 12 {
+			data, err := p.read(offset+8, 4)
+			if err != nil {
+				return err
+			}
+
+			p.track.handler = string(data)
+		}
+	case "stsd":
+		// version/flags (4), entry count (4), then the first sample entry size (4) and type (4).
+		if size >= 16 {
+			data, err := p.read(offset+12, 4)
+			if err != nil {
+				return err
+			}
+
+			p.track.codec = string(data)
+		}
+	case "stts":
+		data, err := p.read(offset, size)
+		if err != nil {
+			return err
+		}
+
+		if len(data) < 8 {
+			return fmt.Errorf("stts: box too short")
+		}
+
+		entries := int(binary.BigEndian.Uint32(data[4:8]))
+		if len(data) < 8+entries*8 {
+			return fmt.Errorf("stts: box too short")
+		}
+
+		for i := 0; i < entries; i++ {
+			p.track.sampleCount += uint64(binary.BigEndian.Uint32(data[8+i*8 : 12+i*8]))
+		}
+	}
+
+	return nil
+}
+
+func (p *mp4Parser) finishTrack() {
+	t := p.track
+
+	switch t.handler {
+	case "vide":
+		if p.info.Codec != "" {
+			return
+		}
+
+		p.info.Codec = t.codec
+		p.info.Width, p.info.Height, p.info.Rotation = t.width, t.height, t.rotation
+
+		if t.rotation == 90 || t.rotation == 270 {
+			p.info.Width, p.info.Height = t.height, t.width
+		}
+
+		if t.timescale > 0 && t.duration > 0 {
+			p.info.FrameRate = float64(t.sampleCount) / (float64(t.duration) / float64(t.timescale))
+		}
+	case "soun":
+		if p.info.AudioCodec == "" {
+			p.info.AudioCodec = t.codec
+		}
+	}
+}
+
+// parseTkhd extracts the dimensions and rotation of the track from its header.
+func (t *mp4Track) parseTkhd(data []byte) error {
+	if len(data) < 1 {
+		return fmt.Errorf("tkhd: box too short")
+	}
+
+	// The matrix starts after the version dependent times, reserved fields, layer, group and volume.
+	matrixOffset := 40
+	if data[0] == 1 {
+		matrixOffset = 52
+	}
+
+	if len(data) < matrixOffset+44 {
+		return fmt.Errorf("tkhd: box too short")
+	}
+
+	matrix := data[matrixOffset : matrixOffset+36]
+	a := int32(binary.BigEndian.Uint32(matrix[0:4]))
+	b := int32(binary.BigEndian.Uint32(matrix[4:8]))
+
+	switch {
+	case a == 0 && b > 0:
+		t.rotation = 90
+	case a < 0 && b == 0:
+		t.rotation = 180
+	case a == 0 && b < 0:
+		t.rotation = 270
+	}
+
+	// Width and height are 16.16 fixed point numbers.
+	t.width = int(binary.BigEndian.Uint32(data[matrixOffset+36:matrixOffset+40]) >> 16)
+	t.height = int(binary.BigEndian.Uint32(data[matrixOffset+40:matrixOffset+44]) >> 16)
+
+	return nil
+}
+
+// parseTimes returns the timescale and duration of a mvhd or mdhd box, which share the same layout.
+// Version 1 boxes use 64 bit times and durations.
+func parseTimes(data []byte) (uint32, uint64, error) {
+	if len(data) < 1 {
+		return 0, 0, fmt.Errorf("box too short")
+	}
+
+	if data[0] == 1 {
+		if len(data) < 32 {
+			return 0, 0, fmt.Errorf("box too short")
+		}
+
+		return binary.BigEndian.Uint32(data[20:24]), binary.BigEndian.Uint64(data[24:32]), nil
+	}
+
+	if len(data) < 20 {
+		return 0, 0, fmt.Errorf("box too short")
+	}
+
+	return binary.BigEndian.Uint32(data[12:16]), uint64(binary.BigEndian.Uint32(data[16:20])), nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
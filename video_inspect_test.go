@@ -0,0 +1,191 @@
+package tiktok_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+)
+
+func testBox(boxType string, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(data)+8))
+	copy(header[4:8], boxType)
+
+	return append(header, data...)
+}
+
+func testUint32(values ...uint32) []byte {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint32(data[i*4:], v)
+	}
+
+	return data
+}
+
+// testMP4 builds a minimal MP4 file with a single video track of 450 frames lasting 15 seconds.
+func testMP4(width, height uint32, codec string, rotate90 bool) []byte {
+	matrix := testUint32(0x10000, 0, 0, 0, 0x10000, 0, 0, 0, 0x40000000)
+	if rotate90 {
+		matrix = testUint32(0, 0x10000, 0, 0xffff0000, 0, 0, 0, 0, 0x40000000)
+	}
+
+	tkhd := testBox("tkhd",
+		testUint32(0x3, 0, 0, 1, 0, 15000, 0, 0, 0, 0),
+		matrix,
+		testUint32(width<<16, height<<16),
+	)
+
+	hdlr := testBox("hdlr", testUint32(0, 0), []byte("vide"), testUint32(0, 0, 0), []byte("VideoHandler\x00"))
+	stsd := testBox("stsd", testUint32(0, 1), testBox(codec, make([]byte, 78)))
+	stts := testBox("stts", testUint32(0, 1, 450, 1000))
+	mdhd := testBox("mdhd", testUint32(0, 0, 0, 30000, 450000, 0))
+
+	trak := testBox("trak", tkhd, testBox("mdia", mdhd, hdlr, testBox("minf", testBox("stbl", stsd, stts))))
+	mvhd := testBox("mvhd", testUint32(0, 0, 0, 1000, 15000), make([]byte, 80))
+
+	return bytes.Join([][]byte{
+		testBox("ftyp", []byte("isom"), testUint32(512), []byte("isomavc1")),
+		testBox("moov", mvhd, trak),
+		testBox("mdat", make([]byte, 64)),
+	}, nil)
+}
+
+func TestInspectVideoSuccess(t *testing.T) {
+	tests := []struct {
+		name           string
+		rotate90       bool
+		expectedWidth  int
+		expectedHeight int
+	}{
+		{
+			name:           "no rotation",
+			expectedWidth:  1080,
+			expectedHeight: 1920,
+		},
+		{
+			name:           "rotated 90 degrees",
+			rotate90:       true,
+			expectedWidth:  1920,
+			expectedHeight: 1080,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := testMP4(1080, 1920, "avc1", tt.rotate90)
+
+			info, err := tiktok.InspectVideo(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("unexpected error %v", err)
+			}
+
+			if info.Brand != "isom" {
+				t.Fatalf("expected brand 'isom', but got %s", info.Brand)
+			}
+
+			if info.Duration != time.Second*15 {
+				t.Fatalf("expected duration '15s', but got %v", info.Duration)
+			}
+
+			if info.Width != tt.expectedWidth || info.Height != tt.expectedHeight {
+				t.Fatalf("expected resolution '%dx%d', but got %dx%d", tt.expectedWidth, tt.expectedHeight, info.Width, info.Height)
+			}
+
+			if info.FrameRate != 30 {
+				t.Fatalf("expected frame rate '30', but got %v", info.FrameRate)
+			}
+
+			if info.Codec != "avc1" {
+				t.Fatalf("expected codec 'avc1', but got %s", info.Codec)
+			}
+		})
+	}
+}
+
+func TestInspectVideoError(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		errorContains string
+	}{
+		{
+			name:          "missing moov box",
+			data:          testBox("ftyp", []byte("isom")),
+			errorContains: "InspectVideo: moov box not found",
+		},
+		{
+			name:          "truncated box",
+			data:          testMP4(1080, 1920, "avc1", false)[:100],
+			errorContains: "InspectVideo: invalid size of box",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tiktok.InspectVideo(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err == nil {
+				t.Fatal("expected error but got nil")
+			}
+
+			if !strings.Contains(err.Error(), tt.errorContains) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.errorContains, err)
+			}
+		})
+	}
+}
+
+func TestVideoInfoValidate(t *testing.T) {
+	tests := []struct {
+		name             string
+		info             tiktok.VideoInfo
+		creatorInfo      *tiktok.CreatorInfo
+		expectedProblems int
+	}{
+		{
+			name:        "valid video",
+			info:        tiktok.VideoInfo{Duration: time.Second * 15, Width: 1080, Height: 1920, FrameRate: 30, Codec: "avc1"},
+			creatorInfo: &tiktok.CreatorInfo{MaxVideoPostDurationSec: 60},
+		},
+		{
+			name:             "longer than creator max duration",
+			info:             tiktok.VideoInfo{Duration: time.Second * 90, Width: 1080, Height: 1920, FrameRate: 30, Codec: "avc1"},
+			creatorInfo:      &tiktok.CreatorInfo{MaxVideoPostDurationSec: 60},
+			expectedProblems: 1,
+		},
+		{
+			name:             "bad dimensions, frame rate and codec",
+			info:             tiktok.VideoInfo{Duration: time.Second * 15, Width: 240, Height: 5000, FrameRate: 15, Codec: "mp4v"},
+			expectedProblems: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.info.Validate(tiktok.DefaultVideoLimits().WithCreatorInfo(tt.creatorInfo))
+			if tt.expectedProblems == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+
+				return
+			}
+
+			var validationErr *tiktok.VideoValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("expected error of type *tiktok.VideoValidationError, but got %T", err)
+			}
+
+			if len(validationErr.Problems) != tt.expectedProblems {
+				t.Fatalf("expected %d problems, but got %v", tt.expectedProblems, validationErr.Problems)
+			}
+		})
+	}
+}
@@ -0,0 +1,539 @@
+package tiktok
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// DefaultWebhookTolerance is the maximum age of a webhook signature accepted by default.
+const DefaultWebhookTolerance = time.Minute * 5
+
+// webhookClaimTimeout is the time a delivery has to dispatch the event it claimed in the Store
+// before another delivery may claim it.
+const webhookClaimTimeout = time.Minute * 5
+
+// maxWebhookBodySize caps the size of a webhook request body.
+const maxWebhookBodySize = 1 << 20
+
+// Webhook signature errors.
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrSignatureExpired = errors.New("webhook signature timestamp outside tolerance")
+	ErrWebhookReplayed  = errors.New("webhook already received")
+	// ErrWebhookInProgress is returned for a delivery of an event that another delivery is
+	// dispatching.
+	ErrWebhookInProgress = errors.New("webhook event being processed")
+)
+
+// WebhookEventType is the type of a TikTok webhook event.
+type WebhookEventType string
+
+// Webhook event types sent by TikTok. TikTok spells the last one "publicaly".
+const (
+	EventAuthorizationRemoved                 WebhookEventType = "authorization.removed"
+	EventVideoUploadFailed                    WebhookEventType = "video.upload.failed"
+	EventVideoPublishCompleted                WebhookEventType = "video.publish.completed"
+	EventPostPublishFailed                    WebhookEventType = "post.publish.failed"
+	EventPostPublishComplete                  WebhookEventType = "post.publish.complete"
+	EventPostPublishInboxDelivered            WebhookEventType = "post.publish.inbox_delivered"
+	EventPostPublishPubliclyAvailable         WebhookEventType = "post.publish.publicly_available"
+	EventPostPublishNoLongerPubliclyAvailable WebhookEventType = "post.publish.no_longer_publicaly_available"
+)
+
+// AuthorizationRemovedReason is the reason TikTok reports for a removed authorization.
+type AuthorizationRemovedReason int
+
+// Known authorization removed reasons.
+const (
+	AuthorizationRemovedUnknown AuthorizationRemovedReason = iota
+	AuthorizationRemovedByUser
+	AuthorizationRemovedAccountDeleted
+	AuthorizationRemovedAgeChanged
+	AuthorizationRemovedAccountBanned
+	AuthorizationRemovedByDeveloper
+)
+
+// WebhookEvent is an event received from TikTok.
+type WebhookEvent struct {
+	// ID identifies the event as the hex SHA-256 of its payload, as TikTok events carry no id and
+	// are delivered again with the same payload.
+	ID         string
+	Type       WebhookEventType
+	ClientKey  string
+	CreateTime time.Time
+	UserOpenID string
+	// Content is the raw JSON content of the event, as decoded in the typed events.
+	Content json.RawMessage
+
+	typed interface{}
+}
+
+// AuthorizationRemovedEvent is sent when a user deauthorizes the app.
+type AuthorizationRemovedEvent struct {
+	*WebhookEvent
+	Reason AuthorizationRemovedReason
+}
+
+// VideoEvent is sent when a video shared through the Share Kit fails to upload or is published.
+type VideoEvent struct {
+	*WebhookEvent
+	ShareID string
+}
+
+// PostPublishEvent is sent as a post initiated through the Content Posting API changes state.
+type PostPublishEvent struct {
+	*WebhookEvent
+	PublishID   string
+	PublishType string
+	// Reason is set for EventPostPublishFailed events.
+	Reason PublishFailReason
+	// PostID is set for the publicly available events.
+	PostID string
+}
+
+// WebhookHandler is an http.Handler receiving TikTok webhooks. It verifies the TikTok-Signature
+// header of every request, rejects replayed requests and dispatches the decoded events to the
+// registered callbacks.
+type WebhookHandler struct {
+	// Tolerance is the maximum age of a signature. Defaults to DefaultWebhookTolerance.
+	Tolerance time.Duration
+	// OnError, if set, is called with the reason of every request rejected or failed.
+	OnError func(r *http.Request, err error)
+	// Store, if set, keeps the events received, so that events delivered again once processed are
+	// acknowledged without being dispatched and past events can be replayed with Replay.
+	Store WebhookEventStore
+	// Retention is the time events are kept in the Store. Defaults to DefaultWebhookRetention.
+	Retention time.Duration
+
+	clientKey    string
+	clientSecret string
+
+	mu        sync.Mutex
+	handlers  []func(context.Context, *WebhookEvent) error
+	seen      map[string]time.Time
+	lastPrune time.Time
+}
+
+// NewWebhookHandler returns a new WebhookHandler verifying signatures with the client secret of
+// the oauth2 config returned by NewConfig.
+func NewWebhookHandler(config *oauth2.Config) (*WebhookHandler, error) {
+	if config == nil {
+		return nil, fmt.Errorf("tiktok-oauth2: NewWebhookHandler: config cannot be nil")
+	}
+
+	if config.ClientSecret == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: NewWebhookHandler: client secret cannot be empty")
+	}
+
+	return &WebhookHandler{
+		clientKey:    config.ClientID,
+		clientSecret: config.ClientSecret,
+		seen:         make(map[string]time.Time),
+	}, nil
+}
+
+// OnEvent registers a callback called with every event. Callbacks are called in the order they
+// were registered; an error stops the dispatch and makes TikTok retry the delivery.
+func (h *WebhookHandler) OnEvent(fn func(ctx context.Context, event *WebhookEvent) error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.handlers = append(h.handlers, fn)
+}
+
+// OnAuthorizationRemoved registers a callback called with every EventAuthorizationRemoved event.
+func (h *WebhookHandler) OnAuthorizationRemoved(fn func(ctx context.Context, event *AuthorizationRemovedEvent) error) {
+	h.OnEvent(func(ctx context.Context, event *WebhookEvent) error {
+		if typed, ok := event.typed.(*AuthorizationRemovedEvent); ok {
+			return fn(ctx, typed)
+		}
+
+		return nil
+	})
+}
+
+// OnVideo registers a callback called with every EventVideoUploadFailed and
+// EventVideoPublishCompleted event.
+func (h *WebhookHandler) OnVideo(fn func(ctx context.Context, event *VideoEvent) error) {
+	h.OnEvent(func(ctx context.Context, event *WebhookEvent) error {
+		if typed, ok := event.typed.(*VideoEvent); ok {
+			return fn(ctx, typed)
+		}
+
+		return nil
+	})
+}
+
+// OnPostPublish registers a callback called with every post.publish event.
+func (h *WebhookHandler) OnPostPublish(fn func(ctx context.Context, event *PostPublishEvent) error) {
+	h.OnEvent(func(ctx context.Context, event *WebhookEvent) error {
+		if typed, ok := event.typed.(*PostPublishEvent); ok {
+			return fn(ctx, typed)
+		}
+
+		return nil
+	})
+}
+
+// ServeHTTP implements http.Handler.
+func (h *WebhookHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	status, err := h.serve(w, r)
+	if err != nil && h.OnError != nil {
+		h.OnError(r, fmt.Errorf("tiktok-oauth2: WebhookHandler: %w", err))
+	}
+
+	w.WriteHeader(status)
+}
+
+func (h *WebhookHandler) serve(w http.ResponseWriter, r *http.Request) (int, error) {
+	if r.Method != http.MethodPost {
+		return http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method)
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	signature := r.Header.Get("TikTok-Signature")
+	if err = VerifyWebhookSignature(h.clientSecret, signature, body, h.tolerance()); err != nil {
+		return http.StatusUnauthorized, err
+	}
+
+	event, err := parseWebhookEvent(body)
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	if h.clientKey != "" && event.ClientKey != h.clientKey {
+		return http.StatusBadRequest, fmt.Errorf("event for client key %q", event.ClientKey)
+	}
+
+	if !h.markSeen(signature) {
+		return http.StatusConflict, ErrWebhookReplayed
+	}
+
+	if err = h.handle(r.Context(), event, body); err != nil {
+		// TikTok retries failed deliveries, which must not be rejected as replays.
+		h.unmarkSeen(signature)
+		return http.StatusInternalServerError, err
+	}
+
+	if err = h.prune(r.Context()); err != nil && h.OnError != nil {
+		h.OnError(r, fmt.Errorf("tiktok-oauth2: WebhookHandler: %w", err))
+	}
+
+	return http.StatusOK, nil
+}
+
+// handle claims the event in the Store and dispatches it, unless it was already processed or
+// another delivery of it is being dispatched.
+func (h *WebhookHandler) handle(ctx context.Context, event *WebhookEvent, body []byte) error {
+	if h.Store == nil {
+		return h.dispatch(ctx, event)
+	}
+
+	now := time.Now()
+	stored := &StoredWebhookEvent{
+		ID:           event.ID,
+		Type:         event.Type,
+		Body:         body,
+		ReceivedAt:   now,
+		ClaimedUntil: now.Add(webhookClaimTimeout),
+	}
+
+	claimed, err := h.Store.Claim(ctx, stored)
+	if err != nil {
+		return err
+	}
+
+	if !claimed {
+		if stored, err = h.Store.Get(ctx, event.ID); err != nil {
+			return err
+		}
+
+		if stored != nil && !stored.ProcessedAt.IsZero() {
+			return nil
+		}
+
+		// Another delivery of the event is dispatching it; TikTok retries this one later.
+		return ErrWebhookInProgress
+	}
+
+	if stored, err = h.Store.Get(ctx, event.ID); err != nil {
+		return err
+	}
+
+	if stored == nil {
+		return fmt.Errorf("event %s missing from store after claim", event.ID)
+	}
+
+	if err = h.dispatch(ctx, event); err != nil {
+		// Release the claim so that the retry of TikTok dispatches the event again.
+		stored.ClaimedUntil = time.Time{}
+		if saveErr := h.Store.Save(ctx, stored); saveErr != nil {
+			return fmt.Errorf("%v: %w", err, saveErr)
+		}
+
+		return err
+	}
+
+	stored.ClaimedUntil = time.Time{}
+	stored.ProcessedAt = time.Now()
+
+	return h.Store.Save(ctx, stored)
+}
+
+// prune removes the events older than the retention from the Store, at most once an hour.
+func (h *WebhookHandler) prune(ctx context.Context) error {
+	if h.Store == nil {
+		return nil
+	}
+
+	h.mu.Lock()
+	if time.Since(h.lastPrune) < time.Hour {
+		h.mu.Unlock()
+		return nil
+	}
+	h.lastPrune = time.Now()
+	h.mu.Unlock()
+
+	retention := h.Retention
+	if retention <= 0 {
+		retention = DefaultWebhookRetention
+	}
+
+	_, err := h.Store.DeleteBefore(ctx, time.Now().Add(-retention))
+
+	return err
+}
+
+// Replay dispatches again the stored events received at or after since that match, or all of
+// them when match is nil, in the order they were received, and returns how many were dispatched.
+// Stored events are dispatched whether they were processed or not, and without checking their
+// signature again as it was verified when they were received.
+func (h *WebhookHandler) Replay(ctx context.Context, since time.Time, match func(*WebhookEvent) bool) (int, error) {
+	if h.Store == nil {
+		return 0, fmt.Errorf("tiktok-oauth2: Replay: event store cannot be nil")
+	}
+
+	events, err := h.Store.List(ctx, since)
+	if err != nil {
+		return 0, fmt.Errorf("tiktok-oauth2: Replay: %w", err)
+	}
+
+	replayed := 0
+	for _, stored := range events {
+		if err = ctx.Err(); err != nil {
+			return replayed, fmt.Errorf("tiktok-oauth2: Replay: %w", err)
+		}
+
+		event, err := parseWebhookEvent(stored.Body)
+		if err != nil {
+			return replayed, fmt.Errorf("tiktok-oauth2: Replay: event %s: %w", stored.ID, err)
+		}
+
+		if match != nil && !match(event) {
+			continue
+		}
+
+		if err = h.dispatch(ctx, event); err != nil {
+			return replayed, fmt.Errorf("tiktok-oauth2: Replay: %w", err)
+		}
+
+		stored.ProcessedAt = time.Now()
+		if err = h.Store.Save(ctx, stored); err != nil {
+			return replayed, fmt.Errorf("tiktok-oauth2: Replay: %w", err)
+		}
+
+		replayed++
+	}
+
+	return replayed, nil
+}
+
+// dispatch calls the registered callbacks with the event.
+func (h *WebhookHandler) dispatch(ctx context.Context, event *WebhookEvent) error {
+	h.mu.Lock()
+	handlers := h.handlers
+	h.mu.Unlock()
+
+	for _, fn := range handlers {
+		if err := fn(ctx, event); err != nil {
+			return fmt.Errorf("%s event: %w", event.Type, err)
+		}
+	}
+
+	return nil
+}
+
+func (h *WebhookHandler) tolerance() time.Duration {
+	if h.Tolerance > 0 {
+		return h.Tolerance
+	}
+
+	return DefaultWebhookTolerance
+}
+
+// markSeen records the signature and reports whether it was not seen before. Signatures are kept
+// for the tolerance window, after which they are rejected as expired anyway.
+func (h *WebhookHandler) markSeen(signature string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for seen, at := range h.seen {
+		if now.Sub(at) > h.tolerance()*2 {
+			delete(h.seen, seen)
+		}
+	}
+
+	if _, ok := h.seen[signature]; ok {
+		return false
+	}
+
+	h.seen[signature] = now
+
+	return true
+}
+
+func (h *WebhookHandler) unmarkSeen(signature string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.seen, signature)
+}
+
+// VerifyWebhookSignature verifies the TikTok-Signature header of a webhook request body, of the
+// form "t=<unix timestamp>,s=<hex HMAC-SHA256 of timestamp.body>", and that its timestamp is
+// within the tolerance of the current time.
+func VerifyWebhookSignature(clientSecret, header string, body []byte, tolerance time.Duration) error {
+	var timestamp string
+	var signatures []string
+
+	for _, part := range strings.Split(header, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		switch kv[0] {
+		case "t":
+			timestamp = kv[1]
+		case "s":
+			signatures = append(signatures, kv[1])
+		}
+	}
+
+	if timestamp == "" || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(clientSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	valid := false
+	for _, signature := range signatures {
+		decoded, err := hex.DecodeString(signature)
+		if err == nil && hmac.Equal(decoded, expected) {
+			valid = true
+		}
+	}
+
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	age := time.Since(time.Unix(unix, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	return nil
+}
+
+// parseWebhookEvent decodes a webhook request body and its typed content.
+func parseWebhookEvent(body []byte) (*WebhookEvent, error) {
+	var payload webhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+
+	if payload.Event == "" {
+		return nil, fmt.Errorf("event type cannot be empty")
+	}
+
+	sum := sha256.Sum256(body)
+
+	event := &WebhookEvent{
+		ID:         hex.EncodeToString(sum[:]),
+		Type:       WebhookEventType(payload.Event),
+		ClientKey:  payload.ClientKey,
+		CreateTime: time.Unix(payload.CreateTime, 0),
+		UserOpenID: payload.UserOpenID,
+		Content:    json.RawMessage(payload.Content),
+	}
+
+	if payload.Content == "" {
+		event.Content = json.RawMessage("{}")
+	}
+
+	var content webhookContent
+	if err := json.Unmarshal(event.Content, &content); err != nil {
+		return nil, fmt.Errorf("%s event content: %w", event.Type, err)
+	}
+
+	switch {
+	case event.Type == EventAuthorizationRemoved:
+		var reason int
+		if len(content.Reason) > 0 {
+			if err := json.Unmarshal(content.Reason, &reason); err != nil {
+				return nil, fmt.Errorf("%s event reason: %w", event.Type, err)
+			}
+		}
+
+		event.typed = &AuthorizationRemovedEvent{WebhookEvent: event, Reason: AuthorizationRemovedReason(reason)}
+	case event.Type == EventVideoUploadFailed || event.Type == EventVideoPublishCompleted:
+		event.typed = &VideoEvent{WebhookEvent: event, ShareID: content.ShareID}
+	case strings.HasPrefix(string(event.Type), "post.publish."):
+		typed := &PostPublishEvent{
+			WebhookEvent: event,
+			PublishID:    content.PublishID,
+			PublishType:  content.PublishType,
+			PostID:       content.PostID.String(),
+		}
+
+		if event.Type == EventPostPublishFailed && len(content.Reason) > 0 {
+			var reason string
+			if err := json.Unmarshal(content.Reason, &reason); err != nil {
+				return nil, fmt.Errorf("%s event reason: %w", event.Type, err)
+			}
+
+			typed.Reason = PublishFailReason(reason)
+		}
+
+		event.typed = typed
+	}
+
+	return event, nil
+}
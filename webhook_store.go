@@ -0,0 +1,339 @@
+package tiktok
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultWebhookRetention is the time received webhook events are kept by default.
+const DefaultWebhookRetention = time.Hour * 24 * 7
+
+// StoredWebhookEvent is a webhook event kept by a WebhookEventStore.
+type StoredWebhookEvent struct {
+	// ID is the id of the event, see WebhookEvent.ID.
+	ID   string           `json:"id"`
+	Type WebhookEventType `json:"type"`
+	// Body is the raw request body of the event.
+	Body       json.RawMessage `json:"body"`
+	ReceivedAt time.Time       `json:"received_at"`
+	// ClaimedUntil is the time the delivery dispatching the event has to process it, after which
+	// another delivery may claim it, e.g. when the process crashed while dispatching it.
+	ClaimedUntil time.Time `json:"claimed_until"`
+	// ProcessedAt is zero until the event is dispatched to all the callbacks successfully.
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// WebhookEventStore keeps the webhook events received by a WebhookHandler, to deduplicate and
+// replay them.
+type WebhookEventStore interface {
+	// Claim atomically stores the event unless an event with the same id is stored, and reports
+	// whether the caller may dispatch it. An event already stored is claimed again, with the
+	// ClaimedUntil of the event, only if it is neither processed nor claimed at the ReceivedAt of
+	// the event.
+	Claim(ctx context.Context, event *StoredWebhookEvent) (bool, error)
+	// Save stores the event, replacing any stored event with the same id.
+	Save(ctx context.Context, event *StoredWebhookEvent) error
+	// Get returns the event with the given id, or nil if there is none.
+	Get(ctx context.Context, id string) (*StoredWebhookEvent, error)
+	// List returns the events received at or after since, in the order they were received.
+	List(ctx context.Context, since time.Time) ([]*StoredWebhookEvent, error)
+	// DeleteBefore removes the events received before the time and returns how many were removed.
+	DeleteBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// webhookEvents holds the events of a store by id. Its methods must be called with the lock of the
+// store held.
+type webhookEvents map[string]*StoredWebhookEvent
+
+// claim returns the event to store for a claim of the event, or nil if it cannot be claimed.
+func (e webhookEvents) claim(event *StoredWebhookEvent) *StoredWebhookEvent {
+	stored, ok := e[event.ID]
+	if !ok {
+		return copyStoredWebhookEvent(event)
+	}
+
+	if !stored.ProcessedAt.IsZero() || stored.ClaimedUntil.After(event.ReceivedAt) {
+		return nil
+	}
+
+	claimed := copyStoredWebhookEvent(stored)
+	claimed.ClaimedUntil = event.ClaimedUntil
+
+	return claimed
+}
+
+func (e webhookEvents) get(id string) *StoredWebhookEvent {
+	event, ok := e[id]
+	if !ok {
+		return nil
+	}
+
+	return copyStoredWebhookEvent(event)
+}
+
+func (e webhookEvents) list(since time.Time) []*StoredWebhookEvent {
+	events := make([]*StoredWebhookEvent, 0)
+	for _, event := range e {
+		if !event.ReceivedAt.Before(since) {
+			events = append(events, copyStoredWebhookEvent(event))
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].ReceivedAt.Before(events[j].ReceivedAt)
+	})
+
+	return events
+}
+
+// deleteBefore removes the events received before the time and returns them.
+func (e webhookEvents) deleteBefore(before time.Time) webhookEvents {
+	removed := make(webhookEvents)
+	for id, event := range e {
+		if event.ReceivedAt.Before(before) {
+			removed[id] = event
+			delete(e, id)
+		}
+	}
+
+	return removed
+}
+
+// MemoryWebhookEventStore is a WebhookEventStore keeping the events in memory, for a single
+// process.
+type MemoryWebhookEventStore struct {
+	mu     sync.Mutex
+	events webhookEvents
+}
+
+// NewMemoryWebhookEventStore returns a new empty MemoryWebhookEventStore.
+func NewMemoryWebhookEventStore() *MemoryWebhookEventStore {
+	return &MemoryWebhookEventStore{events: make(webhookEvents)}
+}
+
+// Claim implements WebhookEventStore.
+func (s *MemoryWebhookEventStore) Claim(_ context.Context, event *StoredWebhookEvent) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claimed := s.events.claim(event)
+	if claimed == nil {
+		return false, nil
+	}
+
+	s.events[event.ID] = claimed
+
+	return true, nil
+}
+
+// Save implements WebhookEventStore.
+func (s *MemoryWebhookEventStore) Save(_ context.Context, event *StoredWebhookEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.events[event.ID] = copyStoredWebhookEvent(event)
+
+	return nil
+}
+
+// Get implements WebhookEventStore.
+func (s *MemoryWebhookEventStore) Get(_ context.Context, id string) (*StoredWebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.events.get(id), nil
+}
+
+// List implements WebhookEventStore.
+func (s *MemoryWebhookEventStore) List(_ context.Context, since time.Time) ([]*StoredWebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.events.list(since), nil
+}
+
+// DeleteBefore implements WebhookEventStore.
+func (s *MemoryWebhookEventStore) DeleteBefore(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.events.deleteBefore(before)), nil
+}
+
+// FileWebhookEventStore is a WebhookEventStore keeping the events in a JSON lines file. Every
+// change appends the changed event to the file, and the file is compacted when events are deleted.
+type FileWebhookEventStore struct {
+	mu     sync.Mutex
+	path   string
+	events webhookEvents
+}
+
+// NewFileWebhookEventStore returns a new FileWebhookEventStore backed by the file at path, loading
+// any events it holds.
+func NewFileWebhookEventStore(path string) (*FileWebhookEventStore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("tiktok-oauth2: NewFileWebhookEventStore: path cannot be empty")
+	}
+
+	store := &FileWebhookEventStore{path: path, events: make(webhookEvents)}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("tiktok-oauth2: NewFileWebhookEventStore: %w", err)
+	}
+
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var event StoredWebhookEvent
+		if err = json.Unmarshal(line, &event); err != nil {
+			// The last line may have been cut short by a crash while it was appended.
+			if i == len(lines)-1 {
+				break
+			}
+
+			return nil, fmt.Errorf("tiktok-oauth2: NewFileWebhookEventStore: line %d: %w", i+1, err)
+		}
+
+		store.events[event.ID] = &event
+	}
+
+	return store, nil
+}
+
+// Claim implements WebhookEventStore.
+func (s *FileWebhookEventStore) Claim(_ context.Context, event *StoredWebhookEvent) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claimed := s.events.claim(event)
+	if claimed == nil {
+		return false, nil
+	}
+
+	if err := s.append(claimed); err != nil {
+		return false, err
+	}
+
+	s.events[event.ID] = claimed
+
+	return true, nil
+}
+
+// Save implements WebhookEventStore.
+func (s *FileWebhookEventStore) Save(_ context.Context, event *StoredWebhookEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved := copyStoredWebhookEvent(event)
+	if err := s.append(saved); err != nil {
+		return err
+	}
+
+	s.events[event.ID] = saved
+
+	return nil
+}
+
+// Get implements WebhookEventStore.
+func (s *FileWebhookEventStore) Get(_ context.Context, id string) (*StoredWebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.events.get(id), nil
+}
+
+// List implements WebhookEventStore.
+func (s *FileWebhookEventStore) List(_ context.Context, since time.Time) ([]*StoredWebhookEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.events.list(since), nil
+}
+
+// DeleteBefore implements WebhookEventStore.
+func (s *FileWebhookEventStore) DeleteBefore(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := s.events.deleteBefore(before)
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	if err := s.compact(); err != nil {
+		for id, event := range removed {
+			s.events[id] = event
+		}
+
+		return 0, err
+	}
+
+	return len(removed), nil
+}
+
+// append appends the event to the store file.
+func (s *FileWebhookEventStore) append(event *StoredWebhookEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileWebhookEventStore: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileWebhookEventStore: %w", err)
+	}
+
+	if _, err = file.Write(append(data, '\n')); err == nil {
+		err = file.Sync()
+	}
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileWebhookEventStore: %w", err)
+	}
+
+	return nil
+}
+
+// compact atomically replaces the store file with the current events, dropping the deleted events
+// and the replaced versions of the others.
+func (s *FileWebhookEventStore) compact() error {
+	var buf bytes.Buffer
+
+	for _, event := range s.events.list(time.Time{}) {
+		data, err := json.Marshal(event)
+		if err != nil {
+			return fmt.Errorf("tiktok-oauth2: FileWebhookEventStore: %w", err)
+		}
+
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	if err := writeFileAtomic(s.path, buf.Bytes()); err != nil {
+		return fmt.Errorf("tiktok-oauth2: FileWebhookEventStore: %w", err)
+	}
+
+	return nil
+}
+
+func copyStoredWebhookEvent(event *StoredWebhookEvent) *StoredWebhookEvent {
+	c := *event
+	c.Body = append(json.RawMessage(nil), event.Body...)
+
+	return &c
+}
@@ -0,0 +1,351 @@
+package tiktok_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+)
+
+func testNewFileWebhookEventStore(t *testing.T) (*tiktok.FileWebhookEventStore, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "events.json")
+
+	store, err := tiktok.NewFileWebhookEventStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return store, path
+}
+
+func TestNewFileWebhookEventStoreInvalidArguments(t *testing.T) {
+	_, err := tiktok.NewFileWebhookEventStore("")
+
+	expectedError := "path cannot be empty"
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("expected error to contain '%s', but got '%v'", expectedError, err)
+	}
+}
+
+func TestFileWebhookEventStorePersistence(t *testing.T) {
+	ctx := context.Background()
+	store, path := testNewFileWebhookEventStore(t)
+
+	now := time.Now()
+	events := []*tiktok.StoredWebhookEvent{
+		{ID: "old", Type: tiktok.EventPostPublishComplete, Body: []byte(`{}`), ReceivedAt: now.Add(-time.Hour * 48)},
+		{ID: "second", Type: tiktok.EventPostPublishComplete, Body: []byte(`{}`), ReceivedAt: now.Add(-time.Minute)},
+		{ID: "first", Type: tiktok.EventPostPublishComplete, Body: []byte(`{}`), ReceivedAt: now.Add(-time.Hour)},
+	}
+
+	for _, event := range events {
+		if err := store.Save(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	removed, err := store.DeleteBefore(ctx, now.Add(-time.Hour*24))
+	if err != nil || removed != 1 {
+		t.Fatalf("expected 1 event removed, but got %d, %v", removed, err)
+	}
+
+	reopened, err := tiktok.NewFileWebhookEventStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listed, err := reopened.List(ctx, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(listed) != 2 || listed[0].ID != "first" || listed[1].ID != "second" {
+		t.Fatalf("expected events 'first' and 'second' in order, but got %+v", listed)
+	}
+}
+
+func TestFileWebhookEventStoreAppendsChanges(t *testing.T) {
+	ctx := context.Background()
+	store, path := testNewFileWebhookEventStore(t)
+
+	event := &tiktok.StoredWebhookEvent{ID: "test-event", Body: []byte(`{}`), ReceivedAt: time.Now()}
+	if err := store.Save(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	event.ProcessedAt = time.Now()
+	if err := store.Save(ctx, event); err != nil {
+		t.Fatal(err)
+	}
+
+	// A crash while appending leaves the last line cut short.
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = file.WriteString(`{"id":"test-event","body":`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = file.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Fatalf("expected 2 appended lines, but got %d", lines)
+	}
+
+	reopened, err := tiktok.NewFileWebhookEventStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	stored, err := reopened.Get(ctx, "test-event")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if stored == nil || stored.ProcessedAt.IsZero() {
+		t.Fatalf("expected the last saved event, but got %+v", stored)
+	}
+}
+
+func TestWebhookEventStoreClaim(t *testing.T) {
+	fileStore, _ := testNewFileWebhookEventStore(t)
+
+	stores := map[string]tiktok.WebhookEventStore{
+		"memory": tiktok.NewMemoryWebhookEventStore(),
+		"file":   fileStore,
+	}
+
+	for name, store := range stores {
+		store := store
+
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+
+			claim := func(at time.Time) bool {
+				t.Helper()
+
+				event := &tiktok.StoredWebhookEvent{
+					ID:           "test-event",
+					Body:         []byte(`{}`),
+					ReceivedAt:   at,
+					ClaimedUntil: at.Add(time.Minute),
+				}
+
+				claimed, err := store.Claim(ctx, event)
+				if err != nil {
+					t.Fatalf("unexpected error %v", err)
+				}
+
+				return claimed
+			}
+
+			if !claim(now) {
+				t.Fatal("expected new event to be claimed")
+			}
+
+			if claim(now.Add(time.Second)) {
+				t.Fatal("expected claimed event not to be claimed again")
+			}
+
+			if !claim(now.Add(time.Minute * 2)) {
+				t.Fatal("expected event with expired claim to be claimed again")
+			}
+
+			stored, err := store.Get(ctx, "test-event")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !stored.ReceivedAt.Equal(now) {
+				t.Fatalf("expected claim to keep received at %v, but got %v", now, stored.ReceivedAt)
+			}
+
+			stored.ProcessedAt = now
+			if err = store.Save(ctx, stored); err != nil {
+				t.Fatal(err)
+			}
+
+			if claim(now.Add(time.Hour)) {
+				t.Fatal("expected processed event not to be claimed")
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerConcurrentDeliveries(t *testing.T) {
+	h := testNewWebhookHandler(t)
+	h.Store = tiktok.NewMemoryWebhookEventStore()
+
+	var calls int32
+
+	h.OnEvent(func(context.Context, *tiktok.WebhookEvent) error {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(time.Millisecond * 50)
+
+		return nil
+	})
+
+	body := testWebhookBody(t, tiktok.EventPostPublishComplete, `{"publish_id":"test-publish-id"}`)
+
+	var wg sync.WaitGroup
+
+	// TikTok may deliver the event again, signed anew, while the first delivery is dispatched.
+	for i := 0; i < 10; i++ {
+		req := testWebhookRequest(t, body, time.Now().Add(-time.Second*time.Duration(i)))
+
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK && rec.Code != http.StatusInternalServerError {
+				t.Errorf("unexpected status code %d", rec.Code)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("expected 1 dispatch, but got %d", calls)
+	}
+}
+
+func TestWebhookHandlerDeduplication(t *testing.T) {
+	store, _ := testNewFileWebhookEventStore(t)
+
+	h := testNewWebhookHandler(t)
+	h.Store = store
+
+	failures := 1
+	calls := 0
+
+	h.OnEvent(func(context.Context, *tiktok.WebhookEvent) error {
+		calls++
+		if failures > 0 {
+			failures--
+			return errors.New("test-error")
+		}
+
+		return nil
+	})
+
+	body := testWebhookBody(t, tiktok.EventPostPublishComplete, `{"publish_id":"test-publish-id"}`)
+
+	// TikTok delivers the event again, signed anew, after a failure and after a success.
+	expectedCodes := []int{http.StatusInternalServerError, http.StatusOK, http.StatusOK}
+	for i, expectedCode := range expectedCodes {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, testWebhookRequest(t, body, time.Now().Add(-time.Second*time.Duration(i))))
+
+		if rec.Code != expectedCode {
+			t.Fatalf("delivery %d: expected status code %d, but got %d", i, expectedCode, rec.Code)
+		}
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected 2 dispatches, but got %d", calls)
+	}
+
+	stored, err := store.List(context.Background(), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stored) != 1 || stored[0].ProcessedAt.IsZero() {
+		t.Fatalf("expected a single processed event, but got %+v", stored)
+	}
+}
+
+func TestWebhookHandlerRetention(t *testing.T) {
+	ctx := context.Background()
+	store, _ := testNewFileWebhookEventStore(t)
+
+	old := &tiktok.StoredWebhookEvent{ID: "old", Body: []byte(`{}`), ReceivedAt: time.Now().Add(-time.Hour * 48)}
+	if err := store.Save(ctx, old); err != nil {
+		t.Fatal(err)
+	}
+
+	h := testNewWebhookHandler(t)
+	h.Store = store
+	h.Retention = time.Hour * 24
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, testWebhookRequest(t, testWebhookBody(t, tiktok.EventPostPublishComplete, `{}`), time.Now()))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status code %d, but got %d", http.StatusOK, rec.Code)
+	}
+
+	if event, _ := store.Get(ctx, "old"); event != nil {
+		t.Fatal("expected event past retention to be removed")
+	}
+}
+
+func TestWebhookHandlerReplayStoredEvents(t *testing.T) {
+	store, _ := testNewFileWebhookEventStore(t)
+
+	h := testNewWebhookHandler(t)
+	h.Store = store
+
+	var published []string
+
+	h.OnPostPublish(func(_ context.Context, event *tiktok.PostPublishEvent) error {
+		published = append(published, event.PublishID)
+		return nil
+	})
+
+	for _, content := range []string{`{"publish_id":"first"}`, `{"publish_id":"second"}`} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, testWebhookRequest(t, testWebhookBody(t, tiktok.EventPostPublishComplete, content), time.Now()))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, but got %d", http.StatusOK, rec.Code)
+		}
+	}
+
+	replayed, err := h.Replay(context.Background(), time.Time{}, func(event *tiktok.WebhookEvent) bool {
+		return strings.Contains(string(event.Content), "second")
+	})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if replayed != 1 || strings.Join(published, ",") != "first,second,second" {
+		t.Fatalf("expected 'second' replayed once, but got %d replayed and %v", replayed, published)
+	}
+}
+
+func TestWebhookHandlerReplayWithoutStore(t *testing.T) {
+	_, err := testNewWebhookHandler(t).Replay(context.Background(), time.Time{}, nil)
+
+	expectedError := "event store cannot be nil"
+	if err == nil || !strings.Contains(err.Error(), expectedError) {
+		t.Fatalf("expected error to contain '%s', but got '%v'", expectedError, err)
+	}
+}
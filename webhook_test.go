@@ -0,0 +1,262 @@
+package tiktok_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chanioxaris/tiktok-oauth2"
+	"golang.org/x/oauth2"
+)
+
+// testWebhookBody returns the body of a webhook event of the test client with the given content.
+func testWebhookBody(t *testing.T, event tiktok.WebhookEventType, content string) []byte {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]interface{}{
+		"client_key":  "test-client-id",
+		"event":       event,
+		"create_time": 1700000000,
+		"user_openid": "test-open-id",
+		"content":     content,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return body
+}
+
+// testWebhookRequest returns a webhook request carrying the body, signed at the given time with the
+// secret of the test config.
+func testWebhookRequest(t *testing.T, body []byte, signedAt time.Time) *http.Request {
+	t.Helper()
+
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte("test-client-secret"))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(string(body)))
+	req.Header.Set("TikTok-Signature", "t="+timestamp+",s="+hex.EncodeToString(mac.Sum(nil)))
+
+	return req
+}
+
+func testNewWebhookHandler(t *testing.T) *tiktok.WebhookHandler {
+	t.Helper()
+
+	h, err := tiktok.NewWebhookHandler(testNewOauthConfig(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return h
+}
+
+func TestNewWebhookHandlerInvalidArguments(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        *oauth2.Config
+		expectedError string
+	}{
+		{
+			name:          "Nil config",
+			expectedError: "config cannot be nil",
+		},
+		{
+			name:          "Empty client secret",
+			config:        &oauth2.Config{ClientID: "test-client-id"},
+			expectedError: "client secret cannot be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tiktok.NewWebhookHandler(tt.config)
+			if err == nil || !strings.Contains(err.Error(), tt.expectedError) {
+				t.Fatalf("expected error to contain '%s', but got '%v'", tt.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerDispatch(t *testing.T) {
+	h := testNewWebhookHandler(t)
+
+	var got []string
+
+	h.OnEvent(func(_ context.Context, event *tiktok.WebhookEvent) error {
+		got = append(got, "event:"+string(event.Type))
+		return nil
+	})
+	h.OnAuthorizationRemoved(func(_ context.Context, event *tiktok.AuthorizationRemovedEvent) error {
+		got = append(got, "removed:"+event.UserOpenID+":"+strconv.Itoa(int(event.Reason)))
+		return nil
+	})
+	h.OnVideo(func(_ context.Context, event *tiktok.VideoEvent) error {
+		got = append(got, "video:"+event.ShareID)
+		return nil
+	})
+	h.OnPostPublish(func(_ context.Context, event *tiktok.PostPublishEvent) error {
+		got = append(got, "post:"+event.PublishID+":"+string(event.Reason)+":"+event.PostID)
+		return nil
+	})
+
+	events := []struct {
+		event   tiktok.WebhookEventType
+		content string
+	}{
+		{event: tiktok.EventAuthorizationRemoved, content: `{"reason":1}`},
+		{event: tiktok.EventVideoPublishCompleted, content: `{"share_id":"test-share-id"}`},
+		{event: tiktok.EventPostPublishFailed, content: `{"publish_id":"test-publish-id","reason":"spam_risk","publish_type":"DIRECT_PUBLISH"}`},
+		{event: tiktok.EventPostPublishPubliclyAvailable, content: `{"publish_id":"test-publish-id","post_id":"7200000000000000000"}`},
+	}
+
+	for _, e := range events {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, testWebhookRequest(t, testWebhookBody(t, e.event, e.content), time.Now()))
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status code %d, but got %d", http.StatusOK, rec.Code)
+		}
+	}
+
+	expected := []string{
+		"event:authorization.removed",
+		"removed:test-open-id:1",
+		"event:video.publish.completed",
+		"video:test-share-id",
+		"event:post.publish.failed",
+		"post:test-publish-id:spam_risk:",
+		"event:post.publish.publicly_available",
+		"post:test-publish-id::7200000000000000000",
+	}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Fatalf("expected callbacks '%v', but got %v", expected, got)
+	}
+}
+
+func TestWebhookHandlerRejects(t *testing.T) {
+	body := testWebhookBody(t, tiktok.EventAuthorizationRemoved, `{"reason":1}`)
+
+	tests := []struct {
+		name          string
+		request       func(t *testing.T) *http.Request
+		expectedCode  int
+		expectedError error
+	}{
+		{
+			name: "Wrong method",
+			request: func(t *testing.T) *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/webhook", nil)
+			},
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name: "Missing signature",
+			request: func(t *testing.T) *http.Request {
+				return httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(string(body)))
+			},
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: tiktok.ErrInvalidSignature,
+		},
+		{
+			name: "Tampered body",
+			request: func(t *testing.T) *http.Request {
+				req := testWebhookRequest(t, body, time.Now())
+				tampered := testWebhookRequest(t, testWebhookBody(t, tiktok.EventAuthorizationRemoved, `{"reason":5}`), time.Now())
+				tampered.Header = req.Header
+				return tampered
+			},
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: tiktok.ErrInvalidSignature,
+		},
+		{
+			name: "Expired signature",
+			request: func(t *testing.T) *http.Request {
+				return testWebhookRequest(t, body, time.Now().Add(-time.Hour))
+			},
+			expectedCode:  http.StatusUnauthorized,
+			expectedError: tiktok.ErrSignatureExpired,
+		},
+		{
+			name: "Malformed payload",
+			request: func(t *testing.T) *http.Request {
+				return testWebhookRequest(t, []byte(`{"event":`), time.Now())
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			h := testNewWebhookHandler(t)
+
+			var gotErr error
+			h.OnError = func(_ *http.Request, err error) {
+				gotErr = err
+			}
+
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, tt.request(t))
+
+			if rec.Code != tt.expectedCode {
+				t.Fatalf("expected status code %d, but got %d", tt.expectedCode, rec.Code)
+			}
+
+			if tt.expectedError != nil && !errors.Is(gotErr, tt.expectedError) {
+				t.Fatalf("expected error '%v', but got '%v'", tt.expectedError, gotErr)
+			}
+		})
+	}
+}
+
+func TestWebhookHandlerReplay(t *testing.T) {
+	h := testNewWebhookHandler(t)
+
+	failures := 1
+	calls := 0
+
+	h.OnEvent(func(context.Context, *tiktok.WebhookEvent) error {
+		calls++
+		if failures > 0 {
+			failures--
+			return errors.New("test-error")
+		}
+
+		return nil
+	})
+
+	req := testWebhookRequest(t, testWebhookBody(t, tiktok.EventPostPublishComplete, `{"publish_id":"test-publish-id"}`), time.Now())
+	signature := req.Header.Get("TikTok-Signature")
+
+	expectedCodes := []int{http.StatusInternalServerError, http.StatusOK, http.StatusConflict}
+	for i, expectedCode := range expectedCodes {
+		retry := testWebhookRequest(t, testWebhookBody(t, tiktok.EventPostPublishComplete, `{"publish_id":"test-publish-id"}`), time.Now())
+		retry.Header.Set("TikTok-Signature", signature)
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, retry)
+
+		if rec.Code != expectedCode {
+			t.Fatalf("delivery %d: expected status code %d, but got %d", i, expectedCode, rec.Code)
+		}
+	}
+
+	if calls != 2 {
+		t.Fatalf("expected 2 dispatches, but got %d", calls)
+	}
+}